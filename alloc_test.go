@@ -0,0 +1,66 @@
+package wltree
+
+import "testing"
+
+func TestAllocs(t *testing.T) {
+	s := random(5000, weights[1])
+	runes := []rune(string(s))
+	var buf []byte
+	buf, _ = NewInt64Keys(byteSlice(s)).MarshalBinary()
+	lazy, err := LoadLazy(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seqs := map[string]Sequence{
+		"Int64Keys":     NewInt64Keys(byteSlice(s)),
+		"LoadLazy":      lazy,
+		"WaveletMatrix": NewWaveletMatrix(byteSlice(s)),
+		"HuffmanMatrix": NewHuffmanMatrix(byteSlice(s)),
+	}
+	for name, opts := range map[string]*Options{
+		"RankOnly": {RankOnly: true}, "LazySelect": {LazySelect: true}, "RRR": {Backend: RRR},
+		"Sparse": {Sparse: true}, "RunLength": {RunLength: true}, "Arena": {Arena: true},
+		"AlphabeticShape": {Shape: AlphabeticShape},
+	} {
+		seqs[name] = NewInt64KeysWithOptions(byteSlice(s), opts)
+	}
+	for name, seq := range seqs {
+		// The first queries may build lazy directories.
+		seq.Rank('c', 2500)
+		seq.Select('c', 100)
+		seq.Access(2500)
+		if n := testing.AllocsPerRun(100, func() { seq.Rank('c', 2500) }); n != 0 {
+			t.Errorf("%v: Rank => %v allocations, want 0", name, n)
+		}
+		if n := testing.AllocsPerRun(100, func() { seq.Select('c', 100) }); n != 0 {
+			t.Errorf("%v: Select => %v allocations, want 0", name, n)
+		}
+		if n := testing.AllocsPerRun(100, func() { seq.Access(2500) }); n != 0 {
+			t.Errorf("%v: Access => %v allocations, want 0", name, n)
+		}
+	}
+
+	bytes, quad, rs := NewBytes(s), NewQuadBytes(s), NewRunes(runes)
+	dna, err := NewDNA([]byte("ACGTNACGGT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, f := range map[string]func(){
+		"Bytes.Rank":       func() { bytes.Rank('c', 2500) },
+		"Bytes.Select":     func() { bytes.Select('c', 100) },
+		"Bytes.Access":     func() { bytes.Access(2500) },
+		"QuadBytes.Rank":   func() { quad.Rank('c', 2500) },
+		"QuadBytes.Select": func() { quad.Select('c', 100) },
+		"QuadBytes.Access": func() { quad.Access(2500) },
+		"Runes.Rank":       func() { rs.Rank('c', 2500) },
+		"Runes.Select":     func() { rs.Select('c', 100) },
+		"Runes.Access":     func() { rs.Access(2500) },
+		"DNA.Rank":         func() { dna.Rank('G', 8) },
+		"DNA.Select":       func() { dna.Select('G', 1) },
+		"DNA.Access":       func() { dna.Access(4) },
+	} {
+		if n := testing.AllocsPerRun(100, f); n != 0 {
+			t.Errorf("%v => %v allocations, want 0", name, n)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package wltree
+
+import "fmt"
+
+// appendBuffer is the number of characters that AppendBytes buffers before indexing them.
+const appendBuffer = 1024
+
+// AppendBytes is a Wavelet Tree on bytestring that only grows by appending characters to s.
+// Appended characters are buffered and indexed by a static Bytes every 1024 characters, and the
+// static parts are merged as in a binary counter, so that s is covered by O(log n) parts and
+// each character is indexed O(log n) times in total. Queries combine the answers of the parts
+// and scan the buffer. Select returns -1 when s has no such occurrence.
+type AppendBytes struct {
+	// parts index s[:n-len(tail)] in order, each at least twice as long as the next.
+	parts []*Bytes
+	tail  []byte
+	n     int
+}
+
+// NewAppendBytes makes an AppendBytes from bytestring, to which further characters may be
+// appended.
+func NewAppendBytes(s []byte) *AppendBytes {
+	w := &AppendBytes{n: len(s)}
+	if len(s) > 0 {
+		w.parts = append(w.parts, NewBytes(s))
+	}
+	return w
+}
+
+// Push appends the character c to s.
+func (w *AppendBytes) Push(c byte) {
+	w.tail = append(w.tail, c)
+	w.n++
+	if len(w.tail) < appendBuffer {
+		return
+	}
+	w.parts = append(w.parts, NewBytes(w.tail))
+	w.tail = w.tail[:0]
+	for k := len(w.parts) - 1; k > 0 && w.parts[k-1].Len() < 2*w.parts[k].Len(); k-- {
+		w.parts = append(w.parts[:k-1], MergeBytes(w.parts[k-1], w.parts[k]))
+	}
+}
+
+// Compact merges the parts of w and the buffer into a single static Bytes, which makes queries
+// as fast as on Bytes until the next Push.
+func (w *AppendBytes) Compact() {
+	if len(w.parts) == 1 && len(w.tail) == 0 || w.n == 0 {
+		return
+	}
+	s := make([]byte, 0, w.n)
+	for _, p := range w.parts {
+		s = p.appendTo(s)
+	}
+	w.parts = []*Bytes{NewBytes(append(s, w.tail...))}
+	w.tail = w.tail[:0]
+}
+
+// appendTo appends s to dst and returns the result.
+func (w *Bytes) appendTo(dst []byte) []byte {
+	for i := 0; i < w.n; i++ {
+		dst = append(dst, w.Access(i))
+	}
+	return dst
+}
+
+// Len returns the length of s.
+func (w *AppendBytes) Len() int {
+	return w.n
+}
+
+// Count returns the count of the character c in s.
+func (w *AppendBytes) Count(c byte) int {
+	return w.Rank(c, w.n)
+}
+
+// Rank returns the count of the character c in s[0:i].
+// i is clamped to the range [0, Len()].
+func (w *AppendBytes) Rank(c byte, i int) int {
+	i = clamp(i, w.n)
+	r := 0
+	for _, p := range w.parts {
+		if i < p.Len() {
+			return r + p.Rank(c, i)
+		}
+		r += p.Count(c)
+		i -= p.Len()
+	}
+	for _, x := range w.tail[:i] {
+		if x == c {
+			r++
+		}
+	}
+	return r
+}
+
+// Select returns the index of the r-th occurrence of the character c, or -1 if there is none.
+func (w *AppendBytes) Select(c byte, r int) int {
+	if r < 0 {
+		return -1
+	}
+	off := 0
+	for _, p := range w.parts {
+		count := p.Count(c)
+		if r < count {
+			return off + p.Select(c, r)
+		}
+		r -= count
+		off += p.Len()
+	}
+	for i, x := range w.tail {
+		if x == c {
+			if r == 0 {
+				return off + i
+			}
+			r--
+		}
+	}
+	return -1
+}
+
+// Access returns the i-th character of s. It panics if i is out of range.
+func (w *AppendBytes) Access(i int) byte {
+	if i < 0 || i >= w.n {
+		panic(fmt.Sprintf("wltree: index %v out of range [0, %v)", i, w.n))
+	}
+	for _, p := range w.parts {
+		if i < p.Len() {
+			return p.Access(i)
+		}
+		i -= p.Len()
+	}
+	return w.tail[i]
+}
@@ -0,0 +1,60 @@
+package wltree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestAppendBytes(t *testing.T) {
+	for _, ws := range weights {
+		s := random(rand.Intn(2000), ws)
+		wt := NewAppendBytes(s)
+		for step := 0; step < 8; step++ {
+			for k := rand.Intn(3 * appendBuffer); k > 0; k-- {
+				c := random(1, ws)[0]
+				wt.Push(c)
+				s = append(s, c)
+			}
+			if step == 5 {
+				wt.Compact()
+			}
+			checkAppend(t, s, wt)
+		}
+		for k := 1; k < len(wt.parts); k++ {
+			if wt.parts[k-1].Len() < 2*wt.parts[k].Len() {
+				t.Errorf("parts %v and %v of lengths %v and %v => want halving lengths", k-1, k, wt.parts[k-1].Len(), wt.parts[k].Len())
+			}
+		}
+	}
+}
+
+func checkAppend(t *testing.T, s []byte, wt *AppendBytes) {
+	t.Helper()
+	if got := wt.Len(); got != len(s) {
+		t.Errorf("Len() => got %v, want %v", got, len(s))
+	}
+	for _, c := range []byte("acfz") {
+		count := 0
+		for i := 0; i <= len(s); i++ {
+			if i%37 == 0 || i == len(s) {
+				if got := wt.Rank(c, i); got != count {
+					t.Errorf("Rank(%q, %v) => got %v, want %v", c, i, got, count)
+				}
+			}
+			if i < len(s) && s[i] == c {
+				if got := wt.Select(c, count); got != i {
+					t.Errorf("Select(%q, %v) => got %v, want %v", c, count, got, i)
+				}
+				count++
+			}
+		}
+		if got := wt.Select(c, count); got != -1 {
+			t.Errorf("Select(%q, %v) => got %v, want -1", c, count, got)
+		}
+	}
+	for i := 0; i < len(s); i += 13 {
+		if got := wt.Access(i); got != s[i] {
+			t.Errorf("Access(%v) => got %q, want %q", i, got, s[i])
+		}
+	}
+}
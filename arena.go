@@ -0,0 +1,45 @@
+package wltree
+
+// arena is a pair of slabs from which the bits and the directories of the BitVectors of a tree
+// are allocated, so that a tree holds a few large objects instead of several per level.
+type arena struct {
+	bytes []byte
+	slab  []int
+}
+
+// newArena returns an arena for BitVectors of the given sizes, built like DefaultBackend.
+func newArena(sizes []int) *arena {
+	nbytes, nints := 0, 0
+	for _, size := range sizes {
+		nbytes += (size + 7) / 8
+		nints += size/defaultBlockBits + 1 + 2*(size/selectSample+2)
+	}
+	return &arena{bytes: make([]byte, nbytes), slab: make([]int, nints)}
+}
+
+// take returns the next n bytes of a.
+func (a *arena) take(n int) []byte {
+	b := a.bytes[:n:n]
+	a.bytes = a.bytes[n:]
+	return b
+}
+
+// ints returns the next n ints of a.
+func (a *arena) ints(n int) []int {
+	s := a.slab[:n:n]
+	a.slab = a.slab[n:]
+	return s
+}
+
+// backend returns a Backend like DefaultBackend that allocates from a. The sizes of the bit
+// vectors it builds must add up to no more than those a was made for.
+func (a *arena) backend(size int) BitVectorBuilder {
+	return &packedBuilder{
+		data:   a.take((size + 7) / 8),
+		size:   size,
+		block:  defaultBlockBits,
+		sample: selectSample,
+		eager:  true,
+		arena:  a,
+	}
+}
@@ -0,0 +1,38 @@
+package wltree
+
+import "testing"
+
+func TestArena(t *testing.T) {
+	for size := 0; size < 8*maxSize; size += 397 {
+		s := random(size, weights[1])
+		wt := NewBytesWithOptions(s, &Options{Arena: true})
+		if !wt.Equal(NewBytes(s)) {
+			t.Errorf("NewBytesWithOptions(Arena) => tree differs from default tree")
+		}
+		var counts [256]int
+		for i, c := range s {
+			if got, want := wt.Select(c, counts[c]), i; got != want {
+				t.Errorf("Select(%q, %v) => got %v, want %v", c, counts[c], got, want)
+			}
+			counts[c]++
+		}
+	}
+
+	// The bits of all levels take up the whole arena.
+	sizes := []int{5000, 4000, 1, 0, 9999}
+	a := newArena(sizes)
+	for _, size := range sizes {
+		b := a.backend(size)
+		if size == 0 {
+			b.Build()
+			continue
+		}
+		b.Set(size / 2)
+		if bv := b.Build(); bv.Rank1(size) != 1 {
+			t.Errorf("arena: Rank1(%v) => got %v, want 1", size, bv.Rank1(size))
+		}
+	}
+	if len(a.bytes) != 0 {
+		t.Errorf("arena: %v bytes left", len(a.bytes))
+	}
+}
@@ -0,0 +1,62 @@
+package wltree
+
+// RankSelect is a read-only bit vector that answers rank and select queries. A tree can be built
+// over any implementation by setting Options.Backend.
+type RankSelect interface {
+	// Len returns the number of bits.
+	Len() int
+	// Rank0 returns the number of zeros in the first i bits.
+	Rank0(i int) int
+	// Rank1 returns the number of ones in the first i bits.
+	Rank1(i int) int
+	// Select0 returns the position of the r-th zero, counting from 0.
+	Select0(r int) int
+	// Select1 returns the position of the r-th one, counting from 0.
+	Select1(r int) int
+}
+
+// BitVectorBuilder builds a RankSelect of a fixed number of bits, all initially zero.
+type BitVectorBuilder interface {
+	// Set sets the i-th bit to one.
+	Set(i int)
+	// Build returns the bit vector. The builder is not used afterwards.
+	Build() RankSelect
+}
+
+// Backend returns a BitVectorBuilder for size bits.
+type Backend func(size int) BitVectorBuilder
+
+// defaultBlockBits is the number of bits between the rank samples of DefaultBackend.
+const defaultBlockBits = 512
+
+// DefaultBackend builds plain bit vectors with rank samples every 512 bits and select samples
+// every 4096 ones and zeros, which take about a fifth more space than the bits themselves.
+func DefaultBackend(size int) BitVectorBuilder {
+	return defaultBackend(size)
+}
+
+var defaultBackend = packedBackend(defaultBlockBits, selectSample, true)
+
+// backend returns the backend selected by o.
+func (o *Options) backend() Backend {
+	switch {
+	case o == nil:
+		return DefaultBackend
+	case o.Backend != nil:
+		return o.Backend
+	case o.LazySelect:
+		return packedBackend(orDefault(o.RankSample, blockBits), orDefault(o.SelectSample, selectSample), false)
+	case o.RankOnly:
+		return packedBackend(orDefault(o.RankSample, blockBits), 0, false)
+	default:
+		return DefaultBackend
+	}
+}
+
+// orDefault returns x if it is positive, or def otherwise.
+func orDefault(x, def int) int {
+	if x > 0 {
+		return x
+	}
+	return def
+}
@@ -0,0 +1,129 @@
+package wltree
+
+import "testing"
+
+// boolBits is a naive RankSelect for testing backends.
+type boolBits []bool
+
+func (b boolBits) Set(i int) {
+	b[i] = true
+}
+
+func (b boolBits) Build() RankSelect {
+	return b
+}
+
+func (b boolBits) Len() int {
+	return len(b)
+}
+
+func (b boolBits) Rank1(i int) int {
+	r := 0
+	for _, x := range b[:i] {
+		if x {
+			r++
+		}
+	}
+	return r
+}
+
+func (b boolBits) Rank0(i int) int {
+	return i - b.Rank1(i)
+}
+
+func (b boolBits) Select1(r int) int {
+	for i, x := range b {
+		if x {
+			if r == 0 {
+				return i
+			}
+			r--
+		}
+	}
+	panic("select beyond the last bit")
+}
+
+func (b boolBits) Select0(r int) int {
+	for i, x := range b {
+		if !x {
+			if r == 0 {
+				return i
+			}
+			r--
+		}
+	}
+	panic("select beyond the last bit")
+}
+
+func TestBackend(t *testing.T) {
+	built := 0
+	opts := &Options{Backend: func(size int) BitVectorBuilder {
+		built++
+		return make(boolBits, size)
+	}}
+	s := random(maxSize, weights[1])
+	wt := NewBytesWithOptions(s, opts)
+	if built == 0 {
+		t.Errorf("NewBytesWithOptions(Backend) => backend not used")
+	}
+	if !wt.Equal(NewBytes(s)) {
+		t.Errorf("NewBytesWithOptions(Backend) => tree differs from default backend")
+	}
+	var counts [256]int
+	for i, c := range s {
+		if got, want := wt.Select(c, counts[c]), i; got != want {
+			t.Errorf("%q.Select(%q, %v) => got %v, want %v", s, c, counts[c], got, want)
+		}
+		counts[c]++
+	}
+
+	b := DefaultBackend(10)
+	b.Set(3)
+	if bv := b.Build(); bv.Len() != 10 || bv.Rank1(10) != 1 || bv.Select1(0) != 3 {
+		t.Errorf("DefaultBackend(10) => Len %v, Rank1(10) %v, Select1(0) %v", bv.Len(), bv.Rank1(10), bv.Select1(0))
+	}
+}
+
+func TestRankOnly(t *testing.T) {
+	s := random(4*maxSize, weights[1])
+	wt := NewBytesWithOptions(s, &Options{RankOnly: true})
+	for _, path := range wt.nodes {
+		for _, bv := range path {
+			if _, ok := bv.(*levelSlice).bv.(*packedBits); !ok {
+				t.Fatalf("NewBytesWithOptions(RankOnly) => %T level, want *packedBits", bv.(*levelSlice).bv)
+			}
+		}
+	}
+	if !wt.Equal(NewBytes(s)) {
+		t.Errorf("NewBytesWithOptions(RankOnly) => tree differs from default tree")
+	}
+	var counts [256]int
+	for i, c := range s {
+		if got, want := wt.Select(c, counts[c]), i; got != want {
+			t.Errorf("%q.Select(%q, %v) => got %v, want %v", s, c, counts[c], got, want)
+		}
+		counts[c]++
+	}
+}
+
+func TestSampling(t *testing.T) {
+	s := random(8*maxSize, weights[0])
+	for _, opts := range []*Options{
+		{RankOnly: true, RankSample: 1},
+		{RankOnly: true, RankSample: 100},
+		{LazySelect: true, RankSample: 64, SelectSample: 1},
+		{LazySelect: true, RankSample: 4096, SelectSample: 50},
+	} {
+		wt := NewBytesWithOptions(s, opts)
+		var counts [256]int
+		for i, c := range s {
+			if got, want := wt.Select(c, counts[c]), i; got != want {
+				t.Errorf("%+v: Select(%q, %v) => got %v, want %v", opts, c, counts[c], got, want)
+			}
+			counts[c]++
+			if got, want := wt.Rank(c, i+1), counts[c]; got != want {
+				t.Errorf("%+v: Rank(%q, %v) => got %v, want %v", opts, c, i+1, got, want)
+			}
+		}
+	}
+}
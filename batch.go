@@ -0,0 +1,150 @@
+package wltree
+
+import "fmt"
+
+// RankBatch sets out[i] to Rank(key, positions[i]) for each i, sharing the lookup of the key and
+// walking the tree one level at a time for all positions. It is fastest for sorted positions.
+// out must be at least as long as positions.
+func (w *Int64Keys) RankBatch(key int64, positions, out []int) {
+	out = out[:len(positions)]
+	for i, p := range positions {
+		out[i] = clamp(p, w.n)
+	}
+	if w.root != nil && w.root.shallow() {
+		for i, p := range out {
+			out[i] = w.root.rankShallow(key, p)
+		}
+		return
+	}
+	k, ok := w.find(key)
+	if !ok {
+		clear(out)
+		return
+	}
+	rankBatch(w.codes[k], w.nodes[k], out)
+}
+
+// RankBatch sets out[i] to Rank(c, positions[i]) for each i, sharing the lookup of c and walking
+// the tree one level at a time for all positions. It is fastest for sorted positions.
+// out must be at least as long as positions.
+func (w *Bytes) RankBatch(c byte, positions, out []int) {
+	out = out[:len(positions)]
+	for i, p := range positions {
+		out[i] = clamp(p, w.n)
+	}
+	if !w.Contains(c) {
+		clear(out)
+		return
+	}
+	if w.root.shallow() {
+		for i, p := range out {
+			out[i] = w.root.rankShallow(int64(c), p)
+		}
+		return
+	}
+	rankBatch(w.codes[c], w.nodes[c], out)
+}
+
+// rankBatch maps each position in is, from the root down the nodes along code, to the position
+// in the leaf.
+func rankBatch(code code, nodes []rankSelect, is []int) {
+	for j, node := range nodes {
+		if code.bit(j) {
+			for k, i := range is {
+				is[k] = node.Rank1(i)
+			}
+		} else {
+			for k, i := range is {
+				is[k] = node.Rank0(i)
+			}
+		}
+	}
+}
+
+// SelectBatch sets out[i] to Select(key, ranks[i]) for each i, sharing the lookup of the key and
+// walking the tree one level at a time for all ranks. It is fastest for sorted ranks. Errors are
+// reported as by Select; with ReturnNotFound, only the entries of out for missing occurrences are
+// -1. out must be at least as long as ranks.
+func (w *Int64Keys) SelectBatch(key int64, ranks, out []int) {
+	out = out[:len(ranks)]
+	k, ok := w.find(key)
+	if !selectRanks(w.n, w.Count(key), w.errorMode, ranks, out) {
+		return
+	}
+	if !ok {
+		panic(fmt.Sprintf("wltree: no such element with key %v in s.", key))
+	}
+	skip := w.errorMode == ReturnNotFound
+	if w.root.shallow() {
+		for i, r := range out {
+			if !skip || r >= 0 {
+				out[i] = w.root.selectShallow(key, r)
+			}
+		}
+		return
+	}
+	selectBatch(w.codes[k], w.nodes[k], out, skip)
+}
+
+// SelectBatch sets out[i] to Select(c, ranks[i]) for each i, sharing the lookup of c and walking
+// the tree one level at a time for all ranks. It is fastest for sorted ranks. Errors are reported
+// as by Select; with ReturnNotFound, only the entries of out for missing occurrences are -1.
+// out must be at least as long as ranks.
+func (w *Bytes) SelectBatch(c byte, ranks, out []int) {
+	out = out[:len(ranks)]
+	if !selectRanks(w.n, w.Count(c), w.errorMode, ranks, out) {
+		return
+	}
+	if !w.Contains(c) {
+		panic(fmt.Sprintf("wltree: no such character %q in s.", string(c)))
+	}
+	skip := w.errorMode == ReturnNotFound
+	if w.root.shallow() {
+		for i, r := range out {
+			if !skip || r >= 0 {
+				out[i] = w.root.selectShallow(int64(c), r)
+			}
+		}
+		return
+	}
+	selectBatch(w.codes[c], w.nodes[c], out, skip)
+}
+
+// selectRanks copies ranks into out for the tree of n elements, reporting errors by mode for a key
+// with count occurrences, and reports whether any of them remain to be selected. An empty tree
+// selects -1 for every rank, and ReturnNotFound selects -1 for the ranks of missing occurrences.
+func selectRanks(n, count int, mode ErrorMode, ranks, out []int) bool {
+	if n == 0 {
+		for i := range out {
+			out[i] = -1
+		}
+		return false
+	}
+	copy(out, ranks)
+	if mode != ReturnNotFound {
+		return true
+	}
+	for i, r := range out {
+		if r < 0 || r >= count {
+			out[i] = -1
+		}
+	}
+	return count > 0
+}
+
+// selectBatch maps each rank in rs, from the leaf up the nodes along code, to the position in the
+// root. If skip, negative ranks are left alone.
+func selectBatch(code code, nodes []rankSelect, rs []int, skip bool) {
+	for j := len(nodes) - 1; j >= 0; j-- {
+		node, one := nodes[j], code.bit(j)
+		for k, r := range rs {
+			switch {
+			case skip && r < 0:
+			case one:
+				rs[k] = node.Select1(r)
+			default:
+				rs[k] = node.Select0(r)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package wltree
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestRankBatch(t *testing.T) {
+	for size := 0; size < maxSize; size += 11 {
+		for _, ws := range weights {
+			bs := random(size, ws)
+			wt := NewBytes(bs)
+			it := NewInt64Keys(byteSlice(bs))
+			positions := make([]int, rand.Intn(20))
+			for i := range positions {
+				positions[i] = rand.Intn(size+3) - 1
+			}
+			if rand.Intn(2) == 0 {
+				sort.Ints(positions)
+			}
+			out := make([]int, len(positions)+1)
+			for c := 'a'; c <= 'e'; c++ {
+				wt.RankBatch(byte(c), positions, out)
+				for i, p := range positions {
+					if want := wt.Rank(byte(c), p); out[i] != want {
+						t.Errorf("%q: RankBatch(%q, %v)[%v] => got %v, want %v", bs, c, positions, i, out[i], want)
+					}
+				}
+				it.RankBatch(int64(c), positions, out)
+				for i, p := range positions {
+					if want := it.Rank(int64(c), p); out[i] != want {
+						t.Errorf("%q: IntKeys.RankBatch(%q, %v)[%v] => got %v, want %v", bs, c, positions, i, out[i], want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSelectBatch(t *testing.T) {
+	for _, mode := range []ErrorMode{PanicOnError, ReturnNotFound} {
+		opts := &Options{ErrorMode: mode}
+		for size := 0; size < maxSize; size += 11 {
+			for _, ws := range weights {
+				bs := random(size, ws)
+				wt := NewBytesWithOptions(bs, opts)
+				it := NewInt64KeysWithOptions(byteSlice(bs), opts)
+				for c := 'a'; c <= 'g'; c++ {
+					count := wt.Count(byte(c))
+					if count == 0 && mode == PanicOnError && size > 0 {
+						continue
+					}
+					ranks := make([]int, rand.Intn(20))
+					for i := range ranks {
+						if mode == ReturnNotFound {
+							ranks[i] = rand.Intn(count+3) - 1
+						} else if count > 0 {
+							ranks[i] = rand.Intn(count)
+						}
+					}
+					if rand.Intn(2) == 0 {
+						sort.Ints(ranks)
+					}
+					out := make([]int, len(ranks))
+					wt.SelectBatch(byte(c), ranks, out)
+					for i, r := range ranks {
+						if want := wt.Select(byte(c), r); out[i] != want {
+							t.Errorf("%q: SelectBatch(%q, %v)[%v] => got %v, want %v", bs, c, ranks, i, out[i], want)
+						}
+					}
+					it.SelectBatch(int64(c), ranks, out)
+					for i, r := range ranks {
+						if want := it.Select(int64(c), r); out[i] != want {
+							t.Errorf("%q: IntKeys.SelectBatch(%q, %v)[%v] => got %v, want %v", bs, c, ranks, i, out[i], want)
+						}
+					}
+				}
+			}
+		}
+	}
+}
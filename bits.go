@@ -0,0 +1,281 @@
+package wltree
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"sync"
+)
+
+// blockBits is the default number of bits between the samples of the rank directory of
+// packedBits.
+const blockBits = 2048
+
+// packedBits is a read-only bit vector over size bits packed 8 per byte, LSB first, as in the
+// serialized form. It refers to the bytes without copying them, and answers rank and select with a
+// directory of the ranks sampled every block bits.
+type packedBits struct {
+	data  []byte
+	size  int
+	block int
+	ranks []int
+}
+
+// newPackedBits returns a packedBits over the first size bits of data, with rank samples every
+// blockBits bits.
+func newPackedBits(data []byte, size int) *packedBits {
+	return newSampledBits(data, size, blockBits, nil)
+}
+
+// newSampledBits returns a packedBits over the first size bits of data, with rank samples every
+// block bits, which must be a positive multiple of 8. The samples are stored in ranks if it is
+// not nil, which must then have size/block+1 elements.
+func newSampledBits(data []byte, size, block int, ranks []int) *packedBits {
+	if ranks == nil {
+		ranks = make([]int, size/block+1)
+	}
+	b := &packedBits{data: data, size: size, block: block, ranks: ranks}
+	for j := 1; j < len(b.ranks); j++ {
+		b.ranks[j] = b.ranks[j-1] + b.count((j-1)*block/8, j*block/8)
+	}
+	return b
+}
+
+// count returns the number of ones in the bytes data[from:to].
+func (b *packedBits) count(from, to int) int {
+	n := 0
+	for ; from+8 <= to; from += 8 {
+		n += bits.OnesCount64(binary.LittleEndian.Uint64(b.data[from:]))
+	}
+	for ; from < to; from++ {
+		n += bits.OnesCount8(b.data[from])
+	}
+	return n
+}
+
+// clone returns a copy of b that shares no memory with it, or nil if b is nil.
+func (b *packedBits) clone() *packedBits {
+	if b == nil {
+		return nil
+	}
+	return &packedBits{data: append([]byte(nil), b.data...), size: b.size, block: b.block,
+		ranks: append([]int(nil), b.ranks...)}
+}
+
+func (b *packedBits) Len() int {
+	return b.size
+}
+
+func (b *packedBits) Rank1(i int) int {
+	j := i / b.block
+	r := b.ranks[j] + b.count(j*b.block/8, i/8)
+	if i%8 != 0 {
+		r += bits.OnesCount8(b.data[i/8] & (1<<uint(i%8) - 1))
+	}
+	return r
+}
+
+func (b *packedBits) Rank0(i int) int {
+	return i - b.Rank1(i)
+}
+
+func (b *packedBits) Select1(r int) int {
+	return b.selectBit(r, 0, len(b.ranks), true)
+}
+
+func (b *packedBits) Select0(r int) int {
+	return b.selectBit(r, 0, len(b.ranks), false)
+}
+
+// before returns the number of ones before block j if one, or of zeros otherwise.
+func (b *packedBits) before(j int, one bool) int {
+	if one {
+		return b.ranks[j]
+	}
+	return j*b.block - b.ranks[j]
+}
+
+// word returns the 64 bits of data from byte i on, with zeros past its end.
+func (b *packedBits) word(i int) uint64 {
+	if i+8 <= len(b.data) {
+		return binary.LittleEndian.Uint64(b.data[i:])
+	}
+	var x uint64
+	for k, c := range b.data[i:] {
+		x |= uint64(c) << uint(8*k)
+	}
+	return x
+}
+
+// selectBit returns the position of the r-th one if one, or zero otherwise, which is known to be in
+// the blocks lo to hi-1.
+func (b *packedBits) selectBit(r, lo, hi int, one bool) int {
+	if r < 0 {
+		panic("wltree: select with negative rank")
+	}
+	// The last block whose preceding bits number no more than r holds the r-th bit.
+	for hi-lo > 1 {
+		mid := int(uint(lo+hi) >> 1)
+		if b.before(mid, one) <= r {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	r -= b.before(lo, one)
+	for i := lo * b.block / 8; i < len(b.data); i += 8 {
+		x := b.word(i)
+		if !one {
+			x = ^x
+		}
+		if n := bits.OnesCount64(x); r >= n {
+			r -= n
+			continue
+		}
+		for ; r > 0; r-- {
+			x &= x - 1
+		}
+		if pos := 8*i + bits.TrailingZeros64(x); pos < b.size {
+			return pos
+		}
+		break
+	}
+	panic("wltree: select beyond the last bit")
+}
+
+// lazyBits is a packedBits whose rank directory is built when it is first queried.
+type lazyBits struct {
+	data []byte
+	size int
+	once sync.Once
+	bits *packedBits
+}
+
+func (b *lazyBits) get() *packedBits {
+	b.once.Do(func() { b.bits = newPackedBits(b.data, b.size) })
+	return b.bits
+}
+
+func (b *lazyBits) Len() int {
+	return b.size
+}
+
+func (b *lazyBits) Rank1(i int) int {
+	return b.get().Rank1(i)
+}
+
+func (b *lazyBits) Rank0(i int) int {
+	return b.get().Rank0(i)
+}
+
+func (b *lazyBits) Select1(r int) int {
+	return b.get().Select1(r)
+}
+
+func (b *lazyBits) Select0(r int) int {
+	return b.get().Select0(r)
+}
+
+// built reports whether the rank directory of b has been built.
+func (b *lazyBits) built() bool {
+	return b.bits != nil
+}
+
+// selectSample is the default number of ones, or zeros, between the samples of the select
+// directories of lazySelectBits.
+const selectSample = 4096
+
+// lazySelectBits is a packedBits with select directories, which hold the blocks of every
+// sample-th one and zero, built on the first Select1 and Select0 respectively.
+type lazySelectBits struct {
+	*packedBits
+	sample int
+	once   [2]sync.Once
+	dirs   [2][]int
+}
+
+func (b *lazySelectBits) Select1(r int) int {
+	return b.selectSampled(r, true)
+}
+
+func (b *lazySelectBits) Select0(r int) int {
+	return b.selectSampled(r, false)
+}
+
+// dir returns the select directory of the ones if one, or of the zeros otherwise, building it if
+// needed.
+func (b *lazySelectBits) dir(one bool) []int {
+	k := 0
+	if one {
+		k = 1
+	}
+	b.once[k].Do(func() {
+		for r, j := 0, 0; ; r += b.sample {
+			for j+1 < len(b.ranks) && b.before(j+1, one) <= r {
+				j++
+			}
+			b.dirs[k] = append(b.dirs[k], j)
+			if j == len(b.ranks)-1 {
+				break
+			}
+		}
+	})
+	return b.dirs[k]
+}
+
+// selectSampled is like selectBit, but narrows the search down with a select directory.
+func (b *lazySelectBits) selectSampled(r int, one bool) int {
+	dir := b.dir(one)
+	if r < 0 {
+		panic("wltree: select with negative rank")
+	}
+	i := min(r/b.sample, len(dir)-1)
+	hi := len(b.ranks)
+	if i+1 < len(dir) {
+		hi = dir[i+1] + 1
+	}
+	return b.selectBit(r, dir[i], hi, one)
+}
+
+// packedBackend returns a Backend that builds packedBits with rank samples every block bits,
+// rounded up to a multiple of 64. If sample is positive they are lazySelectBits with select
+// samples every sample bits, built at once if eager.
+func packedBackend(block, sample int, eager bool) Backend {
+	block = (block + 63) / 64 * 64
+	return func(size int) BitVectorBuilder {
+		return &packedBuilder{data: make([]byte, (size+7)/8), size: size, block: block, sample: sample, eager: eager}
+	}
+}
+
+type packedBuilder struct {
+	data          []byte
+	size          int
+	block, sample int
+	eager         bool
+	// arena, if not nil, holds data and provides the directories.
+	arena *arena
+}
+
+func (b *packedBuilder) Set(i int) {
+	b.data[i/8] |= 1 << uint(i%8)
+}
+
+func (b *packedBuilder) Build() RankSelect {
+	var ranks []int
+	if b.arena != nil {
+		ranks = b.arena.ints(b.size/b.block + 1)
+	}
+	bits := newSampledBits(b.data, b.size, b.block, ranks)
+	if b.sample <= 0 {
+		return bits
+	}
+	v := &lazySelectBits{packedBits: bits, sample: b.sample}
+	if b.arena != nil {
+		v.dirs[0] = b.arena.ints(b.size/b.sample + 2)[:0]
+		v.dirs[1] = b.arena.ints(b.size/b.sample + 2)[:0]
+	}
+	if b.eager {
+		v.dir(false)
+		v.dir(true)
+	}
+	return v
+}
@@ -0,0 +1,93 @@
+package wltree
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestPackedBits(t *testing.T) {
+	for _, size := range []int{0, 1, 7, 8, 9, 63, 64, 65, 2047, 2048, 2049, 5000, 8192} {
+		for _, density := range []float64{0, 0.01, 0.5, 0.99, 1} {
+			data := make([]byte, (size+7)/8)
+			var ones, zeros []int
+			for i := 0; i < size; i++ {
+				if rand.Float64() < density {
+					data[i/8] |= 1 << uint(i%8)
+					ones = append(ones, i)
+				} else {
+					zeros = append(zeros, i)
+				}
+			}
+			b := newPackedBits(data, size)
+
+			rank := 0
+			for i := 0; i <= size; i++ {
+				if got := b.Rank1(i); got != rank {
+					t.Fatalf("size %v: Rank1(%v) => got %v, want %v", size, i, got, rank)
+				}
+				if got := b.Rank0(i); got != i-rank {
+					t.Fatalf("size %v: Rank0(%v) => got %v, want %v", size, i, got, i-rank)
+				}
+				if i < size && data[i/8]&(1<<uint(i%8)) != 0 {
+					rank++
+				}
+			}
+			for r, want := range ones {
+				if got := b.Select1(r); got != want {
+					t.Fatalf("size %v: Select1(%v) => got %v, want %v", size, r, got, want)
+				}
+			}
+			for r, want := range zeros {
+				if got := b.Select0(r); got != want {
+					t.Fatalf("size %v: Select0(%v) => got %v, want %v", size, r, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestLazySelectBits(t *testing.T) {
+	for _, size := range []int{0, 1, 100, 3*blockBits + 5, 5 * selectSample} {
+		for _, density := range []float64{0, 0.1, 0.5, 1} {
+			want := make(boolBits, size)
+			b := packedBackend(blockBits, selectSample, false)(size)
+			for i := range want {
+				if rand.Float64() < density {
+					want[i] = true
+					b.Set(i)
+				}
+			}
+			got := b.Build()
+			ones := 0
+			for i := 0; i < size; i++ {
+				if want[i] {
+					if p := got.Select1(ones); p != i {
+						t.Fatalf("lazySelectBits(%v, %v).Select1(%v) => got %v, want %v", size, density, ones, p, i)
+					}
+					ones++
+				} else if p := got.Select0(i - ones); p != i {
+					t.Fatalf("lazySelectBits(%v, %v).Select0(%v) => got %v, want %v", size, density, i-ones, p, i)
+				}
+			}
+		}
+	}
+
+	s := random(4*maxSize, weights[1])
+	wt := NewBytesWithOptions(s, &Options{LazySelect: true})
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var counts [256]int
+			for i, c := range s {
+				if got, want := wt.Select(c, counts[c]), i; got != want {
+					t.Errorf("%q.Select(%q, %v) => got %v, want %v", s, c, counts[c], got, want)
+				}
+				counts[c]++
+			}
+		}()
+	}
+	wg.Wait()
+}
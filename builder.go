@@ -0,0 +1,104 @@
+package wltree
+
+import (
+	"context"
+	"iter"
+)
+
+// Builder builds Wavelet Trees configured alike, reusing its scratch memory from one build to the
+// next, which saves allocations and garbage when building many small trees. The trees share no
+// memory with the Builder or with each other. A Builder must not be used by several goroutines at
+// once.
+type Builder struct {
+	opts    *Options
+	scratch scratch
+}
+
+// NewBuilder returns a Builder of trees configured by opts, which may be nil.
+func NewBuilder(opts *Options) *Builder {
+	return &Builder{opts: opts}
+}
+
+// Int64Keys is like NewInt64KeysWithOptions with the options of b.
+func (b *Builder) Int64Keys(s Interface) *Int64Keys {
+	return b.int64Keys(context.Background(), s)
+}
+
+// Int64KeysContext is like Int64Keys, but stops early and returns ctx.Err() once ctx is done.
+func (b *Builder) Int64KeysContext(ctx context.Context, s Interface) (*Int64Keys, error) {
+	if w := b.int64Keys(ctx, s); w != nil {
+		return w, nil
+	}
+	return nil, ctx.Err()
+}
+
+// int64Keys makes a Wavelet Tree on s with the options of b, or returns nil once ctx is done.
+func (b *Builder) int64Keys(ctx context.Context, s Interface) *Int64Keys {
+	opts := b.opts
+	var keyset []int64
+	var counts []int
+	p := opts.progress(ctx, PhaseCount)
+	if workers := opts.workers(s.Len()); workers > 1 {
+		keyset, counts = freqOf(s, workers, p)
+	} else {
+		keyset, counts = b.scratch.freq(p.seq(all(s), s.Len(), true))
+	}
+	w := newShapedInt64Keys(ctx, all(s), keyset, counts, opts, &b.scratch)
+	if w != nil {
+		w.configure(opts)
+	}
+	return w
+}
+
+// Bytes is like NewBytesWithOptions with the options of b.
+func (b *Builder) Bytes(s []byte) *Bytes {
+	return newBytes(context.Background(), s, nil, b.opts, &b.scratch)
+}
+
+// BytesContext is like Bytes, but stops early and returns ctx.Err() once ctx is done.
+func (b *Builder) BytesContext(ctx context.Context, s []byte) (*Bytes, error) {
+	if w := newBytes(ctx, s, nil, b.opts, &b.scratch); w != nil {
+		return w, nil
+	}
+	return nil, ctx.Err()
+}
+
+// Reset releases the scratch memory of b, for example after building an unusually large tree.
+func (b *Builder) Reset() {
+	b.scratch = scratch{}
+}
+
+// scratch holds the memory that construction uses only temporarily, so that it can be reused.
+type scratch struct {
+	freqs  map[int64]int
+	codeOf map[int64]string
+	index  map[string]int
+
+	// ids, next and paths number the nodes of trees on bytes.
+	ids   map[string]int
+	next  []int
+	paths [256][]int
+}
+
+// reuse returns the map *m emptied, making it first if needed.
+func reuse[K comparable, V any](m *map[K]V) map[K]V {
+	if *m == nil {
+		*m = make(map[K]V)
+	} else {
+		clear(*m)
+	}
+	return *m
+}
+
+// freq is like the function freq, but counts the keys in the map of sc.
+func (sc *scratch) freq(seq iter.Seq[int64]) (keyset []int64, counts []int) {
+	freqs := reuse(&sc.freqs)
+	for k := range seq {
+		freqs[k]++
+	}
+	for k, w := range freqs {
+		keyset = append(keyset, k)
+		counts = append(counts, w)
+	}
+	return
+}
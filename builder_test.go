@@ -0,0 +1,32 @@
+package wltree
+
+import "testing"
+
+func TestBuilder(t *testing.T) {
+	for _, opts := range []*Options{nil, {Shape: BalancedShape}, {RunLength: true}, {Workers: 2}} {
+		b := NewBuilder(opts)
+		var inputs [][]byte
+		var bytes []*Bytes
+		var ints []*Int64Keys
+		for size := 0; size < maxSize; size += 23 {
+			for _, ws := range weights {
+				bs := random(size, ws)
+				inputs = append(inputs, bs)
+				bytes = append(bytes, b.Bytes(bs))
+				ints = append(ints, b.Int64Keys(byteSlice(bs)))
+			}
+			if size%5 == 0 {
+				b.Reset()
+			}
+		}
+		// Trees built earlier must be unaffected by the builds that reused the scratch memory.
+		for i, bs := range inputs {
+			if want := NewBytesWithOptions(bs, opts); !bytes[i].Equal(want) {
+				t.Errorf("%+v: Builder.Bytes(%q) => differs from NewBytesWithOptions", opts, bs)
+			}
+			if want := NewInt64KeysWithOptions(byteSlice(bs), opts); !ints[i].Equal(want) {
+				t.Errorf("%+v: Builder.Int64Keys(%q) => differs from NewInt64KeysWithOptions", opts, bs)
+			}
+		}
+	}
+}
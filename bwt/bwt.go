@@ -0,0 +1,190 @@
+/*
+Package bwt computes suffix arrays and the Burrows-Wheeler transform (BWT) of texts, the building
+blocks of self-indexes such as wltree/fmindex.
+
+Texts are taken to end with a sentinel that is smaller than any byte and occurs nowhere else. The
+BWT of a text of n bytes is then the n+1 bytes preceding its suffixes in sorted order, with the
+sentinel preceding the whole text. Transform returns these bytes without the sentinel, and its row
+as the primary index, from which Inverse recovers the text.
+
+The suffix array is built by induced sorting (SA-IS) in O(n) time.
+*/
+package bwt
+
+// SuffixArray returns the suffix array of text followed by the sentinel, that is, the starting
+// positions of its n+1 suffixes in sorted order. The first is always n, the sentinel alone.
+func SuffixArray(text []byte) []int {
+	s := make([]int, len(text)+1)
+	for i, c := range text {
+		s[i] = int(c) + 1
+	}
+	return sais(s, 257)
+}
+
+// Transform returns the BWT of text without the sentinel, and the primary index, the row of the
+// BWT of the text followed by the sentinel at which the sentinel is.
+func Transform(text []byte) (bwt []byte, primary int) {
+	bwt = make([]byte, 0, len(text))
+	for i, p := range SuffixArray(text) {
+		if p == 0 {
+			primary = i
+		} else {
+			bwt = append(bwt, text[p-1])
+		}
+	}
+	return bwt, primary
+}
+
+// Inverse returns the text whose BWT and primary index are those returned by Transform.
+func Inverse(bwt []byte, primary int) []byte {
+	n := len(bwt)
+	// at returns the byte at row i of the BWT with the sentinel, for i other than primary.
+	at := func(i int) byte {
+		if i < primary {
+			return bwt[i]
+		}
+		return bwt[i-1]
+	}
+
+	// occ holds for each row the number of rows before it with the same byte, and c for each byte
+	// the first row of the suffixes that start with it, after that of the sentinel.
+	occ := make([]int, n+1)
+	var counts [256]int
+	for i := 0; i <= n; i++ {
+		if i != primary {
+			occ[i] = counts[at(i)]
+			counts[at(i)]++
+		}
+	}
+	var c [256]int
+	sum := 1
+	for b := range counts {
+		c[b] = sum
+		sum += counts[b]
+	}
+
+	// Row 0 is the sentinel alone, preceded by the last byte of the text.
+	text := make([]byte, n)
+	row := 0
+	for k := n - 1; k >= 0; k-- {
+		b := at(row)
+		text[k] = b
+		row = c[b] + occ[row]
+	}
+	return text
+}
+
+// sais returns the suffix array of s, whose symbols are in [0, k), and whose last symbol is 0 and
+// occurs nowhere else.
+func sais(s []int, k int) []int {
+	n := len(s)
+	sa := make([]int, n)
+	if n == 1 {
+		return sa
+	}
+
+	// A suffix is S-type if it is smaller than the next one, and L-type otherwise. LMS suffixes are
+	// S-type suffixes that follow L-type ones.
+	stype := make([]bool, n)
+	stype[n-1] = true
+	for i := n - 2; i >= 0; i-- {
+		stype[i] = s[i] < s[i+1] || s[i] == s[i+1] && stype[i+1]
+	}
+	lms := func(i int) bool {
+		return i > 0 && stype[i] && !stype[i-1]
+	}
+
+	counts := make([]int, k)
+	for _, c := range s {
+		counts[c]++
+	}
+	heads, tails := make([]int, k), make([]int, k)
+	bounds := func() {
+		sum := 0
+		for c, count := range counts {
+			heads[c] = sum
+			sum += count
+			tails[c] = sum
+		}
+	}
+
+	// induce sorts the suffixes given the LMS suffixes in sorted order: it puts these at the ends
+	// of their buckets, then the L-type suffixes at the starts of theirs by scanning forward, and
+	// then the S-type suffixes at the ends of theirs by scanning backward.
+	induce := func(order []int) {
+		for i := range sa {
+			sa[i] = -1
+		}
+		bounds()
+		for j := len(order) - 1; j >= 0; j-- {
+			p := order[j]
+			tails[s[p]]--
+			sa[tails[s[p]]] = p
+		}
+		bounds()
+		for i := 0; i < n; i++ {
+			if p := sa[i] - 1; p >= 0 && !stype[p] {
+				sa[heads[s[p]]] = p
+				heads[s[p]]++
+			}
+		}
+		for i := n - 1; i >= 0; i-- {
+			if p := sa[i] - 1; p >= 0 && stype[p] {
+				tails[s[p]]--
+				sa[tails[s[p]]] = p
+			}
+		}
+	}
+
+	// Sort the LMS substrings, which run from an LMS suffix to the next, by inducing from the LMS
+	// suffixes in any order.
+	var positions []int
+	for i := 1; i < n; i++ {
+		if lms(i) {
+			positions = append(positions, i)
+		}
+	}
+	induce(positions)
+
+	// Name the LMS substrings by their ranks, equal substrings alike.
+	equal := func(a, b int) bool {
+		for i := 0; ; i++ {
+			if s[a+i] != s[b+i] || stype[a+i] != stype[b+i] {
+				return false
+			}
+			if i > 0 && (lms(a+i) || lms(b+i)) {
+				return lms(a+i) && lms(b+i)
+			}
+		}
+	}
+	names := make([]int, n)
+	name, prev := 0, -1
+	for _, p := range sa {
+		if !lms(p) {
+			continue
+		}
+		if prev >= 0 && !equal(prev, p) {
+			name++
+		}
+		names[p], prev = name, p
+	}
+
+	// Sort the LMS suffixes by the suffix array of the string of their names, which is only needed
+	// if some names repeat, and induce the whole suffix array from them.
+	reduced := make([]int, len(positions))
+	for j, p := range positions {
+		reduced[j] = names[p]
+	}
+	order := make([]int, len(positions))
+	if name+1 < len(positions) {
+		for j, r := range sais(reduced, name+1) {
+			order[j] = positions[r]
+		}
+	} else {
+		for j, p := range positions {
+			order[reduced[j]] = p
+		}
+	}
+	induce(order)
+	return sa
+}
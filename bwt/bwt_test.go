@@ -0,0 +1,53 @@
+package bwt
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	for _, alphabet := range []string{"a", "ab", "acgt", "\x00\x01\xff", "abcdefghijklmnopqrstuvwxyz"} {
+		for size := 0; size < 500; size += 19 {
+			text := make([]byte, size)
+			for i := range text {
+				text[i] = alphabet[rand.Intn(len(alphabet))]
+			}
+			// Periodic texts repeat the LMS substrings, which makes SA-IS recurse.
+			if size%2 == 0 {
+				for i := 7; i < len(text); i++ {
+					text[i] = text[i-7]
+				}
+			}
+
+			want := make([]int, len(text)+1)
+			for i := range want {
+				want[i] = i
+			}
+			sort.Slice(want, func(i, j int) bool { return bytes.Compare(text[want[i]:], text[want[j]:]) < 0 })
+			if got := SuffixArray(text); !reflect.DeepEqual(got, want) {
+				t.Errorf("SuffixArray(%q) => got %v, want %v", text, got, want)
+			}
+
+			bwt, primary := Transform(text)
+			var wantBWT []byte
+			for i, p := range want {
+				if p == 0 {
+					if primary != i {
+						t.Errorf("Transform(%q) => got primary %v, want %v", text, primary, i)
+					}
+				} else {
+					wantBWT = append(wantBWT, text[p-1])
+				}
+			}
+			if !bytes.Equal(bwt, wantBWT) {
+				t.Errorf("Transform(%q) => got %q, want %q", text, bwt, wantBWT)
+			}
+			if got := Inverse(bwt, primary); !bytes.Equal(got, text) {
+				t.Errorf("Inverse(Transform(%q)) => got %q", text, got)
+			}
+		}
+	}
+}
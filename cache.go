@@ -0,0 +1,57 @@
+package wltree
+
+// hotSample is the number of positions between the rank samples of the caches of CacheRanks.
+const hotSample = 4096
+
+// CacheRanks makes Rank answer for each of the keys from a bitmap of its occurrences in s with
+// samples of its rank every 4096 positions, that is, with one lookup and a short scan instead of a
+// walk down the tree. Each key costs about Len()/8 bytes, so the cache suits a few keys that take
+// most of the queries. Keys unknown to the tree are ignored. The caches are cloned but not
+// serialized, and CacheRanks must not run concurrently with queries on w.
+func (w *Int64Keys) CacheRanks(keys ...int64) {
+	for _, key := range keys {
+		k, ok := w.find(key)
+		if !ok {
+			continue
+		}
+		if w.hot == nil {
+			w.hot = make([]*packedBits, len(w.keyset))
+		}
+		w.hot[k] = occurrences(w.n, w.counts[k], func(ranks, out []int) { w.SelectBatch(key, ranks, out) })
+	}
+}
+
+// CacheRanks makes Rank answer for each of the characters cs from a bitmap of its occurrences in
+// s with samples of its rank every 4096 positions, that is, with one lookup and a short scan
+// instead of a walk down the tree. Each character costs about Len()/8 bytes, so the cache suits a
+// few characters that take most of the queries. Characters unknown to the tree are ignored. The
+// caches are cloned but not serialized, and CacheRanks must not run concurrently with queries on w.
+func (w *Bytes) CacheRanks(cs ...byte) {
+	for _, c := range cs {
+		if w.Contains(c) {
+			w.hot[c] = occurrences(w.n, w.Count(c), func(ranks, out []int) { w.SelectBatch(c, ranks, out) })
+		}
+	}
+}
+
+// CacheRanks is like Int64Keys.CacheRanks for the characters cs.
+func (w *Runes) CacheRanks(cs ...rune) {
+	for _, c := range cs {
+		w.keys.CacheRanks(int64(c))
+	}
+}
+
+// occurrences returns the bitmap of n bits of the count positions that selectBatch selects for
+// the ranks 0 to count-1, with rank samples every hotSample bits.
+func occurrences(n, count int, selectBatch func(ranks, out []int)) *packedBits {
+	ranks := make([]int, count)
+	for r := range ranks {
+		ranks[r] = r
+	}
+	selectBatch(ranks, ranks)
+	data := make([]byte, (n+7)/8)
+	for _, i := range ranks {
+		data[i/8] |= 1 << uint(i%8)
+	}
+	return newSampledBits(data, n, hotSample, nil)
+}
@@ -0,0 +1,30 @@
+package wltree
+
+import "testing"
+
+func TestCacheRanks(t *testing.T) {
+	for size := 0; size < 3*hotSample; size += 997 {
+		for _, ws := range weights {
+			bs := random(size, ws)
+			wt, it, rs := NewBytes(bs), NewInt64Keys(byteSlice(bs)), NewRunes([]rune(string(bs)))
+			cached, icached, rcached := NewBytes(bs), NewInt64Keys(byteSlice(bs)), NewRunes([]rune(string(bs)))
+			cached.CacheRanks('a', 'c', 'z')
+			icached.CacheRanks('a', 'c', 'z')
+			rcached.CacheRanks('a', 'c', 'z')
+			for i := -1; i <= size+1; i += 1 + i/7 {
+				for _, c := range []byte("abcz") {
+					want := wt.Rank(c, i)
+					if got := cached.Rank(c, i); got != want {
+						t.Errorf("size %v: cached Rank(%q, %v) => got %v, want %v", size, c, i, got, want)
+					}
+					if got := icached.Rank(int64(c), i); got != it.Rank(int64(c), i) || got != want {
+						t.Errorf("size %v: cached IntKeys.Rank(%q, %v) => got %v, want %v", size, c, i, got, want)
+					}
+					if got := rcached.Rank(rune(c), i); got != rs.Rank(rune(c), i) || got != want {
+						t.Errorf("size %v: cached Runes.Rank(%q, %v) => got %v, want %v", size, c, i, got, want)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package wltree
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Chain is a Sequence made of several Sequences concatenated, such as trees built on consecutive
+// chunks of data. Positions are global: the i-th element of the chain is the element of the part
+// that covers i. Queries route to the parts and sum their counts, in time linear in the number of
+// parts. Select returns -1 when s has no such occurrence.
+type Chain struct {
+	parts []Sequence
+	// starts are the positions of the first elements of the parts, followed by the length.
+	starts []int
+}
+
+// NewChain returns the Chain of the parts in order.
+func NewChain(parts ...Sequence) *Chain {
+	c := &Chain{parts: append([]Sequence(nil), parts...), starts: []int{0}}
+	for _, p := range parts {
+		c.starts = append(c.starts, c.Len()+p.Len())
+	}
+	return c
+}
+
+// Len returns the total length of the parts.
+func (c *Chain) Len() int {
+	return c.starts[len(c.starts)-1]
+}
+
+// part returns the index of the part that covers position i, which must be in [0, Len()).
+func (c *Chain) part(i int) int {
+	return sort.SearchInts(c.starts, i+1) - 1
+}
+
+// Count returns the count of elements with the key in all parts.
+func (c *Chain) Count(key int64) int {
+	return c.Rank(key, c.Len())
+}
+
+// Rank returns the count of elements with the key in the first i elements of the chain.
+// i is clamped to the range [0, Len()].
+func (c *Chain) Rank(key int64, i int) int {
+	i = clamp(i, c.Len())
+	r := 0
+	for k, p := range c.parts {
+		if i <= c.starts[k+1] {
+			return r + p.Rank(key, i-c.starts[k])
+		}
+		r += p.Rank(key, p.Len())
+	}
+	return r
+}
+
+// Select returns the global position of the r-th occurrence of the key, or -1 if there is none.
+func (c *Chain) Select(key int64, r int) int {
+	if r < 0 {
+		return -1
+	}
+	for k, p := range c.parts {
+		count := p.Rank(key, p.Len())
+		if r < count {
+			return c.starts[k] + p.Select(key, r)
+		}
+		r -= count
+	}
+	return -1
+}
+
+// Access returns the key of the i-th element of the chain. It panics if i is out of range.
+func (c *Chain) Access(i int) int64 {
+	if i < 0 || i >= c.Len() {
+		panic(fmt.Sprintf("wltree: index %v out of range [0, %v)", i, c.Len()))
+	}
+	k := c.part(i)
+	return c.parts[k].Access(i - c.starts[k])
+}
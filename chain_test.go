@@ -0,0 +1,47 @@
+package wltree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	for trial := 0; trial < 50; trial++ {
+		var s []byte
+		var parts []Sequence
+		for k := rand.Intn(5); k >= 0; k-- {
+			part := random(rand.Intn(100), weights[trial%len(weights)])
+			s = append(s, part...)
+			parts = append(parts, New(byteSlice(part), Layout(rand.Intn(3))))
+		}
+		c := NewChain(parts...)
+		if got := c.Len(); got != len(s) {
+			t.Errorf("Len() => got %v, want %v", got, len(s))
+		}
+		for _, key := range []int64{'a', 'c', 'f', 'z'} {
+			count := 0
+			for i := 0; i <= len(s); i++ {
+				if got := c.Rank(key, i); got != count {
+					t.Errorf("%q: Rank(%v, %v) => got %v, want %v", s, key, i, got, count)
+				}
+				if i < len(s) && int64(s[i]) == key {
+					if got := c.Select(key, count); got != i {
+						t.Errorf("%q: Select(%v, %v) => got %v, want %v", s, key, count, got, i)
+					}
+					count++
+				}
+			}
+			if got := c.Select(key, count); got != -1 {
+				t.Errorf("%q: Select(%v, %v) => got %v, want -1", s, key, count, got)
+			}
+			if got := c.Count(key); got != count {
+				t.Errorf("%q: Count(%v) => got %v, want %v", s, key, got, count)
+			}
+		}
+		for i := range s {
+			if got := c.Access(i); got != int64(s[i]) {
+				t.Errorf("%q: Access(%v) => got %v, want %v", s, i, got, s[i])
+			}
+		}
+	}
+}
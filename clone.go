@@ -0,0 +1,25 @@
+package wltree
+
+// Clone returns a deep copy of w that shares no memory with it, including the BitVectors and the
+// caches of CacheRanks. The nodes are copied like SubTree(0, Len()) copies them, so they are
+// stored as the options w was built with select.
+func (w *Int64Keys) Clone() *Int64Keys {
+	c := subTree(append([]int64(nil), w.keyset...), w.codes, w.opts, w.treeRange(0, w.n))
+	c.errorMode, c.opts = w.errorMode, w.opts
+	if w.hot != nil {
+		c.hot = make([]*packedBits, len(w.hot))
+		for k, h := range w.hot {
+			c.hot[k] = h.clone()
+		}
+	}
+	return c
+}
+
+// Clone is like Int64Keys.Clone for a Wavelet Tree on bytestring.
+func (w *Bytes) Clone() *Bytes {
+	c := bytesFrom(w.int64Keys().Clone())
+	for k, h := range w.hot {
+		c.hot[k] = h.clone()
+	}
+	return c
+}
@@ -0,0 +1,57 @@
+package wltree
+
+import "testing"
+
+func TestClone(t *testing.T) {
+	for size := 0; size < 64; size++ {
+		for _, ws := range weights {
+			bs := random(size, ws)
+			wt := NewBytes(bs).Clone()
+			wti := NewInt64Keys(byteSlice(bs)).Clone()
+			if err := wt.Verify(); err != nil {
+				t.Errorf("Bytes: %q.Clone().Verify() => %v", bs, err)
+			}
+			if err := wti.Verify(); err != nil {
+				t.Errorf("IntKeys: %q.Clone().Verify() => %v", bs, err)
+			}
+
+			var counts [256]int
+			for i, c := range bs {
+				if got, want := wt.Rank(c, i), counts[c]; got != want {
+					t.Errorf("Bytes: %q.Clone().Rank(%q, %v) => got %v, want %v", bs, c, i, got, want)
+				}
+				if got, want := wti.Select(int64(c), counts[c]), i; got != want {
+					t.Errorf("IntKeys: %q.Clone().Select(%q, %v) => got %v, want %v", bs, c, counts[c], got, want)
+				}
+				counts[c]++
+			}
+		}
+	}
+
+	w := NewInt64Keys(byteSlice("abracadabra"))
+	c := w.Clone()
+	if w.root.bv == c.root.bv || w.nodes[0][0] == c.nodes[0][0] {
+		t.Errorf("Clone() => shares BitVectors with the original")
+	}
+	c.counts[0] = 100
+	if w.counts[0] == 100 {
+		t.Errorf("Clone() => shares counts with the original")
+	}
+
+	w.CacheRanks('a')
+	if got := w.Clone(); got.hot[0] == nil || got.hot[0] == w.hot[0] || got.Rank('a', 5) != 2 {
+		t.Errorf("Clone() => does not copy the caches of CacheRanks")
+	}
+
+	s := random(1<<14, weights[0])
+	for _, opts := range []*Options{{Backend: RRR}, {Sparse: true}, {RunLength: true}} {
+		w := NewBytesWithOptions(s, opts)
+		c := w.Clone()
+		if !c.Equal(w) || c.opts != w.opts {
+			t.Errorf("Clone() with %+v => differs from the original", opts)
+		}
+		if got, want := c.SizeInBytes(), w.SizeInBytes(); got != want {
+			t.Errorf("Clone().SizeInBytes() with %+v => %v, want %v", opts, got, want)
+		}
+	}
+}
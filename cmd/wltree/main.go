@@ -0,0 +1,182 @@
+/*
+Command wltree builds Wavelet Tree indexes of bytestrings and answers queries against them.
+
+Usage:
+
+	wltree build [-o index] [file]
+	wltree rank -i index c i
+	wltree select -i index c r
+	wltree access -i index i
+	wltree extract -i index l r
+	wltree count -i index c
+
+build indexes the file, or the standard input if none is given, and writes the index to the
+standard output unless -o names a file. It reads the input twice, the standard input from a
+temporary copy, instead of holding it in memory next to the index. The queries map the index given
+by -i into memory and print the answer as a decimal number, select printing -1 for a missing
+occurrence, except that extract writes the raw bytes of s[l:r]. A character c is either a single
+byte or its value as a number, such as 10 or 0x0a.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/mozu0/wltree"
+	"github.com/mozu0/wltree/internal/char"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "wltree:", err)
+		os.Exit(2)
+	}
+}
+
+// errUsage is the error for a malformed command line.
+var errUsage = errors.New(`usage:
+	wltree build [-o index] [file]
+	wltree rank -i index c i
+	wltree select -i index c r
+	wltree access -i index i
+	wltree extract -i index l r
+	wltree count -i index c`)
+
+// run runs the command with the arguments args, without the program name.
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	cmd := args[0]
+	flags := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	output := flags.String("o", "", "")
+	input := flags.String("i", "", "")
+	if err := flags.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+	args = flags.Args()
+
+	if cmd == "build" {
+		return build(args, *output, stdin, stdout)
+	}
+	if *input == "" {
+		return errUsage
+	}
+	m, err := wltree.OpenMmap(*input)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	wt, err := m.Bytes()
+	if err != nil {
+		return err
+	}
+	switch {
+	case cmd == "rank" && len(args) == 2:
+		c, i, err := charInt(args)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, wt.Rank(c, i))
+		return err
+	case cmd == "select" && len(args) == 2:
+		c, r, err := charInt(args)
+		if err != nil {
+			return err
+		}
+		i, ok := wt.SelectChecked(c, r)
+		if !ok {
+			i = -1
+		}
+		_, err = fmt.Fprintln(stdout, i)
+		return err
+	case cmd == "access" && len(args) == 1:
+		i, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+		if i < 0 || i >= wt.Len() {
+			return fmt.Errorf("index %v out of range [0, %v)", i, wt.Len())
+		}
+		_, err = fmt.Fprintln(stdout, wt.Access(i))
+		return err
+	case cmd == "extract" && len(args) == 2:
+		l, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+		r, err := strconv.Atoi(args[1])
+		if err != nil {
+			return err
+		}
+		_, err = stdout.Write(wt.Extract(l, r))
+		return err
+	case cmd == "count" && len(args) == 1:
+		c, err := parseChar(args[0])
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, wt.Count(c))
+		return err
+	}
+	return errUsage
+}
+
+// build indexes the file named by args, or stdin, and writes the index to the file named output,
+// or stdout.
+func build(args []string, output string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+	in := stdin
+	if len(args) == 1 {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	wt, err := wltree.NewBytesFromReader(in)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = wt.WriteTo(stdout)
+		return err
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if _, err := wt.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// parseChar parses a character given as a single byte or as its value.
+func parseChar(arg string) (byte, error) {
+	c, ok := char.Parse(arg)
+	if !ok {
+		return 0, fmt.Errorf("invalid character %q", arg)
+	}
+	return c, nil
+}
+
+// charInt parses a character and an int.
+func charInt(args []string) (byte, int, error) {
+	c, err := parseChar(args[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	i, err := strconv.Atoi(args[1])
+	return c, i, err
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	index := filepath.Join(t.TempDir(), "index")
+	if err := run([]string{"build", "-o", index}, strings.NewReader("abracadabra"), new(bytes.Buffer)); err != nil {
+		t.Fatalf("build => %v", err)
+	}
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"rank", "-i", index, "a", "8"}, "4\n"},
+		{[]string{"rank", "-i", index, "0x61", "100"}, "5\n"},
+		{[]string{"select", "-i", index, "a", "2"}, "5\n"},
+		{[]string{"select", "-i", index, "z", "0"}, "-1\n"},
+		{[]string{"access", "-i", index, "1"}, "98\n"},
+		{[]string{"count", "-i", index, "r"}, "2\n"},
+		{[]string{"extract", "-i", index, "3", "8"}, "acada"},
+	} {
+		var out bytes.Buffer
+		if err := run(tc.args, nil, &out); err != nil {
+			t.Errorf("%v => %v", tc.args, err)
+		} else if got := out.String(); got != tc.want {
+			t.Errorf("%v => got %q, want %q", tc.args, got, tc.want)
+		}
+	}
+	for _, args := range [][]string{
+		nil,
+		{"rank", index, "a", "1"},
+		{"rank", "-i", index, "a"},
+		{"access", "-i", index, "11"},
+		{"rank", "-i", index, "ab", "1"},
+		{"unknown", "-i", index},
+	} {
+		if err := run(args, nil, new(bytes.Buffer)); err == nil {
+			t.Errorf("%v => no error", args)
+		}
+	}
+
+	// The index streams to stdout without -o.
+	var built bytes.Buffer
+	if err := run([]string{"build"}, strings.NewReader("abracadabra"), &built); err != nil || built.Len() == 0 {
+		t.Errorf("build to stdout => %v, %v bytes", err, built.Len())
+	}
+}
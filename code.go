@@ -0,0 +1,38 @@
+package wltree
+
+import "strings"
+
+// maxCodeBits is the length limit of the codes of the keys, so that a code fits in a uint64.
+const maxCodeBits = 64
+
+// code is the code of a key packed into an integer: bit j of bits is the branch taken at depth j,
+// 1 for the right child.
+type code struct {
+	bits uint64
+	len  uint8
+}
+
+// packCode returns the code spelled by s, a string of at most maxCodeBits '0's and '1's.
+func packCode(s string) code {
+	c := code{len: uint8(len(s))}
+	for j := range s {
+		if s[j] == '1' {
+			c.bits |= 1 << uint(j)
+		}
+	}
+	return c
+}
+
+// bit reports whether the code branches right at depth j.
+func (c code) bit(j int) bool {
+	return c.bits>>uint(j)&1 != 0
+}
+
+// String returns the code as a string of '0's and '1's.
+func (c code) String() string {
+	var b strings.Builder
+	for j := 0; j < int(c.len); j++ {
+		b.WriteByte('0' + byte(c.bits>>uint(j)&1))
+	}
+	return b.String()
+}
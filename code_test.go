@@ -0,0 +1,49 @@
+package wltree
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestCode(t *testing.T) {
+	for trial := 0; trial < 1000; trial++ {
+		s := make([]byte, rand.Intn(maxCodeBits+1))
+		for j := range s {
+			s[j] = '0' + byte(rand.Intn(2))
+		}
+		c := packCode(string(s))
+		if got := c.String(); got != string(s) {
+			t.Errorf("packCode(%q).String() => got %q, want %q", s, got, s)
+		}
+		for j := range s {
+			if got, want := c.bit(j), s[j] == '1'; got != want {
+				t.Errorf("packCode(%q).bit(%v) => got %v, want %v", s, j, got, want)
+			}
+		}
+	}
+}
+
+func TestCodeLimit(t *testing.T) {
+	// Fibonacci counts make the deepest Huffman and alphabetic trees, one level per key.
+	// On 32-bit platforms they stop while their sum still fits in an int.
+	n := 90
+	if strconv.IntSize == 32 {
+		n = 44
+	}
+	counts := []int{1, 1}
+	for len(counts) < n {
+		counts = append(counts, counts[len(counts)-1]+counts[len(counts)-2])
+	}
+	for _, shape := range []Shape{HuffmanShape, AlphabeticShape} {
+		codes := shape.codes(counts, 0)
+		for i, code := range codes {
+			if len(code) > maxCodeBits {
+				t.Errorf("shape %v: code of key %v => got %v bits, want at most %v", shape, i, len(code), maxCodeBits)
+			}
+		}
+		if err := checkCodes(codes); err != nil {
+			t.Errorf("shape %v: codes => %v", shape, err)
+		}
+	}
+}
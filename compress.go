@@ -0,0 +1,78 @@
+package wltree
+
+import (
+	"compress/gzip"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Compressor returns a writer that compresses everything written to it into w. Closing the
+// writer must complete the compressed stream, without closing w.
+type Compressor func(w io.Writer) io.WriteCloser
+
+// Gzip is a Compressor that writes gzip streams at the default compression level.
+func Gzip(w io.Writer) io.WriteCloser {
+	return gzip.NewWriter(w)
+}
+
+// decompressors maps the magic bytes that start compressed streams to the functions that
+// decompress them.
+var decompressors = struct {
+	sync.RWMutex
+	m map[string]func(io.Reader) (io.Reader, error)
+}{m: map[string]func(io.Reader) (io.Reader, error){
+	"\x1f\x8b": func(r io.Reader) (io.Reader, error) {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		// Stop at the end of the tree, which may be followed by other data.
+		zr.Multistream(false)
+		return zr, nil
+	},
+}}
+
+// RegisterCompression registers a compressed form of serialized trees, whose streams start with
+// the given magic bytes, so that UnmarshalBinary, ReadFrom and GobDecode transparently decompress
+// it with decompress. gzip is registered by default.
+func RegisterCompression(magic string, decompress func(io.Reader) (io.Reader, error)) {
+	decompressors.Lock()
+	defer decompressors.Unlock()
+	decompressors.m[magic] = decompress
+}
+
+// decompress returns the decompressed stream of the compressed tree in r, whose first bytes head
+// have already been read.
+func decompress(head []byte, r io.Reader) (io.Reader, error) {
+	decompressors.RLock()
+	defer decompressors.RUnlock()
+	for m, decompress := range decompressors.m {
+		if strings.HasPrefix(string(head), m) || strings.HasPrefix(m, string(head)) {
+			return decompress(io.MultiReader(strings.NewReader(string(head)), r))
+		}
+	}
+	return nil, ErrCorrupt
+}
+
+// WriteCompressed writes the serialized form of w to out, compressed by the writer that compress
+// returns, such as Gzip.
+func (w *Int64Keys) WriteCompressed(out io.Writer, compress Compressor) error {
+	cw := compress(out)
+	if err := w.encode(cw); err != nil {
+		cw.Close()
+		return err
+	}
+	return cw.Close()
+}
+
+// WriteCompressed writes the serialized form of w to out, compressed by the writer that compress
+// returns, such as Gzip.
+func (w *Bytes) WriteCompressed(out io.Writer, compress Compressor) error {
+	cw := compress(out)
+	if err := w.encode(cw); err != nil {
+		cw.Close()
+		return err
+	}
+	return cw.Close()
+}
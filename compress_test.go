@@ -0,0 +1,58 @@
+package wltree
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCompressed(t *testing.T) {
+	s := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog. ", 200))
+	w := NewBytes(s)
+	plain, err := w.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := w.WriteCompressed(&buf, Gzip); err != nil {
+		t.Fatalf("WriteCompressed(Gzip) => %v", err)
+	}
+	if buf.Len() >= len(plain) {
+		t.Errorf("WriteCompressed(Gzip) => %v bytes, not smaller than %v", buf.Len(), len(plain))
+	}
+	var got Bytes
+	if err := got.UnmarshalBinary(buf.Bytes()); err != nil {
+		t.Fatalf("UnmarshalBinary(gzip) => %v", err)
+	}
+	if !got.Equal(w) {
+		t.Errorf("UnmarshalBinary(gzip) => tree differs")
+	}
+
+	// A registered compression is decompressed transparently as well.
+	RegisterCompression("RAW!", func(r io.Reader) (io.Reader, error) {
+		if _, err := io.ReadFull(r, make([]byte, 4)); err != nil {
+			return nil, err
+		}
+		return flate.NewReader(r), nil
+	})
+	buf.Reset()
+	wi := NewInts([]int{1, 2, 3, 2, 1})
+	err = wi.WriteCompressed(&buf, func(w io.Writer) io.WriteCloser {
+		w.Write([]byte("RAW!"))
+		fw, _ := flate.NewWriter(w, flate.BestSpeed)
+		return fw
+	})
+	if err != nil {
+		t.Fatalf("WriteCompressed(flate) => %v", err)
+	}
+	var goti Int64Keys
+	if _, err := goti.ReadFrom(&buf); err != nil {
+		t.Fatalf("ReadFrom(flate) => %v", err)
+	}
+	if !goti.Equal(wi) {
+		t.Errorf("ReadFrom(flate) => tree differs")
+	}
+}
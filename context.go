@@ -0,0 +1,15 @@
+package wltree
+
+import "context"
+
+// NewBytesContext is like NewBytes, but checks ctx every 65536 characters or so and between the
+// levels of the tree, and stops early and returns ctx.Err() once ctx is done. Builder.BytesContext
+// builds trees configured by Options alike.
+func NewBytesContext(ctx context.Context, s []byte) (*Bytes, error) {
+	return NewBuilder(nil).BytesContext(ctx, s)
+}
+
+// NewInt64KeysContext is like NewBytesContext for a Wavelet Tree on int64 keys.
+func NewInt64KeysContext(ctx context.Context, s Interface) (*Int64Keys, error) {
+	return NewBuilder(nil).Int64KeysContext(ctx, s)
+}
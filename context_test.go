@@ -0,0 +1,74 @@
+package wltree
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	for _, ws := range weights {
+		s := random(rand.Intn(maxSize), ws)
+		w, err := NewBytesContext(context.Background(), s)
+		if err != nil || !w.Equal(NewBytes(s)) {
+			t.Errorf("NewBytesContext(%q) => got %v, %v, want NewBytes", s, w, err)
+		}
+		iw, err := NewInt64KeysContext(context.Background(), byteSlice(s))
+		if err != nil || !iw.Equal(NewInt64Keys(byteSlice(s))) {
+			t.Errorf("NewInt64KeysContext(%q) => got %v, %v, want NewInt64Keys", s, iw, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if w, err := NewBytesContext(ctx, []byte("abracadabra")); w != nil || err != context.Canceled {
+		t.Errorf("NewBytesContext(canceled) => got %v, %v, want nil, %v", w, err, context.Canceled)
+	}
+}
+
+func TestContextCancel(t *testing.T) {
+	s := random(8*progressStep, weights[rand.Intn(len(weights))])
+	for _, opts := range []Options{
+		{},
+		{Workers: 4},
+		{RunLength: true},
+		{Sparse: true},
+	} {
+		for _, phase := range []Phase{PhaseCount, PhaseSet, PhaseBuild} {
+			if phase == PhaseBuild && (opts.RunLength || opts.Sparse) {
+				// These build phases only report their end.
+				continue
+			}
+			for name, build := range map[string]func(ctx context.Context, b *Builder) (bool, error){
+				"Bytes": func(ctx context.Context, b *Builder) (bool, error) {
+					w, err := b.BytesContext(ctx, s)
+					return w != nil, err
+				},
+				"Int64Keys": func(ctx context.Context, b *Builder) (bool, error) {
+					w, err := b.Int64KeysContext(ctx, byteSlice(s))
+					return w != nil, err
+				},
+			} {
+				// Cancel once the phase is under way, and count the reports after that.
+				ctx, cancel := context.WithCancel(context.Background())
+				after := -1
+				opts.Progress = func(p Phase, fraction float64) {
+					if after >= 0 {
+						after++
+					} else if p == phase && fraction > 0 {
+						cancel()
+						after = 0
+					}
+				}
+				built, err := build(ctx, NewBuilder(&opts))
+				if built || err != context.Canceled {
+					t.Errorf("%v %+v canceled in %v => got built %v, %v, want nil, %v", name, opts, phase, built, err, context.Canceled)
+				}
+				if after > opts.Workers+1 {
+					t.Errorf("%v %+v canceled in %v => %v reports after canceling", name, opts, phase, after)
+				}
+				cancel()
+			}
+		}
+	}
+}
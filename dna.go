@@ -0,0 +1,120 @@
+package wltree
+
+import "fmt"
+
+// DNA represents a Wavelet Tree on a DNA sequence over the alphabet A, C, G, T and N. The bases
+// other than N are stored as 2-bit digits in a single node of a 4-ary tree, and the positions of
+// N, usually rare, separately, so a query touches at most two bit vectors.
+type DNA struct {
+	bases  *quadVector
+	ns     *eliasFano
+	counts [4]int
+	n      int
+}
+
+// dnaDigit maps the bases to their digits, and N to 4. Lower case bases are accepted as well.
+var dnaDigit = func() (d [256]int8) {
+	for i := range d {
+		d[i] = -1
+	}
+	for i, c := range "ACGTN" {
+		d[c], d[c+'a'-'A'] = int8(i), int8(i)
+	}
+	return d
+}()
+
+// NewDNA makes a Wavelet Tree from the DNA sequence s. It fails if s contains a byte other than
+// A, C, G, T and N, in upper or lower case. Lower case bases are indexed as upper case ones.
+func NewDNA(s []byte) (*DNA, error) {
+	w := &DNA{n: len(s)}
+	var ns []int
+	for i, c := range s {
+		switch d := dnaDigit[c]; d {
+		case -1:
+			return nil, fmt.Errorf("wltree: invalid base %q at %v", c, i)
+		case 4:
+			ns = append(ns, i)
+		default:
+			w.counts[d]++
+		}
+	}
+	w.ns = newEliasFano(len(s), ns)
+	w.bases = newQuadVector(len(s) - len(ns))
+	j := 0
+	for _, c := range s {
+		if d := dnaDigit[c]; d < 4 {
+			w.bases.set(j, int(d))
+			j++
+		}
+	}
+	w.bases.build()
+	return w, nil
+}
+
+// Len returns the length of s.
+func (w *DNA) Len() int {
+	return w.n
+}
+
+// Count returns the count of the base c in s.
+func (w *DNA) Count(c byte) int {
+	switch d := dnaDigit[c]; d {
+	case -1:
+		return 0
+	case 4:
+		return w.ns.ones
+	default:
+		return w.counts[d]
+	}
+}
+
+// Rank returns the count of the base c in s[0:i].
+// i is clamped to the range [0, Len()].
+func (w *DNA) Rank(c byte, i int) int {
+	i = clamp(i, w.n)
+	switch d := dnaDigit[c]; d {
+	case -1:
+		return 0
+	case 4:
+		return w.ns.Rank1(i)
+	default:
+		return w.bases.rank(int(d), w.ns.Rank0(i))
+	}
+}
+
+// Select returns i such that Rank(c, i) = r.
+// i.e. it returns the index of r-th occurrence of the base c.
+// It panics if c is not a base, except that Select on an empty tree returns -1.
+func (w *DNA) Select(c byte, r int) int {
+	if w.n == 0 {
+		return -1
+	}
+	switch d := dnaDigit[c]; d {
+	case -1:
+		panic(fmt.Sprintf("wltree: no such base %q.", c))
+	case 4:
+		return w.ns.Select1(r)
+	default:
+		return w.ns.Select0(w.bases.selectDigit(int(d), r))
+	}
+}
+
+// SelectChecked is like Select, but reports false instead of panicking or returning garbage when
+// s has no r-th occurrence of the base c.
+func (w *DNA) SelectChecked(c byte, r int) (int, bool) {
+	if r < 0 || r >= w.Count(c) {
+		return 0, false
+	}
+	return w.Select(c, r), true
+}
+
+// Access returns s[i] in upper case. It panics if i is out of range.
+func (w *DNA) Access(i int) byte {
+	if i < 0 || i >= w.n {
+		panic(fmt.Sprintf("wltree: index %v out of range [0, %v)", i, w.n))
+	}
+	if r := w.ns.Rank1(i); w.ns.Rank1(i+1) > r {
+		return 'N'
+	}
+	return "ACGT"[w.bases.digit(w.ns.Rank0(i))]
+}
@@ -0,0 +1,50 @@
+package wltree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDNA(t *testing.T) {
+	fails := 0
+	for size := 0; size < maxSize && fails < 30; size += 17 {
+		for _, ws := range []map[byte]int{
+			{'A': 1, 'C': 1, 'G': 1, 'T': 1},
+			{'A': 10, 'C': 5, 'g': 5, 't': 10, 'N': 1},
+			{'N': 1},
+		} {
+			bs := random(size, ws)
+			wt, err := NewDNA(bs)
+			if err != nil {
+				t.Fatalf("NewDNA(%q) => %v", bs, err)
+			}
+			up := bytes.ToUpper(bs)
+
+			var counts [256]int
+			for i := 0; i <= len(up) && fails < 30; i++ {
+				for _, c := range []byte("ACGTNX") {
+					if got, want := wt.Rank(c, i), counts[c]; got != want {
+						t.Errorf("%q.Rank(%q, %v) => got %v, want %v", bs, c, i, got, want)
+						fails++
+					}
+				}
+				if i != len(up) {
+					c := up[i]
+					if got, want := wt.Select(c, counts[c]), i; got != want {
+						t.Errorf("%q.Select(%q, %v) => got %v, want %v", bs, c, counts[c], got, want)
+						fails++
+					}
+					if got := wt.Access(i); got != c {
+						t.Errorf("%q.Access(%v) => got %q, want %q", bs, i, got, c)
+						fails++
+					}
+					counts[c]++
+				}
+			}
+		}
+	}
+
+	if _, err := NewDNA([]byte("ACGU")); err == nil {
+		t.Errorf("NewDNA(\"ACGU\") => got nil error")
+	}
+}
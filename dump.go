@@ -0,0 +1,124 @@
+package wltree
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// dumpTree is the JSON form of a tree written by DumpJSON.
+type dumpTree struct {
+	Length  int          `json:"length"`
+	Symbols []dumpSymbol `json:"symbols"`
+	Nodes   []dumpNode   `json:"nodes"`
+	Depth   dumpDepth    `json:"depth"`
+}
+
+type dumpSymbol struct {
+	Key   int64  `json:"key"`
+	Count int    `json:"count"`
+	Code  string `json:"code"`
+}
+
+type dumpNode struct {
+	Prefix string `json:"prefix"`
+	Size   int    `json:"size"`
+	Ones   int    `json:"ones"`
+}
+
+type dumpDepth struct {
+	Min  int     `json:"min"`
+	Max  int     `json:"max"`
+	Mean float64 `json:"mean"`
+}
+
+// DumpJSON writes a human-readable JSON description of w to out, for debugging and for tools
+// that visualize the shape of the tree: the code and count of each key, the size and number of
+// ones of each internal node, and the minimum, maximum and mean (weighted by count) code length.
+// It is not a serialization format; use MarshalBinary for that.
+func (w *Int64Keys) DumpJSON(out io.Writer) error {
+	return dump(out, w.root, w.n, w.keyset, w.counts, func(key int64) string {
+		i, _ := w.find(key)
+		return w.codes[i].String()
+	})
+}
+
+// DumpJSON is like Int64Keys.DumpJSON.
+func (w *Bytes) DumpJSON(out io.Writer) error {
+	keyset := make([]int64, len(w.keyset))
+	for i, c := range w.keyset {
+		keyset[i] = int64(c)
+	}
+	return dump(out, w.root, w.n, keyset, w.counts, func(key int64) string { return w.codes[key].String() })
+}
+
+func dump(out io.Writer, root *node, n int, keyset []int64, counts []int, code func(int64) string) error {
+	d := dumpTree{Length: n, Symbols: []dumpSymbol{}, Nodes: []dumpNode{}}
+	total := 0
+	for i, key := range keyset {
+		c := code(key)
+		d.Symbols = append(d.Symbols, dumpSymbol{Key: key, Count: counts[i], Code: c})
+		if i == 0 || len(c) < d.Depth.Min {
+			d.Depth.Min = len(c)
+		}
+		if len(c) > d.Depth.Max {
+			d.Depth.Max = len(c)
+		}
+		total += len(c) * counts[i]
+	}
+	if n > 0 {
+		d.Depth.Mean = float64(total) / float64(n)
+	}
+	var walk func(nd *node, prefix string)
+	walk = func(nd *node, prefix string) {
+		if nd == nil || nd.leaf() {
+			return
+		}
+		d.Nodes = append(d.Nodes, dumpNode{Prefix: prefix, Size: nd.size, Ones: nd.bv.Rank1(nd.size)})
+		walk(nd.child[0], prefix+"0")
+		walk(nd.child[1], prefix+"1")
+	}
+	walk(root, "")
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// DumpDOT writes the shape of w to out in the DOT language of Graphviz, for visualizing why a key
+// distribution makes a deep or skewed tree: each internal node is labeled with its size, each leaf
+// with its key and count, and each edge with the bit of the code that leads to the child.
+func (w *Int64Keys) DumpDOT(out io.Writer) error {
+	return dumpDOT(out, w.root, func(key int64) string { return strconv.FormatInt(key, 10) })
+}
+
+// DumpDOT is like Int64Keys.DumpDOT, labeling the leaves with quoted characters.
+func (w *Bytes) DumpDOT(out io.Writer) error {
+	return dumpDOT(out, w.root, func(key int64) string { return strconv.QuoteRune(rune(key)) })
+}
+
+func dumpDOT(out io.Writer, root *node, label func(int64) string) error {
+	var b bytes.Buffer
+	b.WriteString("digraph wltree {\n")
+	// Nodes are named by their code prefixes, the root being "n".
+	var walk func(nd *node, prefix string)
+	walk = func(nd *node, prefix string) {
+		if nd.leaf() {
+			fmt.Fprintf(&b, "\tn%v [shape=ellipse, label=%v];\n", prefix,
+				strconv.Quote(fmt.Sprintf("%v\ncount %v", label(nd.key), nd.size)))
+			return
+		}
+		fmt.Fprintf(&b, "\tn%v [shape=box, label=%v];\n", prefix, strconv.Quote(fmt.Sprintf("size %v", nd.size)))
+		for bit, child := range nd.child {
+			fmt.Fprintf(&b, "\tn%v -> n%v%v [label=%v];\n", prefix, prefix, bit, bit)
+			walk(child, prefix+strconv.Itoa(bit))
+		}
+	}
+	if root != nil {
+		walk(root, "")
+	}
+	b.WriteString("}\n")
+	_, err := out.Write(b.Bytes())
+	return err
+}
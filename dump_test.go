@@ -0,0 +1,77 @@
+package wltree
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDumpJSON(t *testing.T) {
+	s := []byte("abracadabra")
+	var buf bytes.Buffer
+	if err := NewBytes(s).DumpJSON(&buf); err != nil {
+		t.Fatalf("DumpJSON() => %v", err)
+	}
+	var d dumpTree
+	if err := json.Unmarshal(buf.Bytes(), &d); err != nil {
+		t.Fatalf("DumpJSON() => invalid JSON %q: %v", buf.String(), err)
+	}
+	if d.Length != len(s) || len(d.Symbols) != 5 {
+		t.Errorf("DumpJSON() => length %v, %v symbols, want %v, 5", d.Length, len(d.Symbols), len(s))
+	}
+	// A binary tree on 5 leaves has 4 internal nodes; the root holds every position.
+	if len(d.Nodes) != 4 || d.Nodes[0].Prefix != "" || d.Nodes[0].Size != len(s) {
+		t.Errorf("DumpJSON() => nodes %+v", d.Nodes)
+	}
+	total := 0
+	for _, sym := range d.Symbols {
+		total += len(sym.Code) * sym.Count
+	}
+	if got, want := d.Depth.Mean, float64(total)/float64(len(s)); got != want {
+		t.Errorf("DumpJSON() => mean depth %v, want %v", got, want)
+	}
+
+	buf.Reset()
+	if err := NewInts(nil).DumpJSON(&buf); err != nil {
+		t.Fatalf("DumpJSON(empty) => %v", err)
+	}
+}
+
+func TestDumpDOT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewBytes([]byte("aab")).DumpDOT(&buf); err != nil {
+		t.Fatalf("DumpDOT() => %v", err)
+	}
+	want := `digraph wltree {
+	n [shape=box, label="size 3"];
+	n -> n0 [label=0];
+	n0 [shape=ellipse, label="'a'\ncount 2"];
+	n -> n1 [label=1];
+	n1 [shape=ellipse, label="'b'\ncount 1"];
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("DumpDOT() => got %v, want %v", got, want)
+	}
+
+	buf.Reset()
+	if err := NewInt64Keys(byteSlice("abracadabra")).DumpDOT(&buf); err != nil {
+		t.Fatalf("DumpDOT() => %v", err)
+	}
+	// 5 leaves and 4 internal nodes, with an edge to each node but the root.
+	if got := strings.Count(buf.String(), "shape="); got != 9 {
+		t.Errorf("DumpDOT() => %v nodes, want 9", got)
+	}
+	if got := strings.Count(buf.String(), "->"); got != 8 {
+		t.Errorf("DumpDOT() => %v edges, want 8", got)
+	}
+	if !strings.Contains(buf.String(), `label="97\ncount 5"`) {
+		t.Errorf("DumpDOT() => got %v, want a leaf for 97 with count 5", buf.String())
+	}
+
+	buf.Reset()
+	if err := NewInts(nil).DumpDOT(&buf); err != nil || buf.String() != "digraph wltree {\n}\n" {
+		t.Errorf("DumpDOT(empty) => got %q, %v", buf.String(), err)
+	}
+}
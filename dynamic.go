@@ -0,0 +1,321 @@
+package wltree
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+// DynamicBytes is a Wavelet Tree on bytestring that supports inserting and deleting characters.
+// Its nodes are dynamic BitVectors, so that an edit and a query alike take O(log n) time per level
+// of the balanced tree of 8 levels over all bytes. Queries are several times slower than on Bytes.
+// Select returns -1 when s has no such occurrence.
+type DynamicBytes struct {
+	t *dynTree
+}
+
+// NewDynamicBytes makes a DynamicBytes from bytestring.
+func NewDynamicBytes(s []byte) *DynamicBytes {
+	w := &DynamicBytes{newDynTree(256)}
+	for i, c := range s {
+		w.t.insert(i, int(c), false)
+	}
+	return w
+}
+
+// Len returns the length of s.
+func (w *DynamicBytes) Len() int {
+	return w.t.n
+}
+
+// Count returns the count of the character c in s.
+func (w *DynamicBytes) Count(c byte) int {
+	return w.t.count(int(c))
+}
+
+// Rank returns the count of the character c in s[0:i].
+// i is clamped to the range [0, Len()].
+func (w *DynamicBytes) Rank(c byte, i int) int {
+	return w.t.rank(int(c), i)
+}
+
+// Select returns the index of the r-th occurrence of the character c, or -1 if there is none.
+func (w *DynamicBytes) Select(c byte, r int) int {
+	return w.t.selectRank(int(c), r)
+}
+
+// Access returns the i-th character of s. It panics if i is out of range.
+func (w *DynamicBytes) Access(i int) byte {
+	w.t.check(i, w.t.n)
+	return byte(w.t.access(i))
+}
+
+// Insert inserts the character c before s[i], or at the end of s if i is Len(). It panics if i is
+// out of range.
+func (w *DynamicBytes) Insert(i int, c byte) {
+	w.t.check(i, w.t.n+1)
+	w.t.insert(i, int(c), false)
+}
+
+// Delete deletes s[i] and returns it. It panics if i is out of range.
+func (w *DynamicBytes) Delete(i int) byte {
+	w.t.check(i, w.t.n)
+	id, _ := w.t.delete(i, false)
+	return byte(id)
+}
+
+// Update replaces s[i] by the character c, and returns the replaced character. It panics if i is
+// out of range.
+func (w *DynamicBytes) Update(i int, c byte) byte {
+	old := w.Delete(i)
+	w.t.insert(i, int(c), false)
+	return old
+}
+
+// DynamicInt64Keys is a Wavelet Tree on int64 keys that supports inserting and deleting elements
+// with keys in an alphabet fixed at construction, like DynamicBytes.
+type DynamicInt64Keys struct {
+	t *dynTree
+	// keyset is the alphabet in ascending order.
+	keyset []int64
+}
+
+// NewDynamicInt64Keys makes a DynamicInt64Keys from s, whose elements may then have the keys of s
+// and those in alphabet.
+func NewDynamicInt64Keys(s Interface, alphabet []int64) *DynamicInt64Keys {
+	keyset, counts := freq(all(s))
+	keyset, _ = withAlphabet(keyset, counts, alphabet)
+	sort.Slice(keyset, func(i, j int) bool { return keyset[i] < keyset[j] })
+	w := &DynamicInt64Keys{t: newDynTree(len(keyset)), keyset: keyset}
+	for i := 0; i < s.Len(); i++ {
+		id, _ := w.find(s.Key(i))
+		w.t.insert(i, id, false)
+	}
+	return w
+}
+
+// find returns the index of the key in keyset, and whether it is there.
+func (w *DynamicInt64Keys) find(key int64) (int, bool) {
+	i := sort.Search(len(w.keyset), func(i int) bool { return w.keyset[i] >= key })
+	return i, i < len(w.keyset) && w.keyset[i] == key
+}
+
+// Len returns the length of s.
+func (w *DynamicInt64Keys) Len() int {
+	return w.t.n
+}
+
+// Count returns the count of elements with the key in s.
+func (w *DynamicInt64Keys) Count(key int64) int {
+	id, ok := w.find(key)
+	if !ok {
+		return 0
+	}
+	return w.t.count(id)
+}
+
+// Rank returns the count of elements with the key in s[0:i].
+// i is clamped to the range [0, Len()].
+func (w *DynamicInt64Keys) Rank(key int64, i int) int {
+	id, ok := w.find(key)
+	if !ok {
+		return 0
+	}
+	return w.t.rank(id, i)
+}
+
+// Select returns the index of the r-th occurrence of the key, or -1 if there is none.
+func (w *DynamicInt64Keys) Select(key int64, r int) int {
+	id, ok := w.find(key)
+	if !ok {
+		return -1
+	}
+	return w.t.selectRank(id, r)
+}
+
+// Access returns the key of s[i]. It panics if i is out of range.
+func (w *DynamicInt64Keys) Access(i int) int64 {
+	w.t.check(i, w.t.n)
+	return w.keyset[w.t.access(i)]
+}
+
+// Insert inserts an element with the key before s[i], or at the end of s if i is Len(). It panics
+// if i is out of range or the key is not in the alphabet.
+func (w *DynamicInt64Keys) Insert(i int, key int64) {
+	w.t.check(i, w.t.n+1)
+	id, ok := w.find(key)
+	if !ok {
+		panic(fmt.Sprintf("wltree: key %v not in the alphabet", key))
+	}
+	w.t.insert(i, id, false)
+}
+
+// Delete deletes s[i] and returns its key. It panics if i is out of range.
+func (w *DynamicInt64Keys) Delete(i int) int64 {
+	w.t.check(i, w.t.n)
+	id, _ := w.t.delete(i, false)
+	return w.keyset[id]
+}
+
+// Update replaces the key of s[i] by the key, and returns the replaced key. It panics if i is out
+// of range or the key is not in the alphabet.
+func (w *DynamicInt64Keys) Update(i int, key int64) int64 {
+	w.t.check(i, w.t.n)
+	id, ok := w.find(key)
+	if !ok {
+		panic(fmt.Sprintf("wltree: key %v not in the alphabet", key))
+	}
+	old, _ := w.t.delete(i, false)
+	w.t.insert(i, id, false)
+	return w.keyset[old]
+}
+
+// dynTree is a balanced wavelet tree on the symbols 0 to σ-1, whose codes are their depth-bit
+// binary representations, with dynBits nodes. Nodes are made on the first insertion through
+// them. The edits take a cow flag, with which they copy the nodes they change instead of
+// modifying them and return a new tree that shares the other nodes with t.
+type dynTree struct {
+	root  *dynNode
+	depth int
+	n     int
+}
+
+type dynNode struct {
+	bits  dynBits
+	child [2]*dynNode
+}
+
+func newDynTree(sigma int) *dynTree {
+	t := &dynTree{}
+	if sigma > 1 {
+		t.depth = bits.Len(uint(sigma - 1))
+	}
+	return t
+}
+
+// count returns the number of occurrences of the symbol.
+func (t *dynTree) count(id int) int {
+	return t.rank(id, t.n)
+}
+
+// check panics unless i is in [0, n).
+func (t *dynTree) check(i, n int) {
+	if i < 0 || i >= n {
+		panic(fmt.Sprintf("wltree: index %v out of range [0, %v)", i, n))
+	}
+}
+
+// bit returns the branch of the symbol at depth d.
+func (t *dynTree) bit(id, d int) int {
+	return id >> uint(t.depth-1-d) & 1
+}
+
+// rank returns the number of the bits b in the first i bits of n.
+func (n *dynNode) rank(b, i int) int {
+	if b == 1 {
+		return n.bits.Rank1(i)
+	}
+	return n.bits.Rank0(i)
+}
+
+func (t *dynTree) rank(id, i int) int {
+	i = clamp(i, t.n)
+	n := t.root
+	for d := 0; d < t.depth; d++ {
+		if n == nil {
+			return 0
+		}
+		b := t.bit(id, d)
+		i = n.rank(b, i)
+		n = n.child[b]
+	}
+	return i
+}
+
+func (t *dynTree) selectRank(id, r int) int {
+	if r < 0 || r >= t.count(id) {
+		return -1
+	}
+	var path [64]*dynNode
+	n := t.root
+	for d := 0; d < t.depth; d++ {
+		path[d] = n
+		n = n.child[t.bit(id, d)]
+	}
+	for d := t.depth - 1; d >= 0; d-- {
+		if t.bit(id, d) == 1 {
+			r = path[d].bits.Select1(r)
+		} else {
+			r = path[d].bits.Select0(r)
+		}
+	}
+	return r
+}
+
+func (t *dynTree) access(i int) int {
+	id := 0
+	n := t.root
+	for d := 0; d < t.depth; d++ {
+		b := 0
+		if n.bits.Access(i) {
+			b = 1
+		}
+		i = n.rank(b, i)
+		id = id<<1 | b
+		n = n.child[b]
+	}
+	return id
+}
+
+// insert inserts the symbol at position i, and returns the tree, which is a new one if cow.
+func (t *dynTree) insert(i, id int, cow bool) *dynTree {
+	if cow {
+		c := *t
+		t = &c
+	}
+	p := &t.root
+	for d := 0; d < t.depth; d++ {
+		n := *p
+		if n == nil {
+			n = &dynNode{}
+		} else if cow {
+			c := *n
+			n = &c
+		}
+		*p = n
+		b := t.bit(id, d)
+		n.bits.insert(i, b == 1, cow)
+		i = n.rank(b, i)
+		p = &n.child[b]
+	}
+	t.n++
+	return t
+}
+
+// delete deletes the symbol at position i, and returns it and the tree, which is a new one if cow.
+func (t *dynTree) delete(i int, cow bool) (int, *dynTree) {
+	if cow {
+		c := *t
+		t = &c
+	}
+	id := 0
+	p := &t.root
+	for d := 0; d < t.depth; d++ {
+		n := *p
+		if cow {
+			c := *n
+			n = &c
+			*p = n
+		}
+		b := 0
+		if n.bits.delete(i, cow) {
+			b = 1
+		}
+		// The bits before i are unaffected by the deletion.
+		i = n.rank(b, i)
+		id = id<<1 | b
+		p = &n.child[b]
+	}
+	t.n--
+	return id, t
+}
@@ -0,0 +1,120 @@
+package wltree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestDynamicBytes(t *testing.T) {
+	for _, ws := range weights {
+		s := random(300, ws)
+		wt := NewDynamicBytes(s)
+		for op := 0; op < 2000; op++ {
+			if len(s) > 0 && rand.Intn(2) == 0 {
+				i := rand.Intn(len(s))
+				if got := wt.Delete(i); got != s[i] {
+					t.Errorf("Delete(%v) => got %q, want %q", i, got, s[i])
+				}
+				s = append(s[:i], s[i+1:]...)
+			} else {
+				i, c := rand.Intn(len(s)+1), random(1, ws)[0]
+				wt.Insert(i, c)
+				s = append(s[:i], append([]byte{c}, s[i:]...)...)
+			}
+			if op%100 == 0 {
+				checkDynamic(t, s, wt)
+			}
+		}
+	}
+}
+
+// byteIndex is the query interface shared by the Wavelet Trees on bytestring.
+type byteIndex interface {
+	Len() int
+	Count(c byte) int
+	Rank(c byte, i int) int
+	Select(c byte, r int) int
+	Access(i int) byte
+}
+
+func checkDynamic(t *testing.T, s []byte, wt byteIndex) {
+	t.Helper()
+	if got := wt.Len(); got != len(s) {
+		t.Errorf("Len() => got %v, want %v", got, len(s))
+	}
+	for _, c := range []byte("abcdefgtz") {
+		count := 0
+		for i := 0; i <= len(s); i++ {
+			if got := wt.Rank(c, i); got != count {
+				t.Errorf("Rank(%q, %v) => got %v, want %v", c, i, got, count)
+			}
+			if i < len(s) && s[i] == c {
+				if got := wt.Select(c, count); got != i {
+					t.Errorf("Select(%q, %v) => got %v, want %v", c, count, got, i)
+				}
+				count++
+			}
+		}
+		if got := wt.Count(c); got != count {
+			t.Errorf("Count(%q) => got %v, want %v", c, got, count)
+		}
+		if got := wt.Select(c, count); got != -1 {
+			t.Errorf("Select(%q, %v) => got %v, want -1", c, count, got)
+		}
+	}
+	for i := range s {
+		if got := wt.Access(i); got != s[i] {
+			t.Errorf("Access(%v) => got %q, want %q", i, got, s[i])
+		}
+	}
+}
+
+func TestDynamicInt64Keys(t *testing.T) {
+	keys := []int64{-5, 3, 1 << 40}
+	for _, alphabet := range [][]int64{keys[:1], keys[:2], keys} {
+		var s []int64
+		wt := NewDynamicInt64Keys(intSlice(nil), alphabet)
+		for op := 0; op < 1000; op++ {
+			if len(s) > 0 && rand.Intn(3) == 0 {
+				i := rand.Intn(len(s))
+				if got := wt.Delete(i); got != s[i] {
+					t.Errorf("Delete(%v) => got %v, want %v", i, got, s[i])
+				}
+				s = append(s[:i], s[i+1:]...)
+			} else {
+				i, k := rand.Intn(len(s)+1), alphabet[rand.Intn(len(alphabet))]
+				wt.Insert(i, k)
+				s = append(s[:i], append([]int64{k}, s[i:]...)...)
+			}
+		}
+		counts := make(map[int64]int)
+		for i, k := range s {
+			if got := wt.Access(i); got != k {
+				t.Errorf("Access(%v) => got %v, want %v", i, got, k)
+			}
+			if got := wt.Rank(k, i); got != counts[k] {
+				t.Errorf("Rank(%v, %v) => got %v, want %v", k, i, got, counts[k])
+			}
+			if got := wt.Select(k, counts[k]); got != i {
+				t.Errorf("Select(%v, %v) => got %v, want %v", k, counts[k], got, i)
+			}
+			counts[k]++
+		}
+		if got := wt.Rank(7, len(s)); got != 0 {
+			t.Errorf("Rank(7) => got %v, want 0", got)
+		}
+	}
+}
+
+func TestNewDynamicInt64Keys(t *testing.T) {
+	wt := NewDynamicInt64Keys(intSlice{3, -5, 3}, []int64{9})
+	wt.Insert(1, 9)
+	for i, want := range []int64{3, 9, -5, 3} {
+		if got := wt.Access(i); got != want {
+			t.Errorf("Access(%v) => got %v, want %v", i, got, want)
+		}
+	}
+	if got, want := wt.Select(3, 1), 3; got != want {
+		t.Errorf("Select(3, 1) => got %v, want %v", got, want)
+	}
+}
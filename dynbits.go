@@ -0,0 +1,367 @@
+package wltree
+
+import (
+	"math/bits"
+	"math/rand"
+)
+
+// chunkBits is the number of bits at which a chunk of dynBits is split in two.
+const chunkBits = 1024
+
+// dynBits is a bit vector that supports inserting and deleting bits as well as rank and select,
+// all in O(log n) time. The bits are kept in chunks of less than chunkBits bits, which are the
+// nodes of a treap ordered by position.
+type dynBits struct {
+	root *chunk
+}
+
+// chunk is a node of the treap of dynBits, which holds bits bits packed LSB first in words.
+type chunk struct {
+	left, right *chunk
+	prio        uint32
+	words       []uint64
+	bits, ones  int
+	// sumBits and sumOnes are the numbers of bits and ones in the subtree rooted at the chunk.
+	sumBits, sumOnes int
+}
+
+func (b *dynBits) Len() int {
+	return b.root.sumBitsOf()
+}
+
+func (b *dynBits) Rank1(i int) int {
+	r := 0
+	for t := b.root; t != nil; {
+		ls := t.left.sumBitsOf()
+		if i <= ls {
+			t = t.left
+			continue
+		}
+		i -= ls
+		r += t.left.sumOnesOf()
+		if i <= t.bits {
+			return r + t.rank(i)
+		}
+		i -= t.bits
+		r += t.ones
+		t = t.right
+	}
+	return r
+}
+
+func (b *dynBits) Rank0(i int) int {
+	return i - b.Rank1(i)
+}
+
+func (b *dynBits) Select1(r int) int {
+	return b.selectBit(r, true)
+}
+
+func (b *dynBits) Select0(r int) int {
+	return b.selectBit(r, false)
+}
+
+// selectBit returns the position of the r-th one if one, or zero otherwise.
+func (b *dynBits) selectBit(r int, one bool) int {
+	if r < 0 {
+		panic("wltree: select with negative rank")
+	}
+	count := func(t *chunk) int {
+		if one {
+			return t.sumOnesOf()
+		}
+		return t.sumBitsOf() - t.sumOnesOf()
+	}
+	pos := 0
+	for t := b.root; t != nil; {
+		left := count(t.left)
+		if r < left {
+			t = t.left
+			continue
+		}
+		r -= left
+		pos += t.left.sumBitsOf()
+		c := t.ones
+		if !one {
+			c = t.bits - t.ones
+		}
+		if r < c {
+			return pos + t.selectBit(r, one)
+		}
+		r -= c
+		pos += t.bits
+		t = t.right
+	}
+	panic("wltree: select beyond the last bit")
+}
+
+// Access returns the i-th bit.
+func (b *dynBits) Access(i int) bool {
+	for t := b.root; ; {
+		ls := t.left.sumBitsOf()
+		if i < ls {
+			t = t.left
+			continue
+		}
+		i -= ls
+		if i < t.bits {
+			return t.words[i/64]>>uint(i%64)&1 != 0
+		}
+		i -= t.bits
+		t = t.right
+	}
+}
+
+// Insert inserts the bit at position i, which must be in [0, Len()].
+func (b *dynBits) Insert(i int, bit bool) {
+	b.insert(i, bit, false)
+}
+
+// Delete deletes the bit at position i, which must be in [0, Len()), and returns it.
+func (b *dynBits) Delete(i int) bool {
+	return b.delete(i, false)
+}
+
+// insert is like Insert, but if cow it copies the chunks it changes instead of modifying them, so
+// that copies of b made before keep their bits.
+func (b *dynBits) insert(i int, bit, cow bool) {
+	if b.root == nil {
+		b.root = &chunk{prio: rand.Uint32()}
+		cow = false
+	}
+	b.root = b.root.insert(i, bit, cow)
+}
+
+// delete is like Delete, but copies the chunks it changes if cow, as insert does.
+func (b *dynBits) delete(i int, cow bool) bool {
+	var bit bool
+	b.root, bit = b.root.delete(i, cow)
+	return bit
+}
+
+func (t *chunk) sumBitsOf() int {
+	if t == nil {
+		return 0
+	}
+	return t.sumBits
+}
+
+func (t *chunk) sumOnesOf() int {
+	if t == nil {
+		return 0
+	}
+	return t.sumOnes
+}
+
+// update recomputes the sums of t from its children.
+func (t *chunk) update() {
+	t.sumBits = t.left.sumBitsOf() + t.bits + t.right.sumBitsOf()
+	t.sumOnes = t.left.sumOnesOf() + t.ones + t.right.sumOnesOf()
+}
+
+// rank returns the number of ones in the first i bits of t.
+func (t *chunk) rank(i int) int {
+	r := 0
+	for _, w := range t.words[:i/64] {
+		r += bits.OnesCount64(w)
+	}
+	if i%64 != 0 {
+		r += bits.OnesCount64(t.words[i/64] & (1<<uint(i%64) - 1))
+	}
+	return r
+}
+
+// selectBit returns the position in t of its r-th one if one, or zero otherwise.
+func (t *chunk) selectBit(r int, one bool) int {
+	for j, w := range t.words {
+		if !one {
+			w = ^w
+			if j == len(t.words)-1 && t.bits%64 != 0 {
+				w &= 1<<uint(t.bits%64) - 1
+			}
+		}
+		if c := bits.OnesCount64(w); r >= c {
+			r -= c
+			continue
+		}
+		for ; r > 0; r-- {
+			w &= w - 1
+		}
+		return 64*j + bits.TrailingZeros64(w)
+	}
+	panic("wltree: select beyond the last bit")
+}
+
+// insert inserts the bit at position i of the subtree rooted at t, and returns its new root. If
+// cow, the chunks on the way are copied instead of modified.
+func (t *chunk) insert(i int, bit, cow bool) *chunk {
+	if cow {
+		t = t.clone()
+	}
+	ls := t.left.sumBitsOf()
+	switch {
+	case t.left != nil && i <= ls:
+		t.left = t.left.insert(i, bit, cow)
+		if t.left.prio > t.prio {
+			t = t.rotateRight()
+		}
+	case i-ls <= t.bits:
+		if cow {
+			t.words = append(make([]uint64, 0, len(t.words)+1), t.words...)
+		}
+		t.insertBit(i-ls, bit)
+		if t.bits == chunkBits {
+			t.right = t.right.pushFront(t.split(), cow)
+			if t.right.prio > t.prio {
+				t = t.rotateLeft()
+			}
+		}
+	default:
+		t.right = t.right.insert(i-ls-t.bits, bit, cow)
+		if t.right.prio > t.prio {
+			t = t.rotateLeft()
+		}
+	}
+	t.update()
+	return t
+}
+
+// delete deletes the bit at position i of the subtree rooted at t, and returns its new root and
+// the bit. A chunk left empty is removed. If cow, the chunks on the way are copied instead of
+// modified.
+func (t *chunk) delete(i int, cow bool) (*chunk, bool) {
+	if cow {
+		t = t.clone()
+	}
+	var bit bool
+	ls := t.left.sumBitsOf()
+	switch {
+	case i < ls:
+		t.left, bit = t.left.delete(i, cow)
+	case i-ls < t.bits:
+		if cow {
+			t.words = append([]uint64(nil), t.words...)
+		}
+		bit = t.removeBit(i - ls)
+		if t.bits == 0 {
+			return mergeChunks(t.left, t.right, cow), bit
+		}
+	default:
+		t.right, bit = t.right.delete(i-ls-t.bits, cow)
+	}
+	t.update()
+	return t, bit
+}
+
+// insertBit inserts the bit at position k of the bits of t.
+func (t *chunk) insertBit(k int, bit bool) {
+	if t.bits%64 == 0 {
+		t.words = append(t.words, 0)
+	}
+	w := k / 64
+	for j := len(t.words) - 1; j > w; j-- {
+		t.words[j] = t.words[j]<<1 | t.words[j-1]>>63
+	}
+	low := uint64(1)<<uint(k%64) - 1
+	t.words[w] = t.words[w]&low | (t.words[w]&^low)<<1
+	if bit {
+		t.words[w] |= 1 << uint(k%64)
+		t.ones++
+	}
+	t.bits++
+}
+
+// removeBit removes the bit at position k of the bits of t, and returns it.
+func (t *chunk) removeBit(k int) bool {
+	w := k / 64
+	bit := t.words[w]>>uint(k%64)&1 != 0
+	low := uint64(1)<<uint(k%64) - 1
+	t.words[w] = t.words[w]&low | (t.words[w]>>1)&^low
+	for j := w + 1; j < len(t.words); j++ {
+		t.words[j-1] |= t.words[j] << 63
+		t.words[j] >>= 1
+	}
+	t.bits--
+	if t.bits%64 == 0 {
+		t.words = t.words[:len(t.words)-1]
+	}
+	if bit {
+		t.ones--
+	}
+	return bit
+}
+
+// split moves the second half of the bits of t into a new chunk, and returns it.
+func (t *chunk) split() *chunk {
+	half := chunkBits / 2
+	c := &chunk{prio: rand.Uint32(), words: append([]uint64(nil), t.words[half/64:]...), bits: t.bits - half}
+	t.words, t.bits = t.words[:half/64], half
+	t.ones = t.rank(half)
+	c.ones = c.rank(c.bits)
+	c.update()
+	return c
+}
+
+// pushFront inserts the chunk c before all those of the subtree rooted at t, and returns its new
+// root. If cow, the chunks on the way are copied instead of modified.
+func (t *chunk) pushFront(c *chunk, cow bool) *chunk {
+	if t == nil {
+		return c
+	}
+	if cow {
+		t = t.clone()
+	}
+	t.left = t.left.pushFront(c, cow)
+	if t.left.prio > t.prio {
+		t = t.rotateRight()
+	}
+	t.update()
+	return t
+}
+
+func (t *chunk) rotateRight() *chunk {
+	l := t.left
+	t.left, l.right = l.right, t
+	t.update()
+	l.update()
+	return l
+}
+
+func (t *chunk) rotateLeft() *chunk {
+	r := t.right
+	t.right, r.left = r.left, t
+	t.update()
+	r.update()
+	return r
+}
+
+// mergeChunks returns the root of the treap of the chunks of a followed by those of b. If cow,
+// the chunks on the way are copied instead of modified.
+func mergeChunks(a, b *chunk, cow bool) *chunk {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.prio > b.prio:
+		if cow {
+			a = a.clone()
+		}
+		a.right = mergeChunks(a.right, b, cow)
+		a.update()
+		return a
+	default:
+		if cow {
+			b = b.clone()
+		}
+		b.left = mergeChunks(a, b.left, cow)
+		b.update()
+		return b
+	}
+}
+
+// clone returns a shallow copy of t, which shares the words of t.
+func (t *chunk) clone() *chunk {
+	c := *t
+	return &c
+}
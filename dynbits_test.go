@@ -0,0 +1,100 @@
+package wltree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestDynBits(t *testing.T) {
+	for trial := 0; trial < 20; trial++ {
+		var b dynBits
+		var want []bool
+		for op := 0; op < 5000; op++ {
+			if len(want) > 0 && rand.Intn(3) == 0 {
+				i := rand.Intn(len(want))
+				if got := b.Delete(i); got != want[i] {
+					t.Errorf("Delete(%v) => got %v, want %v", i, got, want[i])
+				}
+				want = append(want[:i], want[i+1:]...)
+			} else {
+				i, bit := rand.Intn(len(want)+1), rand.Intn(4) == 0
+				b.Insert(i, bit)
+				want = append(want[:i], append([]bool{bit}, want[i:]...)...)
+			}
+		}
+		if got := b.Len(); got != len(want) {
+			t.Errorf("Len() => got %v, want %v", got, len(want))
+		}
+		ones, zeros := 0, 0
+		for i, bit := range want {
+			if got := b.Rank1(i); got != ones {
+				t.Errorf("Rank1(%v) => got %v, want %v", i, got, ones)
+			}
+			if got := b.Access(i); got != bit {
+				t.Errorf("Access(%v) => got %v, want %v", i, got, bit)
+			}
+			if bit {
+				if got := b.Select1(ones); got != i {
+					t.Errorf("Select1(%v) => got %v, want %v", ones, got, i)
+				}
+				ones++
+			} else {
+				if got := b.Select0(zeros); got != i {
+					t.Errorf("Select0(%v) => got %v, want %v", zeros, got, i)
+				}
+				zeros++
+			}
+		}
+		if got := b.Rank1(len(want)); got != ones {
+			t.Errorf("Rank1(%v) => got %v, want %v", len(want), got, ones)
+		}
+	}
+}
+
+func TestDynBitsCopy(t *testing.T) {
+	for trial := 0; trial < 10; trial++ {
+		var b dynBits
+		var want []bool
+		for i := 0; i < 3000; i++ {
+			bit := rand.Intn(2) == 0
+			b.Insert(i, bit)
+			want = append(want, bit)
+		}
+		versions, wants := []dynBits{b}, [][]bool{want}
+		for op := 0; op < 3000; op++ {
+			// First delete from the last version at the start of its second chunk, so that chunks
+			// empty and their children merge, then edit random versions.
+			v := len(versions) - 1
+			if op >= 1500 {
+				v = rand.Intn(len(versions))
+			}
+			b, want := versions[v], append([]bool(nil), wants[v]...)
+			if op < 1500 || len(want) > 0 && rand.Intn(2) == 0 {
+				i := min(len(want)-1, chunkBits/2)
+				if op >= 1500 {
+					i = rand.Intn(len(want))
+				}
+				if got := b.delete(i, true); got != want[i] {
+					t.Errorf("delete(%v) => got %v, want %v", i, got, want[i])
+				}
+				want = append(want[:i], want[i+1:]...)
+			} else {
+				i, bit := rand.Intn(len(want)+1), rand.Intn(2) == 0
+				b.insert(i, bit, true)
+				want = append(want[:i], append([]bool{bit}, want[i:]...)...)
+			}
+			versions, wants = append(versions, b), append(wants, want)
+		}
+		for v := 0; v < len(versions); v += 37 {
+			b, want := versions[v], wants[v]
+			if got := b.Len(); got != len(want) {
+				t.Errorf("version %v: Len() => got %v, want %v", v, got, len(want))
+			}
+			for i, bit := range want {
+				if got := b.Access(i); got != bit {
+					t.Errorf("version %v: Access(%v) => got %v, want %v", v, i, got, bit)
+				}
+			}
+		}
+	}
+}
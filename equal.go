@@ -0,0 +1,58 @@
+package wltree
+
+// Equal reports whether w and v index the same sequence with the same codes and bits.
+func (w *Int64Keys) Equal(v *Int64Keys) bool {
+	if w.n != v.n || !equalFreq(w.keyset, w.counts, v.keyset, v.counts) {
+		return false
+	}
+	for i := range w.keyset {
+		if w.codes[i] != v.codes[i] {
+			return false
+		}
+	}
+	return w.root.equalTree(v.root)
+}
+
+// Equal reports whether w and v index the same sequence with the same codes and bits.
+func (w *Bytes) Equal(v *Bytes) bool {
+	if w.n != v.n || w.codes != v.codes || len(w.keyset) != len(v.keyset) {
+		return false
+	}
+	for i := range w.keyset {
+		if w.keyset[i] != v.keyset[i] || w.counts[i] != v.counts[i] {
+			return false
+		}
+	}
+	return w.root.equalTree(v.root)
+}
+
+func equalFreq(keyset1 []int64, counts1 []int, keyset2 []int64, counts2 []int) bool {
+	if len(keyset1) != len(keyset2) {
+		return false
+	}
+	for i := range keyset1 {
+		if keyset1[i] != keyset2[i] || counts1[i] != counts2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// equalTree reports whether the subtrees rooted at n and m have the same shape, keys and bits.
+func (n *node) equalTree(m *node) bool {
+	if n == nil || m == nil {
+		return n == m
+	}
+	if n.leaf() != m.leaf() || n.size != m.size {
+		return false
+	}
+	if n.leaf() {
+		return n.key == m.key
+	}
+	for i := 1; i <= n.size; i++ {
+		if n.bv.Rank1(i) != m.bv.Rank1(i) {
+			return false
+		}
+	}
+	return n.child[0].equalTree(m.child[0]) && n.child[1].equalTree(m.child[1])
+}
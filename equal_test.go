@@ -0,0 +1,27 @@
+package wltree
+
+import "testing"
+
+func TestEqual(t *testing.T) {
+	s := []byte("abracadabra")
+	w := NewBytes(s)
+	if !w.Equal(w.Clone()) {
+		t.Errorf("Bytes: Equal(Clone()) => got false, want true")
+	}
+	if w.Equal(NewBytes([]byte("abracadabrr"))) {
+		t.Errorf("Bytes: Equal(tree of another sequence) => got true, want false")
+	}
+	if !NewBytes(nil).Equal(NewBytes(nil)) {
+		t.Errorf("Bytes: empty tree Equal(empty tree) => got false, want true")
+	}
+
+	wi := NewInt64Keys(byteSlice(s))
+	if !wi.Equal(wi.Clone()) {
+		t.Errorf("IntKeys: Equal(Clone()) => got false, want true")
+	}
+	for _, other := range []string{"abracadabrx", "abracadabr", "aabracadbra", ""} {
+		if wi.Equal(NewInt64Keys(byteSlice(other))) {
+			t.Errorf("IntKeys: Equal(tree of %q) => got true, want false", other)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package wltree
+
+// Export returns the positions of the elements with each of the keys in ascending order, as the
+// posting lists of a positional inverted index, with a nil list for a key that does not occur.
+// Without keys, it exports all the keys known to w. Each list is found by a single SelectBatch.
+func (w *Int64Keys) Export(keys ...int64) map[int64][]int {
+	if len(keys) == 0 {
+		keys = w.keyset
+	}
+	lists := make(map[int64][]int, len(keys))
+	for _, key := range keys {
+		lists[key] = postings(w.Count(key), func(ranks []int) { w.SelectBatch(key, ranks, ranks) })
+	}
+	return lists
+}
+
+// Export is like Int64Keys.Export for a Wavelet Tree on bytestring.
+func (w *Bytes) Export(cs ...byte) map[byte][]int {
+	if len(cs) == 0 {
+		cs = w.keyset
+	}
+	lists := make(map[byte][]int, len(cs))
+	for _, c := range cs {
+		lists[c] = postings(w.Count(c), func(ranks []int) { w.SelectBatch(c, ranks, ranks) })
+	}
+	return lists
+}
+
+// postings returns the positions of the count occurrences of a key, which selectBatch maps from
+// their ranks in place, or nil if count is 0.
+func postings(count int, selectBatch func(ranks []int)) []int {
+	if count == 0 {
+		return nil
+	}
+	list := make([]int, count)
+	for r := range list {
+		list[r] = r
+	}
+	selectBatch(list)
+	return list
+}
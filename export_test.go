@@ -0,0 +1,32 @@
+package wltree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	for size := 0; size < maxSize; size += 17 {
+		for _, ws := range weights {
+			s := random(size, ws)
+			want := make(map[byte][]int)
+			for i, c := range s {
+				want[c] = append(want[c], i)
+			}
+			if got := NewBytes(s).Export(); !reflect.DeepEqual(got, want) {
+				t.Errorf("%q: Export() => got %v, want %v", s, got, want)
+			}
+			wantInts := make(map[int64][]int)
+			for c, list := range want {
+				wantInts[int64(c)] = list
+			}
+			if got := NewInt64Keys(byteSlice(s)).Export(); !reflect.DeepEqual(got, wantInts) {
+				t.Errorf("%q: IntKeys.Export() => got %v, want %v", s, got, wantInts)
+			}
+			wantSome := map[byte][]int{'a': want['a'], 'z': nil}
+			if got := NewBytes(s).Export('a', 'z'); !reflect.DeepEqual(got, wantSome) {
+				t.Errorf("%q: Export('a', 'z') => got %v, want %v", s, got, wantSome)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package fmindex
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/mozu0/wltree"
+	"github.com/mozu0/wltree/bwt"
+)
+
+// Documents is an FM-index of a collection of documents that lists the documents in which a
+// pattern occurs. The documents are concatenated with the byte 0 after each, and a wavelet tree on
+// the document of each suffix, in the order of the rows, yields the distinct documents among the
+// rows found by backward search, in O(log D) time per document for D documents, however often the
+// pattern occurs in them. Patterns containing the byte 0 are not found.
+type Documents struct {
+	*Index
+	// docs holds the document of the suffix at each row, or -1 for the sentinel alone.
+	docs *wltree.Int64Keys
+	// starts are the positions at which the documents start in the concatenation.
+	starts []int
+}
+
+// NewDocuments makes a Documents index of docs, which are numbered from 0 in order.
+func NewDocuments(docs [][]byte) *Documents {
+	var text []byte
+	d := &Documents{starts: make([]int, len(docs))}
+	for k, doc := range docs {
+		d.starts[k] = len(text)
+		text = append(append(text, doc...), 0)
+	}
+	sa := bwt.SuffixArray(text)
+	d.Index = build(text, sa, DefaultSampleRate)
+	ids := make(ints, len(sa))
+	for i, p := range sa {
+		ids[i] = d.doc(p)
+	}
+	// A balanced tree keeps each subtree on a contiguous range of documents.
+	d.docs = wltree.NewInt64KeysWithOptions(ids, &wltree.Options{Shape: wltree.BalancedShape})
+	return d
+}
+
+// NumDocs returns the number of documents.
+func (d *Documents) NumDocs() int {
+	return len(d.starts)
+}
+
+// doc returns the document at position p of the concatenation, or -1 for its end.
+func (d *Documents) doc(p int) int {
+	if p == d.Len() {
+		return -1
+	}
+	return sort.Search(len(d.starts), func(k int) bool { return d.starts[k] > p }) - 1
+}
+
+// Document returns the document at position p of the concatenation and the offset of p in it,
+// where the offset of the separator after a document is its length. It panics if p is out of
+// range.
+func (d *Documents) Document(p int) (doc, offset int) {
+	if p < 0 || p >= d.Len() {
+		panic(fmt.Sprintf("fmindex: position %v out of range [0, %v)", p, d.Len()))
+	}
+	doc = d.doc(p)
+	return doc, p - d.starts[doc]
+}
+
+// List returns the documents in which pattern occurs, in ascending order.
+func (d *Documents) List(pattern []byte) []int {
+	return d.ListIn(pattern, 0, d.NumDocs())
+}
+
+// ListIn returns the documents numbered from lo to hi-1 in which pattern occurs, in ascending
+// order.
+func (d *Documents) ListIn(pattern []byte, lo, hi int) []int {
+	if bytes.IndexByte(pattern, 0) >= 0 {
+		return nil
+	}
+	sp, ep := d.search(pattern)
+	var docs []int
+	for doc := range d.docs.RangeKeys(sp, ep, int64(max(lo, 0)), int64(hi)) {
+		docs = append(docs, int(doc))
+	}
+	return docs
+}
+
+// DocFreq returns the number of documents in which pattern occurs.
+func (d *Documents) DocFreq(pattern []byte) int {
+	docs, _ := d.TermFreqs(pattern)
+	return len(docs)
+}
+
+// TermFreq returns the number of occurrences of pattern in the document doc.
+func (d *Documents) TermFreq(pattern []byte, doc int) int {
+	if bytes.IndexByte(pattern, 0) >= 0 {
+		return 0
+	}
+	sp, ep := d.search(pattern)
+	return d.docs.RangeCount(sp, ep, int64(doc), int64(doc)+1)
+}
+
+// TermFreqs returns the documents in which pattern occurs, in ascending order, and the number of
+// its occurrences in each, as a posting list would hold them.
+func (d *Documents) TermFreqs(pattern []byte) (docs, freqs []int) {
+	if bytes.IndexByte(pattern, 0) >= 0 {
+		return nil, nil
+	}
+	sp, ep := d.search(pattern)
+	for doc, freq := range d.docs.RangeKeys(sp, ep, 0, int64(d.NumDocs())) {
+		docs, freqs = append(docs, int(doc)), append(freqs, freq)
+	}
+	return docs, freqs
+}
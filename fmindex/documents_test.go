@@ -0,0 +1,72 @@
+package fmindex
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestDocuments(t *testing.T) {
+	for _, alphabet := range []string{"a", "ab", "acgt"} {
+		for ndocs := 0; ndocs < 30; ndocs += 4 {
+			docs := make([][]byte, ndocs)
+			for k := range docs {
+				docs[k] = make([]byte, rand.Intn(20))
+				for i := range docs[k] {
+					docs[k][i] = alphabet[rand.Intn(len(alphabet))]
+				}
+			}
+			d := NewDocuments(docs)
+			if got := d.NumDocs(); got != ndocs {
+				t.Errorf("NumDocs() => got %v, want %v", got, ndocs)
+			}
+			p := 0
+			for k, doc := range docs {
+				for off := 0; off <= len(doc); off++ {
+					if gotDoc, gotOff := d.Document(p); gotDoc != k || gotOff != off {
+						t.Errorf("Document(%v) => got (%v, %v), want (%v, %v)", p, gotDoc, gotOff, k, off)
+					}
+					p++
+				}
+			}
+			for n := 0; n < 30; n++ {
+				pattern := make([]byte, rand.Intn(4))
+				for i := range pattern {
+					pattern[i] = alphabet[rand.Intn(len(alphabet))]
+				}
+				lo, hi := rand.Intn(ndocs+3)-1, rand.Intn(ndocs+3)-1
+				var all, in, freqs []int
+				for k, doc := range docs {
+					freq := 0
+					for i := 0; i+len(pattern) <= len(doc); i++ {
+						if bytes.HasPrefix(doc[i:], pattern) {
+							freq++
+						}
+					}
+					if got := d.TermFreq(pattern, k); got != freq {
+						t.Errorf("%q: TermFreq(%q, %v) => got %v, want %v", docs, pattern, k, got, freq)
+					}
+					if freq > 0 {
+						all, freqs = append(all, k), append(freqs, freq)
+						if lo <= k && k < hi {
+							in = append(in, k)
+						}
+					}
+				}
+				if got := d.DocFreq(pattern); got != len(all) {
+					t.Errorf("%q: DocFreq(%q) => got %v, want %v", docs, pattern, got, len(all))
+				}
+				if gotDocs, gotFreqs := d.TermFreqs(pattern); !reflect.DeepEqual(gotDocs, all) || !reflect.DeepEqual(gotFreqs, freqs) {
+					t.Errorf("%q: TermFreqs(%q) => got (%v, %v), want (%v, %v)", docs, pattern, gotDocs, gotFreqs, all, freqs)
+				}
+				if got := d.List(pattern); !reflect.DeepEqual(got, all) {
+					t.Errorf("%q: List(%q) => got %v, want %v", docs, pattern, got, all)
+				}
+				if got := d.ListIn(pattern, lo, hi); !reflect.DeepEqual(got, in) {
+					t.Errorf("%q: ListIn(%q, %v, %v) => got %v, want %v", docs, pattern, lo, hi, got, in)
+				}
+			}
+		}
+	}
+}
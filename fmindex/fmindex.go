@@ -0,0 +1,141 @@
+/*
+Package fmindex provides an FM-index, a compressed full-text index that counts and locates the
+occurrences of patterns in a text.
+
+The index is the Burrows-Wheeler transform (BWT) of the text, indexed by a wltree.Bytes, on which
+backward search finds the range of the sorted suffixes that start with a pattern in O(m log σ)
+time for a pattern of length m. Locate then maps each suffix in the range to its position in the
+text by walking back to the nearest sampled suffix array value.
+
+Example
+
+	idx := fmindex.New([]byte("abracadabra"))
+	idx.Count([]byte("abra"))  //=> 2
+	idx.Locate([]byte("abra")) //=> [0 7]
+*/
+package fmindex
+
+import (
+	"sort"
+
+	"github.com/mozu0/wltree"
+	"github.com/mozu0/wltree/bwt"
+)
+
+// DefaultSampleRate is the sample rate of the suffix array values kept by New.
+const DefaultSampleRate = 32
+
+// Index is an FM-index of a text.
+type Index struct {
+	// bwt is the BWT of the text followed by a sentinel smaller than any byte, with the sentinel,
+	// at row primary, stored as 0.
+	bwt     *wltree.Bytes
+	primary int
+	// c holds for each byte the number of rows of the suffixes that start with smaller bytes,
+	// counting the row of the sentinel.
+	c [256]int
+	// sampled marks the rows whose suffix array values, in samples, are kept.
+	sampled wltree.RankSelect
+	samples []int
+	rate    int
+}
+
+// New makes an FM-index of text, keeping every DefaultSampleRate-th suffix array value.
+func New(text []byte) *Index {
+	return NewSampled(text, DefaultSampleRate)
+}
+
+// NewSampled makes an FM-index of text, keeping the suffix array values that are multiples of
+// rate. Locate takes O(rate) steps per occurrence, and the samples take n/rate ints.
+func NewSampled(text []byte, rate int) *Index {
+	if rate <= 0 {
+		rate = DefaultSampleRate
+	}
+	return build(text, bwt.SuffixArray(text), rate)
+}
+
+// build makes the FM-index of text from its suffix array sa.
+func build(text []byte, sa []int, rate int) *Index {
+	last := make([]byte, len(sa))
+	idx := &Index{rate: rate}
+	b := wltree.DefaultBackend(len(sa))
+	for i, p := range sa {
+		if p == 0 {
+			idx.primary = i
+		} else {
+			last[i] = text[p-1]
+		}
+		if p%rate == 0 {
+			b.Set(i)
+			idx.samples = append(idx.samples, p)
+		}
+	}
+	idx.bwt = wltree.NewBytes(last)
+	idx.sampled = b.Build()
+
+	var counts [256]int
+	for _, c := range text {
+		counts[c]++
+	}
+	sum := 1
+	for c := range counts {
+		idx.c[c] = sum
+		sum += counts[c]
+	}
+	return idx
+}
+
+// Len returns the length of the text.
+func (idx *Index) Len() int {
+	return idx.bwt.Len() - 1
+}
+
+// rank returns the count of the byte c in the first i rows of the BWT, not counting the sentinel.
+func (idx *Index) rank(c byte, i int) int {
+	r := idx.bwt.Rank(c, i)
+	if c == 0 && idx.primary < i {
+		r--
+	}
+	return r
+}
+
+// search returns the range of rows of the suffixes that start with pattern.
+func (idx *Index) search(pattern []byte) (sp, ep int) {
+	sp, ep = 0, idx.bwt.Len()
+	for k := len(pattern) - 1; k >= 0 && sp < ep; k-- {
+		c := pattern[k]
+		sp = idx.c[c] + idx.rank(c, sp)
+		ep = idx.c[c] + idx.rank(c, ep)
+	}
+	return sp, ep
+}
+
+// Count returns the number of occurrences of pattern in the text. The empty pattern occurs at
+// every position, including the end of the text.
+func (idx *Index) Count(pattern []byte) int {
+	sp, ep := idx.search(pattern)
+	return ep - sp
+}
+
+// Locate returns the positions of the occurrences of pattern in the text in ascending order.
+func (idx *Index) Locate(pattern []byte) []int {
+	sp, ep := idx.search(pattern)
+	pos := make([]int, 0, ep-sp)
+	for i := sp; i < ep; i++ {
+		pos = append(pos, idx.locate(i))
+	}
+	sort.Ints(pos)
+	return pos
+}
+
+// locate returns the suffix array value of row i, by stepping from the suffix of the row to the
+// preceding ones until one is sampled. The suffix at the primary row is sampled, being the text.
+func (idx *Index) locate(i int) int {
+	steps := 0
+	for idx.sampled.Rank1(i+1) == idx.sampled.Rank1(i) {
+		c := idx.bwt.Access(i)
+		i = idx.c[c] + idx.rank(c, i)
+		steps++
+	}
+	return idx.samples[idx.sampled.Rank1(i)] + steps
+}
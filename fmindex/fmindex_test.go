@@ -0,0 +1,46 @@
+package fmindex
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	for _, alphabet := range []string{"a", "ab", "acgt", "\x00\x01\xff"} {
+		for size := 0; size < 300; size += 23 {
+			text := make([]byte, size)
+			for i := range text {
+				text[i] = alphabet[rand.Intn(len(alphabet))]
+			}
+			for _, rate := range []int{1, 3, DefaultSampleRate} {
+				idx := NewSampled(text, rate)
+				if got := idx.Len(); got != len(text) {
+					t.Errorf("%q: Len() => got %v, want %v", text, got, len(text))
+				}
+				for k := 0; k < 20; k++ {
+					pattern := make([]byte, rand.Intn(5))
+					for i := range pattern {
+						pattern[i] = alphabet[rand.Intn(len(alphabet))]
+					}
+					if l := rand.Intn(len(text) + 1); k%2 == 0 && l+len(pattern) <= len(text) {
+						pattern = text[l : l+len(pattern)]
+					}
+					want := []int{}
+					for i := 0; i <= len(text)-len(pattern); i++ {
+						if bytes.HasPrefix(text[i:], pattern) {
+							want = append(want, i)
+						}
+					}
+					if got := idx.Count(pattern); got != len(want) {
+						t.Errorf("%q: Count(%q) => got %v, want %v", text, pattern, got, len(want))
+					}
+					if got := idx.Locate(pattern); !reflect.DeepEqual(got, want) {
+						t.Errorf("%q: Locate(%q) => got %v, want %v", text, pattern, got, want)
+					}
+				}
+			}
+		}
+	}
+}
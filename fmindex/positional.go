@@ -0,0 +1,65 @@
+package fmindex
+
+import (
+	"sort"
+
+	"github.com/mozu0/wltree"
+	"github.com/mozu0/wltree/bwt"
+)
+
+// Positional is an FM-index that also counts and locates the occurrences of patterns within a
+// range of the text. It keeps the whole suffix array in a wavelet tree, whose range queries
+// restrict the rows found by backward search to the suffixes that start in the range, so that it
+// takes O(n log n) bits more than Index.
+type Positional struct {
+	*Index
+	sa *wltree.Int64Keys
+}
+
+// NewPositional makes a Positional index of text.
+func NewPositional(text []byte) *Positional {
+	sa := bwt.SuffixArray(text)
+	return &Positional{
+		Index: build(text, sa, DefaultSampleRate),
+		// A balanced tree keeps each subtree on a contiguous range of positions.
+		sa: wltree.NewInt64KeysWithOptions(ints(sa), &wltree.Options{Shape: wltree.BalancedShape}),
+	}
+}
+
+// occurrences returns the rows of the suffixes that start with pattern, and the range of the
+// positions at which pattern occurs within text[l:r], with l and r clamped to [0, Len()].
+func (p *Positional) occurrences(pattern []byte, l, r int) (sp, ep int, lo, hi int64) {
+	l, r = max(0, min(l, p.Len())), max(0, min(r, p.Len()))
+	sp, ep = p.search(pattern)
+	return sp, ep, int64(l), int64(r - len(pattern) + 1)
+}
+
+// CountIn returns the number of occurrences of pattern within text[l:r], with l and r clamped to
+// [0, Len()]. The empty pattern occurs at every position from l to r.
+func (p *Positional) CountIn(pattern []byte, l, r int) int {
+	sp, ep, lo, hi := p.occurrences(pattern, l, r)
+	return p.sa.RangeCount(sp, ep, lo, hi)
+}
+
+// LocateIn returns the positions of the occurrences of pattern within text[l:r] in ascending
+// order, with l and r clamped to [0, Len()].
+func (p *Positional) LocateIn(pattern []byte, l, r int) []int {
+	sp, ep, lo, hi := p.occurrences(pattern, l, r)
+	var pos []int
+	for key := range p.sa.RangeKeys(sp, ep, lo, hi) {
+		pos = append(pos, int(key))
+	}
+	sort.Ints(pos)
+	return pos
+}
+
+// ints is a suffix array as a wltree.Interface.
+type ints []int
+
+func (s ints) Len() int {
+	return len(s)
+}
+
+func (s ints) Key(i int) int64 {
+	return int64(s[i])
+}
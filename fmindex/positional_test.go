@@ -0,0 +1,40 @@
+package fmindex
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestPositional(t *testing.T) {
+	for _, alphabet := range []string{"a", "ab", "acgt"} {
+		for size := 0; size < 200; size += 13 {
+			text := make([]byte, size)
+			for i := range text {
+				text[i] = alphabet[rand.Intn(len(alphabet))]
+			}
+			idx := NewPositional(text)
+			for k := 0; k < 30; k++ {
+				pattern := make([]byte, rand.Intn(4))
+				for i := range pattern {
+					pattern[i] = alphabet[rand.Intn(len(alphabet))]
+				}
+				l, r := rand.Intn(len(text)+3)-1, rand.Intn(len(text)+3)-1
+				cl, cr := max(0, min(l, len(text))), max(0, min(r, len(text)))
+				var want []int
+				for i := cl; i+len(pattern) <= cr; i++ {
+					if bytes.HasPrefix(text[i:], pattern) {
+						want = append(want, i)
+					}
+				}
+				if got := idx.CountIn(pattern, l, r); got != len(want) {
+					t.Errorf("%q: CountIn(%q, %v, %v) => got %v, want %v", text, pattern, l, r, got, len(want))
+				}
+				if got := idx.LocateIn(pattern, l, r); !reflect.DeepEqual(got, want) {
+					t.Errorf("%q: LocateIn(%q, %v, %v) => got %v, want %v", text, pattern, l, r, got, want)
+				}
+			}
+		}
+	}
+}
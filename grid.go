@@ -0,0 +1,64 @@
+package wltree
+
+import (
+	"iter"
+	"sort"
+)
+
+// Point is a point of a Grid.
+type Point struct {
+	X, Y int64
+}
+
+// Grid indexes a set of points for orthogonal range queries. It sorts the points by X and keeps
+// the Y of each in a balanced Wavelet Tree, so that the points in a range of X are a range of
+// positions in the tree, and counting those in a rectangle takes O(log n) time.
+type Grid struct {
+	// xs are the X of the points in ascending order, and ys their Y in the same order.
+	xs []int64
+	ys *Int64Keys
+}
+
+// NewGrid makes a Grid on the points, which may repeat.
+func NewGrid(points []Point) *Grid {
+	sorted := append([]Point(nil), points...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].X < sorted[j].X })
+	g := &Grid{xs: make([]int64, len(sorted))}
+	for i, p := range sorted {
+		g.xs[i] = p.X
+	}
+	g.ys = NewInt64KeysWithOptions(slice[Point]{sorted, func(p Point) int64 { return p.Y }},
+		&Options{Shape: BalancedShape})
+	return g
+}
+
+// Len returns the number of points.
+func (g *Grid) Len() int {
+	return len(g.xs)
+}
+
+// Count returns the number of points with x1 <= X < x2 and y1 <= Y < y2.
+func (g *Grid) Count(x1, x2, y1, y2 int64) int {
+	l, r := g.span(x1, x2)
+	return g.ys.RangeCount(l, r, y1, y2)
+}
+
+// Report returns the points with x1 <= X < x2 and y1 <= Y < y2, one at a time so that they need
+// not be held in memory, in ascending order of Y and then of X. Each point takes O(log n) time.
+func (g *Grid) Report(x1, x2, y1, y2 int64) iter.Seq[Point] {
+	return func(yield func(Point) bool) {
+		l, r := g.span(x1, x2)
+		for i, y := range g.ys.RangeElements(l, r, y1, y2) {
+			if !yield(Point{g.xs[i], y}) {
+				return
+			}
+		}
+	}
+}
+
+// span returns the range of positions of the points with x1 <= X < x2.
+func (g *Grid) span(x1, x2 int64) (l, r int) {
+	l = sort.Search(len(g.xs), func(i int) bool { return g.xs[i] >= x1 })
+	r = sort.Search(len(g.xs), func(i int) bool { return g.xs[i] >= x2 })
+	return l, r
+}
@@ -0,0 +1,44 @@
+package wltree
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestGrid(t *testing.T) {
+	for size := 0; size < 200; size += 13 {
+		points := make([]Point, size)
+		for i := range points {
+			points[i] = Point{int64(rand.Intn(20) - 5), int64(rand.Intn(20) - 5)}
+		}
+		g := NewGrid(points)
+		if got := g.Len(); got != size {
+			t.Errorf("Len() => got %v, want %v", got, size)
+		}
+		for k := 0; k < 50; k++ {
+			x1, x2 := int64(rand.Intn(24)-7), int64(rand.Intn(24)-7)
+			y1, y2 := int64(rand.Intn(24)-7), int64(rand.Intn(24)-7)
+			var want []Point
+			for _, p := range points {
+				if x1 <= p.X && p.X < x2 && y1 <= p.Y && p.Y < y2 {
+					want = append(want, p)
+				}
+			}
+			sort.Slice(want, func(i, j int) bool {
+				return want[i].Y < want[j].Y || want[i].Y == want[j].Y && want[i].X < want[j].X
+			})
+			if got := g.Count(x1, x2, y1, y2); got != len(want) {
+				t.Errorf("%v: Count(%v, %v, %v, %v) => got %v, want %v", points, x1, x2, y1, y2, got, len(want))
+			}
+			var got []Point
+			for p := range g.Report(x1, x2, y1, y2) {
+				got = append(got, p)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%v: Report(%v, %v, %v, %v) => got %v, want %v", points, x1, x2, y1, y2, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package wltree
+
+import (
+	"fmt"
+	"iter"
+	"sort"
+)
+
+// HuffmanMatrix represents a Huffman-shaped Wavelet Matrix on int64 keys. Like WaveletMatrix it
+// stores one BitVector per level, but keys have Huffman codes of different lengths and level l
+// holds only the elements whose codes are longer than l bits, so the matrix takes as little space
+// as the Huffman-shaped tree of Int64Keys with the query speed of the matrix layout.
+// See https://doi.org/10.1016/j.is.2014.06.002 for details.
+type HuffmanMatrix struct {
+	levels []rankSelect
+	zeros  []int
+
+	// codes are the codes of the keys in keyset, and trie decodes them: trie[j][b] is the node
+	// reached from node j by the bit b, or ^k for the k-th key.
+	codes []string
+	trie  [][2]int
+
+	// keyset and counts are the distinct keys in ascending order and their occurrences.
+	keyset []int64
+	counts []int
+	n      int
+
+	errorMode ErrorMode
+}
+
+// NewHuffmanMatrix makes a Huffman-shaped Wavelet Matrix from arraylike s whose elements can
+// yield integer keys. s is read once to count the keys and once per level, and is never copied.
+func NewHuffmanMatrix(s Interface) *HuffmanMatrix {
+	keyset, counts := freq(all(s))
+	return newHuffmanMatrix(all(s), keyset, counts, 0)
+}
+
+// NewHuffmanMatrixWithOptions is like NewHuffmanMatrix, but configured by opts. Of the shape
+// options only MaxCodeLen applies.
+func NewHuffmanMatrixWithOptions(s Interface, opts *Options) *HuffmanMatrix {
+	keyset, counts := freq(all(s))
+	if opts == nil {
+		return newHuffmanMatrix(all(s), keyset, counts, 0)
+	}
+	w := newHuffmanMatrix(all(s), keyset, counts, opts.MaxCodeLen)
+	w.errorMode = opts.ErrorMode
+	return w
+}
+
+// newHuffmanMatrix makes a Huffman-shaped Wavelet Matrix from seq whose distinct keys and their
+// occurrences are keyset and counts, with codes of at most maxLen bits if it is positive.
+func newHuffmanMatrix(seq iter.Seq[int64], keyset []int64, counts []int, maxLen int) *HuffmanMatrix {
+	sortFreq(keyset, counts)
+	w := &HuffmanMatrix{keyset: keyset, counts: counts, trie: [][2]int{{}}}
+	for _, count := range counts {
+		w.n += count
+	}
+	if len(keyset) == 0 {
+		return w
+	}
+	lengths := make([]int, len(counts))
+	for i, code := range HuffmanShape.codes(counts, maxLen) {
+		lengths[i] = len(code)
+	}
+	w.codes = matrixCodes(lengths)
+	id := make(map[int64]int)
+	for i, k := range keyset {
+		id[k] = i
+		w.insert(w.codes[i], i)
+	}
+
+	for l := 0; ; l++ {
+		// Level l holds the elements with codes longer than l, stably sorted by their first l bits
+		// read backwards, so the elements of each such prefix start where the smaller reversed
+		// prefixes end.
+		start := make(map[string]int)
+		for i, code := range w.codes {
+			if len(code) > l {
+				start[code[:l]] += counts[i]
+			}
+		}
+		if len(start) == 0 {
+			break
+		}
+		size := 0
+		for _, p := range reversedOrder(start) {
+			start[p], size = size, size+start[p]
+		}
+
+		b := DefaultBackend(size)
+		zeros := size
+		for k := range seq {
+			code := w.codes[id[k]]
+			if len(code) <= l {
+				continue
+			}
+			if code[l] == '1' {
+				b.Set(start[code[:l]])
+				zeros--
+			}
+			start[code[:l]]++
+		}
+		w.levels = append(w.levels, b.Build())
+		w.zeros = append(w.zeros, zeros)
+	}
+	return w
+}
+
+// matrixCodes returns prefix codes with the given lengths such that, read backwards, the codes of
+// each length come after the prefixes of that length of all longer codes. Then the elements whose
+// codes end at a level sort last in the next level, which can leave them out.
+func matrixCodes(lengths []int) []string {
+	var leaves []int
+	for _, l := range lengths {
+		for len(leaves) <= l {
+			leaves = append(leaves, 0)
+		}
+		leaves[l]++
+	}
+	codes := make([]string, len(lengths))
+	next := make([][]string, len(leaves))
+	internal := []string{""}
+	for d := 0; d < len(leaves); d++ {
+		if d > 0 {
+			var avail []string
+			for _, p := range internal {
+				avail = append(avail, p+"0", p+"1")
+			}
+			sort.Slice(avail, func(i, j int) bool { return reversed(avail[i]) < reversed(avail[j]) })
+			internal, next[d] = avail[:len(avail)-leaves[d]], avail[len(avail)-leaves[d]:]
+		} else if leaves[0] > 0 {
+			internal, next[0] = nil, []string{""}
+		}
+	}
+	for i, l := range lengths {
+		codes[i], next[l] = next[l][0], next[l][1:]
+	}
+	return codes
+}
+
+// reversed returns s backwards.
+func reversed(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+// reversedOrder returns the keys of m in ascending order of their reverses.
+func reversedOrder(m map[string]int) []string {
+	var ps []string
+	for p := range m {
+		ps = append(ps, p)
+	}
+	sort.Slice(ps, func(i, j int) bool { return reversed(ps[i]) < reversed(ps[j]) })
+	return ps
+}
+
+// insert adds the code of the k-th key to the trie.
+func (w *HuffmanMatrix) insert(code string, k int) {
+	j := 0
+	for i := range code {
+		b := int(code[i] - '0')
+		if i == len(code)-1 {
+			w.trie[j][b] = ^k
+			return
+		}
+		if w.trie[j][b] == 0 {
+			w.trie[j][b] = len(w.trie)
+			w.trie = append(w.trie, [2]int{})
+		}
+		j = w.trie[j][b]
+	}
+}
+
+// id returns the index of the key in keyset, or false if the key is not known to w.
+func (w *HuffmanMatrix) id(key int64) (int, bool) {
+	i := sort.Search(len(w.keyset), func(i int) bool { return w.keyset[i] >= key })
+	return i, i < len(w.keyset) && w.keyset[i] == key
+}
+
+// descend returns the range that s[l:r] with the code maps to past the last level of the code.
+func (w *HuffmanMatrix) descend(code string, l, r int) (int, int) {
+	for j := range code {
+		bv := w.levels[j]
+		if code[j] == '1' {
+			l, r = w.zeros[j]+bv.Rank1(l), w.zeros[j]+bv.Rank1(r)
+		} else {
+			l, r = bv.Rank0(l), bv.Rank0(r)
+		}
+	}
+	return l, r
+}
+
+// Contains reports whether the key occurs in s.
+func (w *HuffmanMatrix) Contains(key int64) bool {
+	_, ok := w.id(key)
+	return ok
+}
+
+// Symbols returns the keys in s in ascending order, and the number of occurrences of each.
+func (w *HuffmanMatrix) Symbols() (keys []int64, counts []int) {
+	return append([]int64(nil), w.keyset...), append([]int(nil), w.counts...)
+}
+
+// Len returns the length of s.
+func (w *HuffmanMatrix) Len() int {
+	return w.n
+}
+
+// Count returns the count of elements with the key in s.
+func (w *HuffmanMatrix) Count(key int64) int {
+	if id, ok := w.id(key); ok {
+		return w.counts[id]
+	}
+	return 0
+}
+
+// Rank returns the count of elements with the key in s[0:i].
+// i is clamped to the range [0, Len()].
+func (w *HuffmanMatrix) Rank(key int64, i int) int {
+	id, ok := w.id(key)
+	if !ok {
+		return 0
+	}
+	l, r := w.descend(w.codes[id], 0, clamp(i, w.n))
+	return r - l
+}
+
+// Select returns i such that Rank(key, i) = r.
+// i.e. it returns the index of r-th occurrence of the element with the key.
+// Errors are reported as selected by Options.ErrorMode, except that Select on an empty matrix
+// always returns -1.
+func (w *HuffmanMatrix) Select(key int64, r int) int {
+	if w.n == 0 || w.errorMode == ReturnNotFound && (r < 0 || r >= w.Count(key)) {
+		return -1
+	}
+	id, ok := w.id(key)
+	if !ok {
+		panic(fmt.Sprintf("wltree: no such element with key %v in s.", key))
+	}
+
+	code := w.codes[id]
+	l, _ := w.descend(code, 0, 0)
+	r += l
+	for j := len(code) - 1; j >= 0; j-- {
+		if code[j] == '1' {
+			r = w.levels[j].Select1(r - w.zeros[j])
+		} else {
+			r = w.levels[j].Select0(r)
+		}
+	}
+	return r
+}
+
+// SelectChecked is like Select, but reports false instead of panicking or returning garbage when
+// s has no r-th occurrence of the key.
+func (w *HuffmanMatrix) SelectChecked(key int64, r int) (int, bool) {
+	if r < 0 || r >= w.Count(key) {
+		return 0, false
+	}
+	return w.Select(key, r), true
+}
+
+// Access returns the key of s[i]. It panics if i is out of range.
+func (w *HuffmanMatrix) Access(i int) int64 {
+	if i < 0 || i >= w.n {
+		panic(fmt.Sprintf("wltree: index %v out of range [0, %v)", i, w.n))
+	}
+	if len(w.levels) == 0 {
+		return w.keyset[0]
+	}
+	for l, j := 0, 0; ; l++ {
+		bv := w.levels[l]
+		b := 0
+		if r := bv.Rank1(i); bv.Rank1(i+1) > r {
+			b, i = 1, w.zeros[l]+r
+		} else {
+			i = bv.Rank0(i)
+		}
+		if j = w.trie[j][b]; j < 0 {
+			return w.keyset[^j]
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package wltree
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestHuffmanMatrix(t *testing.T) {
+	fails := 0
+	for size := 0; size < maxSize && fails < 30; size += 7 {
+		for _, sigma := range []int{1, 2, 3, 5, 8, 100} {
+			ks := make([]int, size)
+			for i := range ks {
+				// Skewed towards small keys.
+				ks[i] = rand.Intn(rand.Intn(sigma)+1)*1000 - 3000
+			}
+			wm := NewHuffmanMatrix(intSlice(ks))
+
+			counts := make(map[int64]int)
+			for i := 0; i <= len(ks) && fails < 30; i++ {
+				for _, k := range []int64{-3000, -2000, 0, 96000, 1} {
+					if got, want := wm.Rank(k, i), counts[k]; got != want {
+						t.Errorf("%v.Rank(%v, %v) => got %v, want %v", ks, k, i, got, want)
+						fails++
+					}
+				}
+				if i == len(ks) {
+					break
+				}
+				k := int64(ks[i])
+				if got, want := wm.Select(k, counts[k]), i; got != want {
+					t.Errorf("%v.Select(%v, %v) => got %v, want %v", ks, k, counts[k], got, want)
+					fails++
+				}
+				if got, want := wm.Access(i), k; got != want {
+					t.Errorf("%v.Access(%v) => got %v, want %v", ks, i, got, want)
+					fails++
+				}
+				counts[k]++
+			}
+		}
+	}
+}
+
+func TestMatrixCodes(t *testing.T) {
+	for trial := 0; trial < 100; trial++ {
+		counts := make([]int, 1+rand.Intn(20))
+		for i := range counts {
+			counts[i] = 1 + rand.Intn(50)
+		}
+		codes := HuffmanShape.codes(counts, 0)
+		lengths := make([]int, len(codes))
+		for i, code := range codes {
+			lengths[i] = len(code)
+		}
+		mcodes := matrixCodes(lengths)
+		if err := checkCodes(mcodes); err != nil {
+			t.Errorf("matrixCodes(%v) => %v: %v", lengths, mcodes, err)
+		}
+		for i, c := range mcodes {
+			if len(c) != lengths[i] {
+				t.Errorf("matrixCodes(%v) => %v, wrong lengths", lengths, mcodes)
+			}
+			for _, d := range mcodes {
+				if len(d) > len(c) && reversed(d[:len(c)]) >= reversed(c) {
+					t.Errorf("matrixCodes(%v) => %q before prefix of %q", lengths, c, d)
+				}
+			}
+		}
+	}
+
+	s := strings.Repeat("a", 100) + strings.Repeat("b", 10) + "cdefgh"
+	seq := New(byteSlice(s), HuffmanMatrixLayout)
+	if got, want := seq.Rank('a', len(s)), 100; got != want {
+		t.Errorf("Rank('a', %v) => got %v, want %v", len(s), got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package wltree
+
+import "container/heap"
+
+// huffmanLengths returns the lengths of the Huffman codes of the keys with counts. Ties between
+// equal weights are broken by age, so the lengths depend only on counts.
+func huffmanLengths(counts []int) []int {
+	lengths := make([]int, len(counts))
+	if len(counts) < 2 {
+		return lengths
+	}
+	// Trees 0 to len(counts)-1 are the leaves, and the others are made by merging two of them.
+	parent := make([]int, len(counts), 2*len(counts)-1)
+	h := &treeHeap{}
+	for i, c := range counts {
+		h.items = append(h.items, weight{c, i})
+	}
+	heap.Init(h)
+	for h.Len() > 1 {
+		a, b := heap.Pop(h).(weight), heap.Pop(h).(weight)
+		id := len(parent)
+		parent[a.id], parent[b.id] = id, id
+		parent = append(parent, -1)
+		heap.Push(h, weight{a.w + b.w, id})
+	}
+	// Parents come after their children, so the depths are filled in from the root down.
+	depth := make([]int, len(parent))
+	for id := len(parent) - 2; id >= 0; id-- {
+		depth[id] = depth[parent[id]] + 1
+	}
+	copy(lengths, depth)
+	return lengths
+}
+
+type weight struct {
+	w, id int
+}
+
+// treeHeap is a min-heap of trees by weight, then by id.
+type treeHeap struct {
+	items []weight
+}
+
+func (h *treeHeap) Len() int {
+	return len(h.items)
+}
+
+func (h *treeHeap) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	return a.w < b.w || a.w == b.w && a.id < b.id
+}
+
+func (h *treeHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *treeHeap) Push(x any) {
+	h.items = append(h.items, x.(weight))
+}
+
+func (h *treeHeap) Pop() any {
+	x := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return x
+}
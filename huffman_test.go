@@ -0,0 +1,34 @@
+package wltree
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestHuffmanLengths(t *testing.T) {
+	for trial := 0; trial < 200; trial++ {
+		counts := make([]int, 1+rand.Intn(40))
+		for i := range counts {
+			counts[i] = rand.Intn(1000)
+		}
+		lengths := huffmanLengths(counts)
+
+		// The cost of a Huffman code is the total weight of the merged trees.
+		want := 0
+		ws := append([]int(nil), counts...)
+		for len(ws) > 1 {
+			sort.Ints(ws)
+			ws = append([]int{ws[0] + ws[1]}, ws[2:]...)
+			want += ws[0]
+		}
+		got, kraft := 0, 0.0
+		for i, l := range lengths {
+			got += l * counts[i]
+			kraft += 1 / float64(uint64(1)<<uint(l))
+		}
+		if got != want || kraft != 1 {
+			t.Errorf("huffmanLengths(%v) => %v of cost %v and Kraft sum %v, want cost %v and 1", counts, lengths, got, kraft, want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+// Package char parses the characters that the command and the server of this module take as
+// arguments of queries on Wavelet Trees of bytestrings.
+package char
+
+import "strconv"
+
+// Parse parses a character given as a single byte or as its value as a number, such as 10 or 0x0a,
+// and reports whether s is either.
+func Parse(s string) (byte, bool) {
+	if len(s) == 1 {
+		return s[0], true
+	}
+	c, err := strconv.ParseUint(s, 0, 8)
+	if err != nil {
+		return 0, false
+	}
+	return byte(c), true
+}
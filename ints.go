@@ -0,0 +1,151 @@
+package wltree
+
+import (
+	"fmt"
+	"iter"
+)
+
+// NewInts makes a Wavelet Tree on int64 keys from a slice of ints.
+func NewInts(s []int) *Int64Keys {
+	keyset, counts := denseFreq(all(intSlice(s)))
+	return newInt64Keys(all(intSlice(s)), keyset, counts)
+}
+
+// NewUint16s makes a Wavelet Tree on int64 keys from a slice of uint16s.
+func NewUint16s(s []uint16) *Int64Keys {
+	keyset, counts := denseFreq(all(uint16Slice(s)))
+	return newInt64Keys(all(uint16Slice(s)), keyset, counts)
+}
+
+// NewUint32s makes a Wavelet Tree on int64 keys from a slice of uint32s.
+func NewUint32s(s []uint32) *Int64Keys {
+	keyset, counts := denseFreq(all(uint32Slice(s)))
+	return newInt64Keys(all(uint32Slice(s)), keyset, counts)
+}
+
+// denseFreq is like freq, but counts occurrences in a slice indexed by key when the keys of s
+// span a range not much larger than s itself, which avoids the map in freq.
+func denseFreq(seq iter.Seq[int64]) (keyset []int64, counts []int) {
+	size := 0
+	var lo, hi int64
+	for k := range seq {
+		if size == 0 || k < lo {
+			lo = k
+		}
+		if size == 0 || k > hi {
+			hi = k
+		}
+		size++
+	}
+	if size == 0 {
+		return nil, nil
+	}
+	if uint64(hi-lo) > uint64(4*size+256) {
+		return freq(seq)
+	}
+
+	dense := make([]int, hi-lo+1)
+	for k := range seq {
+		dense[k-lo]++
+	}
+	for k, count := range dense {
+		if count > 0 {
+			keyset = append(keyset, lo+int64(k))
+			counts = append(counts, count)
+		}
+	}
+	return
+}
+
+type intSlice []int
+
+func (s intSlice) Len() int {
+	return len(s)
+}
+func (s intSlice) Key(i int) int64 {
+	return int64(s[i])
+}
+
+type uint16Slice []uint16
+
+func (s uint16Slice) Len() int {
+	return len(s)
+}
+func (s uint16Slice) Key(i int) int64 {
+	return int64(s[i])
+}
+
+type uint32Slice []uint32
+
+func (s uint32Slice) Len() int {
+	return len(s)
+}
+func (s uint32Slice) Key(i int) int64 {
+	return int64(s[i])
+}
+
+// PackedInts is an arraylike of N unsigned integers of Width bits each, 1 to 64, packed into
+// Words least significant bit first, so that the i-th integer occupies bits i*Width to
+// (i+1)*Width-1 of the words. Integers of 64 bits are keyed by their int64 conversion.
+type PackedInts struct {
+	Words []uint64
+	Width int
+	N     int
+}
+
+func (p PackedInts) Len() int {
+	return p.N
+}
+
+func (p PackedInts) Key(i int) int64 {
+	off := i * p.Width
+	w, b := off/64, uint(off%64)
+	x := p.Words[w] >> b
+	if b+uint(p.Width) > 64 {
+		x |= p.Words[w+1] << (64 - b)
+	}
+	return int64(x & p.mask())
+}
+
+// mask returns the mask of the low Width bits.
+func (p PackedInts) mask() uint64 {
+	return ^uint64(0) >> uint(64-p.Width)
+}
+
+// all returns the sequence of the integers, unpacked a word at a time.
+func (p PackedInts) all() iter.Seq[int64] {
+	return func(yield func(int64) bool) {
+		// buf holds the have bits that follow the last integer, which are fewer than Width when
+		// the next integer continues into the next word.
+		var buf uint64
+		var have uint
+		width := uint(p.Width)
+		for i, next := 0, 0; i < p.N; i++ {
+			x := buf
+			if have >= width {
+				buf >>= width
+				have -= width
+			} else {
+				w := p.Words[next]
+				next++
+				x |= w << have
+				buf = w >> (width - have)
+				have = 64 - (width - have)
+			}
+			if !yield(int64(x & p.mask())) {
+				return
+			}
+		}
+	}
+}
+
+// NewPackedInts makes a Wavelet Tree on int64 keys from the bit-packed integers of p, which are
+// read a word at a time instead of through Key. It panics if p.Width is not between 1 and 64 or
+// p.Words holds fewer than p.N integers.
+func NewPackedInts(p PackedInts) *Int64Keys {
+	if p.Width < 1 || p.Width > 64 || len(p.Words) < (p.N*p.Width+63)/64 {
+		panic(fmt.Sprintf("wltree: %v words cannot hold %v integers of %v bits", len(p.Words), p.N, p.Width))
+	}
+	keyset, counts := denseFreq(p.all())
+	return newInt64Keys(p.all(), keyset, counts)
+}
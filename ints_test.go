@@ -0,0 +1,122 @@
+package wltree
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestInts(t *testing.T) {
+	for _, spread := range []int{4, 1000, 1 << 30} {
+		var (
+			is  []int
+			u16 []uint16
+			u32 []uint32
+		)
+		for i := 0; i < 300; i++ {
+			k := rand.Intn(spread)
+			is = append(is, k-spread/2)
+			u16 = append(u16, uint16(k))
+			u32 = append(u32, uint32(k))
+		}
+		wts := []*Int64Keys{NewInts(is), NewUint16s(u16), NewUint32s(u32)}
+		keys := []Interface{intSlice(is), uint16Slice(u16), uint32Slice(u32)}
+
+		for j, wt := range wts {
+			s := keys[j]
+			counts := make(map[int64]int)
+			for i := 0; i < s.Len(); i++ {
+				k := s.Key(i)
+				if got, want := wt.Rank(k, i), counts[k]; got != want {
+					t.Errorf("%T: Rank(%v, %v) => got %v, want %v", s, k, i, got, want)
+				}
+				if got, want := wt.Select(k, counts[k]), i; got != want {
+					t.Errorf("%T: Select(%v, %v) => got %v, want %v", s, k, counts[k], got, want)
+				}
+				counts[k]++
+			}
+		}
+	}
+}
+
+func TestNegativeKeys(t *testing.T) {
+	keys := []int64{-1, math.MinInt64, 0, -1, math.MaxInt64, -7, -1, math.MinInt64, 3}
+	s := NewSlice(keys, func(k int64) int64 { return k })
+	is := make([]int, len(keys))
+	for i, k := range keys {
+		is[i] = int(k)
+	}
+
+	trees := []*Int64Keys{s}
+	if strconv.IntSize == 64 {
+		// An int cannot hold the extreme keys on 32-bit platforms.
+		trees = append(trees, NewInts(is))
+	}
+	for _, wt := range trees {
+		counts := make(map[int64]int)
+		less := func(key int64, i int) int {
+			n := 0
+			for _, k := range keys[:i] {
+				if k < key {
+					n++
+				}
+			}
+			return n
+		}
+		for i, k := range keys {
+			if got, want := wt.Rank(k, i), counts[k]; got != want {
+				t.Errorf("Rank(%v, %v) => got %v, want %v", k, i, got, want)
+			}
+			if got, want := wt.Select(k, counts[k]), i; got != want {
+				t.Errorf("Select(%v, %v) => got %v, want %v", k, counts[k], got, want)
+			}
+			for _, key := range []int64{math.MinInt64, -1, 0, math.MaxInt64} {
+				if got, want := wt.RankLessThan(key, i), less(key, i); got != want {
+					t.Errorf("RankLessThan(%v, %v) => got %v, want %v", key, i, got, want)
+				}
+			}
+			counts[k]++
+		}
+		if key, count := wt.Mode(0, len(keys)); key != -1 || count != 3 {
+			t.Errorf("Mode(0, %v) => got (%v, %v), want (-1, 3)", len(keys), key, count)
+		}
+		if got, want := fmt.Sprint(wt.Symbols()), fmt.Sprint([]int64{math.MinInt64, -7, -1, 0, 3, math.MaxInt64}, []int{2, 1, 3, 1, 1, 1}); got != want {
+			t.Errorf("Symbols() => got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestPackedInts(t *testing.T) {
+	for _, width := range []int{1, 3, 8, 13, 32, 63, 64} {
+		for _, n := range []int{0, 1, 5, 64, 300} {
+			want := make([]int64, n)
+			words := make([]uint64, (n*width+63)/64)
+			for i := range want {
+				x := rand.Uint64() >> uint(64-width)
+				want[i] = int64(x)
+				for b := 0; b < width; b++ {
+					if x&(1<<uint(b)) != 0 {
+						words[(i*width+b)/64] |= 1 << uint((i*width+b)%64)
+					}
+				}
+			}
+			p := PackedInts{words, width, n}
+			i := 0
+			for k := range p.all() {
+				if k != want[i] || p.Key(i) != want[i] {
+					t.Errorf("PackedInts(width %v)[%v] => got %v and %v, want %v", width, i, k, p.Key(i), want[i])
+				}
+				i++
+			}
+			if i != n {
+				t.Errorf("PackedInts(width %v, n %v) => %v integers", width, n, i)
+			}
+			wt := NewPackedInts(p)
+			if !wt.Equal(NewSlice(want, func(k int64) int64 { return k })) {
+				t.Errorf("NewPackedInts(width %v, n %v) => tree differs", width, n)
+			}
+		}
+	}
+}
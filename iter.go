@@ -0,0 +1,172 @@
+package wltree
+
+import "iter"
+
+// positionsBatch is the number of positions that Positions selects at once.
+const positionsBatch = 256
+
+// Positions returns the positions of the elements with the key in ascending order, selected 256
+// at a time by SelectBatch, which shares the lookup of the key and the walk of each level among
+// them, so that even a long posting list takes little time per position and constant memory.
+// It yields nothing for a key that does not occur.
+func (w *Int64Keys) Positions(key int64) iter.Seq[int] {
+	return positions(w.Count(key), func(ranks []int) { w.SelectBatch(key, ranks, ranks) })
+}
+
+// Positions is like Int64Keys.Positions for the character c.
+func (w *Bytes) Positions(c byte) iter.Seq[int] {
+	return positions(w.Count(c), func(ranks []int) { w.SelectBatch(c, ranks, ranks) })
+}
+
+// positions returns the positions of the count occurrences of a key, which selectBatch maps from
+// their ranks in place.
+func positions(count int, selectBatch func(ranks []int)) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		buf := make([]int, min(count, positionsBatch))
+		for r := 0; r < count; r += len(buf) {
+			batch := buf[:min(len(buf), count-r)]
+			for i := range batch {
+				batch[i] = r + i
+			}
+			selectBatch(batch)
+			for _, p := range batch {
+				if !yield(p) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Iter returns the keys of s[l:r] in order, with l and r clamped as by the range queries. It keeps
+// a cursor in each node it visits, so that the next bit of a node is read directly if its bits are
+// packed in memory, as with the default backends, and otherwise from the position of the next of
+// the bits that are rarer in the node, which costs a Select per rare bit. Either way the common
+// case costs far less than the two Ranks per node of a fresh Access.
+func (w *Int64Keys) Iter(l, r int) iter.Seq[int64] {
+	l, r = clampRange(l, r, w.n)
+	return func(yield func(int64) bool) {
+		if l == r {
+			return
+		}
+		root := newCursor(w.root, l)
+		for i := l; i < r; i++ {
+			if !yield(root.next()) {
+				return
+			}
+		}
+	}
+}
+
+// Iter is like Int64Keys.Iter for a Wavelet Tree on bytestring.
+func (w *Bytes) Iter(l, r int) iter.Seq[byte] {
+	l, r = clampRange(l, r, w.n)
+	return func(yield func(byte) bool) {
+		if l == r {
+			return
+		}
+		root := newCursor(w.root, l)
+		for i := l; i < r; i++ {
+			if !yield(byte(root.next())) {
+				return
+			}
+		}
+	}
+}
+
+// cursor reads the elements of a node in order from a position on.
+type cursor struct {
+	n *node
+	// pos is the position of the next element in the node.
+	pos int
+	// data, if not nil, holds the bits of the node from the bit off on, packed LSB first.
+	data []byte
+	off  int
+	// Otherwise rare is the bit that is rarer in the node, of which total occur in the node, seen
+	// before pos and the next at nextRare, or at the end if none.
+	rare     int
+	seen     int
+	total    int
+	nextRare int
+	child    [2]*cursor
+}
+
+// newCursor returns a cursor at the position pos of n.
+func newCursor(n *node, pos int) *cursor {
+	c := &cursor{n: n, pos: pos}
+	if n.leaf() {
+		return c
+	}
+	if data, off, ok := packedData(n.bv); ok {
+		c.data, c.off = data, off
+		return c
+	}
+	c.total = n.bv.Rank1(n.size)
+	if c.total > n.size-c.total {
+		c.rare, c.total = 0, n.size-c.total
+	} else {
+		c.rare = 1
+	}
+	c.seen = rankBit(n.bv, c.rare, pos)
+	c.seek()
+	return c
+}
+
+// packedData returns the bytes that hold the bits of bv packed LSB first from the bit off on, if
+// bv stores them so.
+func packedData(bv rankSelect) (data []byte, off int, ok bool) {
+	switch b := bv.(type) {
+	case *levelSlice:
+		data, off, ok = packedData(b.bv)
+		return data, off + b.off, ok
+	case *packedBits:
+		return b.data, 0, true
+	case *lazySelectBits:
+		return b.data, 0, true
+	case *lazyBits:
+		return b.data, 0, true
+	}
+	return nil, 0, false
+}
+
+// seek finds the position of the next rare bit.
+func (c *cursor) seek() {
+	if c.seen == c.total {
+		c.nextRare = c.n.size
+	} else if c.rare == 1 {
+		c.nextRare = c.n.bv.Select1(c.seen)
+	} else {
+		c.nextRare = c.n.bv.Select0(c.seen)
+	}
+}
+
+// next returns the key of the next element of the node, and moves the cursors past it.
+func (c *cursor) next() int64 {
+	for !c.n.leaf() {
+		var bit int
+		if c.data != nil {
+			i := c.off + c.pos
+			bit = int(c.data[i/8] >> uint(i%8) & 1)
+		} else if c.pos == c.nextRare {
+			bit = c.rare
+			c.seen++
+			c.seek()
+		} else {
+			bit = 1 - c.rare
+		}
+		if c.child[bit] == nil {
+			c.child[bit] = newCursor(c.n.child[bit], rankBit(c.n.bv, bit, c.pos))
+		}
+		c.pos++
+		c = c.child[bit]
+	}
+	return c.n.key
+}
+
+// rankBit returns the number of the bits equal to bit in the first i bits of bv.
+func rankBit(bv rankSelect, bit, i int) int {
+	if bit == 1 {
+		return bv.Rank1(i)
+	}
+	return bv.Rank0(i)
+}
@@ -0,0 +1,76 @@
+package wltree
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestPositions(t *testing.T) {
+	for _, ws := range weights {
+		s := random(rand.Intn(4*positionsBatch*len(ws)), ws)
+		w, iw := NewBytes(s), NewInt64Keys(byteSlice(s))
+		for c := range ws {
+			var want []int
+			for i := range s {
+				if s[i] == c {
+					want = append(want, i)
+				}
+			}
+			if got := slices.Collect(w.Positions(c)); !slices.Equal(got, want) {
+				t.Errorf("NewBytes(%q).Positions(%q) => got %v, want %v", s, c, got, want)
+			}
+			if got := slices.Collect(iw.Positions(int64(c))); !slices.Equal(got, want) {
+				t.Errorf("NewInt64Keys(%q).Positions(%q) => got %v, want %v", s, c, got, want)
+			}
+
+			// Stopping early yields a prefix.
+			k := rand.Intn(len(want) + 1)
+			var got []int
+			for p := range w.Positions(c) {
+				if len(got) == k {
+					break
+				}
+				got = append(got, p)
+			}
+			if !slices.Equal(got, want[:k]) {
+				t.Errorf("NewBytes(%q).Positions(%q) stopped after %v => got %v, want %v", s, c, k, got, want[:k])
+			}
+		}
+		if got := slices.Collect(w.Positions(0)); len(got) != 0 {
+			t.Errorf("NewBytes(%q).Positions(0) => got %v, want none", s, got)
+		}
+	}
+}
+
+func TestIter(t *testing.T) {
+	for _, ws := range weights {
+		s := random(rand.Intn(maxSize), ws)
+		w, iw := NewBytes(s), NewInt64Keys(byteSlice(s))
+		for trial := 0; trial < 20; trial++ {
+			l, r := randomRange(len(s))
+			if got := slices.Collect(w.Iter(l, r)); !slices.Equal(got, s[l:r]) {
+				t.Errorf("NewBytes(%q).Iter(%v, %v) => got %q, want %q", s, l, r, got, s[l:r])
+			}
+			var want []int64
+			for _, c := range s[l:r] {
+				want = append(want, int64(c))
+			}
+			if got := slices.Collect(iw.Iter(l, r)); !slices.Equal(got, want) {
+				t.Errorf("NewInt64Keys(%q).Iter(%v, %v) => got %v, want %v", s, l, r, got, want)
+			}
+			// Nodes whose bits are not packed in memory are read from their rare bits.
+			for _, opts := range []*Options{{Backend: RRR}, {RunLength: true}, {Sparse: true}} {
+				if got := slices.Collect(NewBytesWithOptions(s, opts).Iter(l, r)); !slices.Equal(got, s[l:r]) {
+					t.Errorf("NewBytesWithOptions(%q, %+v).Iter(%v, %v) => got %q, want %q", s, opts, l, r, got, s[l:r])
+				}
+			}
+		}
+		if got := slices.Collect(w.Iter(-1, len(s)+1)); !slices.Equal(got, s) {
+			t.Errorf("NewBytes(%q).Iter(-1, %v) => got %q, want all", s, len(s)+1, got)
+		}
+	}
+	if got := slices.Collect(NewBytes([]byte("aaa")).Iter(1, 3)); string(got) != "aa" {
+		t.Errorf("NewBytes(aaa).Iter(1, 3) => got %q, want aa", got)
+	}
+}
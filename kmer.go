@@ -0,0 +1,131 @@
+package wltree
+
+import "fmt"
+
+// MaxK is the longest k-mer that KMers indexes. The bases of a k-mer take 2 bits each in its key,
+// and a key with the top bits set is left for the k-mers that contain N.
+const MaxK = 31
+
+// KMers is a Wavelet Tree on the k-mers of a DNA sequence, that is, on its substrings of a fixed
+// length k. The k-mer at each position is encoded as an int64 key of 2 bits per base, A, C, G and T
+// being 0 to 3, by a rolling encoder, so that the k-mers can be ranked and selected like the keys
+// of Int64Keys. Positions are those at which the k-mers start.
+type KMers struct {
+	w *Int64Keys
+	k int
+}
+
+// noKMer is the key of the k-mers that contain N.
+const noKMer = -1
+
+// NewKMers makes a Wavelet Tree on the k-mers of the DNA sequence s. It fails if k is not in
+// [1, MaxK], or if s contains a byte other than A, C, G, T and N, in upper or lower case.
+func NewKMers(s []byte, k int) (*KMers, error) {
+	if k < 1 || k > MaxK {
+		return nil, fmt.Errorf("wltree: k-mer length %v out of range [1, %v]", k, MaxK)
+	}
+	var keys []int64
+	mask := int64(1)<<(2*k) - 1
+	// key holds the last bases, and valid the number of them since the last N.
+	key, valid := int64(0), 0
+	for i, c := range s {
+		switch d := dnaDigit[c]; d {
+		case -1:
+			return nil, fmt.Errorf("wltree: invalid base %q at %v", c, i)
+		case 4:
+			valid = 0
+		default:
+			key = (key<<2 | int64(d)) & mask
+			valid++
+		}
+		if i >= k-1 {
+			if valid >= k {
+				keys = append(keys, key)
+			} else {
+				keys = append(keys, noKMer)
+			}
+		}
+	}
+	return &KMers{w: NewSlice(keys, func(key int64) int64 { return key }), k: k}, nil
+}
+
+// EncodeKMer returns the key of the k-mer, and false if it contains a byte other than A, C, G and
+// T, in upper or lower case, or is longer than MaxK.
+func EncodeKMer(kmer []byte) (int64, bool) {
+	if len(kmer) > MaxK {
+		return 0, false
+	}
+	key := int64(0)
+	for _, c := range kmer {
+		d := dnaDigit[c]
+		if d < 0 || d == 4 {
+			return 0, false
+		}
+		key = key<<2 | int64(d)
+	}
+	return key, true
+}
+
+// key returns the key of the k-mer, and false if it is not a k-mer of the length of w.
+func (w *KMers) key(kmer []byte) (int64, bool) {
+	if len(kmer) != w.k {
+		return 0, false
+	}
+	return EncodeKMer(kmer)
+}
+
+// K returns the length of the k-mers.
+func (w *KMers) K() int {
+	return w.k
+}
+
+// Len returns the number of positions of k-mers, len(s)-k+1, or 0 if s is shorter than k.
+func (w *KMers) Len() int {
+	return w.w.Len()
+}
+
+// Count returns the count of the k-mer in s. A k-mer of another length never occurs.
+func (w *KMers) Count(kmer []byte) int {
+	key, ok := w.key(kmer)
+	if !ok {
+		return 0
+	}
+	return w.w.Count(key)
+}
+
+// Rank returns the count of the k-mer at the positions in [0, i).
+// i is clamped to the range [0, Len()].
+func (w *KMers) Rank(kmer []byte, i int) int {
+	key, ok := w.key(kmer)
+	if !ok {
+		return 0
+	}
+	return w.w.Rank(key, i)
+}
+
+// Select returns the position of the r-th occurrence of the k-mer, or -1 if there is none.
+func (w *KMers) Select(kmer []byte, r int) int {
+	key, ok := w.key(kmer)
+	if !ok {
+		return -1
+	}
+	if i, ok := w.w.SelectChecked(key, r); ok {
+		return i
+	}
+	return -1
+}
+
+// Access returns the k-mer at position i, in upper case, or nil if it contains N. It panics if i
+// is out of range.
+func (w *KMers) Access(i int) []byte {
+	key := w.w.Access(i)
+	if key == noKMer {
+		return nil
+	}
+	kmer := make([]byte, w.k)
+	for j := w.k - 1; j >= 0; j-- {
+		kmer[j] = "ACGT"[key&3]
+		key >>= 2
+	}
+	return kmer
+}
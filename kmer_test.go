@@ -0,0 +1,74 @@
+package wltree
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestKMers(t *testing.T) {
+	for _, k := range []int{1, 3, MaxK} {
+		for size := 0; size < 200; size += 11 {
+			s := make([]byte, size)
+			for i := range s {
+				s[i] = "ACGTacgtN"[rand.Intn(9)]
+			}
+			w, err := NewKMers(s, k)
+			if err != nil {
+				t.Fatalf("NewKMers(%q, %v) => %v", s, k, err)
+			}
+			upper := bytes.ToUpper(s)
+			var kmers [][]byte
+			for i := 0; i+k <= len(s); i++ {
+				kmers = append(kmers, upper[i:i+k])
+			}
+			if got := w.Len(); got != len(kmers) {
+				t.Errorf("%q: Len() => got %v, want %v", s, got, len(kmers))
+			}
+			for n := 0; n < 5 && len(kmers) > 0; n++ {
+				kmer := kmers[rand.Intn(len(kmers))]
+				if bytes.IndexByte(kmer, 'N') >= 0 {
+					if got := w.Count(kmer); got != 0 {
+						t.Errorf("%q: Count(%q) => got %v, want 0", s, kmer, got)
+					}
+					continue
+				}
+				count := 0
+				for i, x := range kmers {
+					if got := w.Rank(kmer, i); got != count {
+						t.Errorf("%q: Rank(%q, %v) => got %v, want %v", s, kmer, i, got, count)
+					}
+					if bytes.Equal(x, kmer) {
+						if got := w.Select(kmer, count); got != i {
+							t.Errorf("%q: Select(%q, %v) => got %v, want %v", s, kmer, count, got, i)
+						}
+						count++
+					}
+				}
+				if got := w.Count(bytes.ToLower(kmer)); got != count {
+					t.Errorf("%q: Count(%q) => got %v, want %v", s, kmer, got, count)
+				}
+				if got := w.Select(kmer, count); got != -1 {
+					t.Errorf("%q: Select(%q, %v) => got %v, want -1", s, kmer, count, got)
+				}
+			}
+			for i, kmer := range kmers {
+				want := kmer
+				if bytes.IndexByte(kmer, 'N') >= 0 {
+					want = nil
+				}
+				if got := w.Access(i); !bytes.Equal(got, want) {
+					t.Errorf("%q: Access(%v) => got %q, want %q", s, i, got, want)
+				}
+			}
+		}
+	}
+	for _, k := range []int{0, MaxK + 1} {
+		if _, err := NewKMers([]byte("ACGT"), k); err == nil {
+			t.Errorf("NewKMers(%q, %v) => no error", "ACGT", k)
+		}
+	}
+	if _, err := NewKMers([]byte("ACXT"), 2); err == nil {
+		t.Errorf("NewKMers(%q, 2) => no error", "ACXT")
+	}
+}
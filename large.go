@@ -0,0 +1,125 @@
+package wltree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// largeChunk is the length of every part of a Large but the last. It keeps the positions within
+// the parts below 2^30, so that they fit in an int on every platform.
+var largeChunk = 1 << 30
+
+// Large is a Wavelet Tree on int64 keys whose positions are int64s, so that it indexes sequences
+// of billions of elements even on 32-bit platforms, where an Int64Keys is limited to 2^31-1
+// elements. It is made of Int64Keys parts of 2^30 elements each but the last, and queries route
+// to the parts and sum their counts, in time linear in the number of parts.
+type Large struct {
+	parts []*Int64Keys
+	n     int64
+}
+
+// NewLarge makes a Large from the sequence of keys yielded by seq, which may be a one-shot stream
+// as for NewSeq. seq is ranged over once, and only the part being built is buffered.
+func NewLarge(seq iter.Seq[int64]) *Large {
+	w := new(Large)
+	b := newSeqBuffer(0)
+	for k := range seq {
+		b.add(k)
+		if b.n == largeChunk {
+			w.add(b)
+			b = newSeqBuffer(0)
+		}
+	}
+	if b.n > 0 {
+		w.add(b)
+	}
+	return w
+}
+
+// NewLargeFromReader makes a Large on the bytes read from r until EOF, as int64 keys. The input is
+// read once.
+func NewLargeFromReader(r io.Reader) (*Large, error) {
+	var err error
+	br := bufio.NewReader(r)
+	w := NewLarge(func(yield func(int64) bool) {
+		for {
+			c, e := br.ReadByte()
+			if e != nil {
+				if e != io.EOF {
+					err = e
+				}
+				return
+			}
+			if !yield(int64(c)) {
+				return
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// add appends the part buffered in b.
+func (w *Large) add(b *seqBuffer) {
+	keyset, counts := append([]int64(nil), b.keys...), append([]int(nil), b.counts...)
+	w.parts = append(w.parts, newInt64Keys(b.all(), keyset, counts))
+	w.n += int64(b.n)
+}
+
+// Len returns the length of s.
+func (w *Large) Len() int64 {
+	return w.n
+}
+
+// Count returns the count of the key in s.
+func (w *Large) Count(key int64) int64 {
+	var count int64
+	for _, p := range w.parts {
+		count += int64(p.Count(key))
+	}
+	return count
+}
+
+// Rank returns the count of the key in s[0:i].
+// i is clamped to the range [0, Len()].
+func (w *Large) Rank(key int64, i int64) int64 {
+	i = min(max(i, 0), w.n)
+	var r int64
+	for k, p := range w.parts {
+		start := int64(k) * int64(largeChunk)
+		if i <= start+int64(p.Len()) {
+			return r + int64(p.Rank(key, int(i-start)))
+		}
+		r += int64(p.Count(key))
+	}
+	return r
+}
+
+// Select returns the position of the r-th occurrence of the key in s, counting from 0, or -1 if
+// there is none.
+func (w *Large) Select(key int64, r int64) int64 {
+	if r < 0 {
+		return -1
+	}
+	for k, p := range w.parts {
+		count := int64(p.Count(key))
+		if r < count {
+			return int64(k)*int64(largeChunk) + int64(p.Select(key, int(r)))
+		}
+		r -= count
+	}
+	return -1
+}
+
+// Access returns the key of the i-th element of s. It panics if i is out of range.
+func (w *Large) Access(i int64) int64 {
+	if i < 0 || i >= w.n {
+		panic(fmt.Sprintf("wltree: index %v out of range [0, %v)", i, w.n))
+	}
+	k := i / int64(largeChunk)
+	return w.parts[k].Access(int(i - k*int64(largeChunk)))
+}
@@ -0,0 +1,57 @@
+package wltree
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLarge(t *testing.T) {
+	defer func(n int) { largeChunk = n }(largeChunk)
+	largeChunk = 37
+
+	for _, ws := range weights {
+		s := random(5*largeChunk+7, ws)
+		for _, size := range []int{0, 1, largeChunk, len(s)} {
+			s := s[:size]
+			w, err := NewLargeFromReader(bytes.NewReader(s))
+			if err != nil {
+				t.Fatalf("NewLargeFromReader(%q) => got error %v", s, err)
+			}
+			if got := w.Len(); got != int64(len(s)) {
+				t.Errorf("%q: Len() => got %v, want %v", s, got, len(s))
+			}
+			for _, key := range []int64{'a', 'c', 'f', 'z'} {
+				var count int64
+				for i := 0; i <= len(s); i++ {
+					if got := w.Rank(key, int64(i)); got != count {
+						t.Errorf("%q: Rank(%v, %v) => got %v, want %v", s, key, i, got, count)
+					}
+					if i < len(s) && int64(s[i]) == key {
+						if got := w.Select(key, count); got != int64(i) {
+							t.Errorf("%q: Select(%v, %v) => got %v, want %v", s, key, count, got, i)
+						}
+						count++
+					}
+				}
+				if got := w.Select(key, count); got != -1 {
+					t.Errorf("%q: Select(%v, %v) => got %v, want -1", s, key, count, got)
+				}
+				if got := w.Count(key); got != count {
+					t.Errorf("%q: Count(%v) => got %v, want %v", s, key, got, count)
+				}
+			}
+			for i := range s {
+				if got := w.Access(int64(i)); got != int64(s[i]) {
+					t.Errorf("%q: Access(%v) => got %v, want %v", s, i, got, s[i])
+				}
+			}
+		}
+	}
+
+	errRead := errors.New("read failed")
+	if _, err := NewLargeFromReader(iotest.ErrReader(errRead)); err != errRead {
+		t.Errorf("NewLargeFromReader(failing reader) => got error %v, want %v", err, errRead)
+	}
+}
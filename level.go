@@ -0,0 +1,136 @@
+package wltree
+
+// nodeBuilder builds the nodes of a wavelet tree from the set bits of each node, which are set in
+// increasing order.
+type nodeBuilder interface {
+	// set sets the i-th bit of the node with the prefix.
+	set(prefix string, i int)
+	// build returns the nodes indexed by code prefix.
+	build() map[string]rankSelect
+}
+
+// levelBuilder lays out the nodes of a wavelet tree level by level: the nodes at each depth are
+// concatenated in the order of their code prefixes into a single BitVector, so that a tree takes
+// one BitVector, with its rank and select directories, per level instead of one per node. The
+// layout is not pointerless: each node is still a levelSlice of its level, allocated on its own
+// and linked into the tree of nodes, and the nodes are found by their code prefixes while building.
+type levelBuilder struct {
+	builders []BitVectorBuilder
+	offsets  map[string]int
+	sizes    map[string]int
+	// progress reports each level built, and cancels the rest.
+	progress progress
+}
+
+// newLevelBuilder returns a levelBuilder for the nodes with sizes, indexed by code prefix, that
+// builds the levels with backend.
+func newLevelBuilder(sizes map[string]int, backend Backend) *levelBuilder {
+	b := &levelBuilder{offsets: make(map[string]int), sizes: sizes}
+	var totals []int
+	for _, prefix := range prefixes(sizes) {
+		for len(totals) <= len(prefix) {
+			totals = append(totals, 0)
+		}
+		b.offsets[prefix] = totals[len(prefix)]
+		totals[len(prefix)] += sizes[prefix]
+	}
+	for _, total := range totals {
+		b.builders = append(b.builders, backend(total))
+	}
+	return b
+}
+
+// levelSizes returns the number of bits in each level of the wavelet tree whose nodes have sizes,
+// indexed by code prefix.
+func levelSizes(sizes map[string]int) []int {
+	var totals []int
+	for prefix, size := range sizes {
+		for len(totals) <= len(prefix) {
+			totals = append(totals, 0)
+		}
+		totals[len(prefix)] += size
+	}
+	return totals
+}
+
+// set sets the i-th bit of the node with the prefix.
+func (b *levelBuilder) set(prefix string, i int) {
+	b.builders[len(prefix)].Set(b.offsets[prefix] + i)
+}
+
+// build returns the nodes indexed by code prefix, or nil if canceled.
+func (b *levelBuilder) build() map[string]rankSelect {
+	levels := make([]rankSelect, len(b.builders))
+	for d, builder := range b.builders {
+		if b.progress.canceled() {
+			return nil
+		}
+		b.progress.at(d, len(b.builders))
+		levels[d] = builder.Build()
+	}
+	b.progress.at(len(b.builders), len(b.builders))
+	bvs := make(map[string]rankSelect)
+	for prefix, off := range b.offsets {
+		level := levels[len(prefix)]
+		s := &levelSlice{bv: level, off: off, n: b.sizes[prefix], ones: level.Rank1(off)}
+		switch bv := level.(type) {
+		case *packedBits:
+			s.packed = bv
+		case *lazySelectBits:
+			s.packed = bv.packedBits
+		}
+		bvs[prefix] = s
+	}
+	return bvs
+}
+
+// levelSlice is a node of a wavelet tree of n bits stored at offset off of the BitVector of its
+// level, which has ones set bits before the node.
+type levelSlice struct {
+	bv   rankSelect
+	off  int
+	n    int
+	ones int
+	// packed is bv if it is packed in memory, for rankPath to rank without going through bv.
+	packed *packedBits
+}
+
+// rankPath returns the number of the first i bits of the nodes along the code, from the root down,
+// that lead to the leaf of the code. Nodes packed in memory, as with the default backends, are
+// ranked directly on the bits of their levels, and each node is ranked once for both of its bits.
+func rankPath(code code, nodes []rankSelect, i int) int {
+	for j, nd := range nodes {
+		var ones int
+		if s, ok := nd.(*levelSlice); ok && s.packed != nil {
+			ones = s.packed.Rank1(s.off+i) - s.ones
+		} else {
+			ones = nd.Rank1(i)
+		}
+		if code.bit(j) {
+			i = ones
+		} else {
+			i -= ones
+		}
+	}
+	return i
+}
+
+func (s *levelSlice) Len() int {
+	return s.n
+}
+
+func (s *levelSlice) Rank1(i int) int {
+	return s.bv.Rank1(s.off+i) - s.ones
+}
+
+func (s *levelSlice) Rank0(i int) int {
+	return i - s.Rank1(i)
+}
+
+func (s *levelSlice) Select1(r int) int {
+	return s.bv.Select1(s.ones+r) - s.off
+}
+
+func (s *levelSlice) Select0(r int) int {
+	return s.bv.Select0(s.off-s.ones+r) - s.off
+}
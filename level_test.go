@@ -0,0 +1,19 @@
+package wltree
+
+import "testing"
+
+func TestLevelLayout(t *testing.T) {
+	s := random(maxSize, weights[1])
+	wt := NewBytes(s)
+	levels := make(map[rankSelect]bool)
+	depth := 0
+	for _, c := range wt.keyset {
+		depth = max(depth, int(wt.codes[c].len))
+		for _, bv := range wt.nodes[c] {
+			levels[bv.(*levelSlice).bv] = true
+		}
+	}
+	if len(levels) != depth {
+		t.Errorf("NewBytes(%q) => %v BitVectors, want one per level, %v", s, len(levels), depth)
+	}
+}
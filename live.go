@@ -0,0 +1,121 @@
+package wltree
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Edit is the replacement of the i-th character of a bytestring by the character C.
+type Edit struct {
+	I int
+	C byte
+}
+
+// LiveBytes is a Wavelet Tree on bytestring that takes batches of point edits while readers keep
+// querying it without blocking. BulkApply publishes each batch atomically as a new Overlay on the
+// current Bytes, and a background goroutine then flattens the Overlay into a new Bytes and swaps
+// it in, so that the replacements that queries correct for stay few. Select returns -1 when s has
+// no such occurrence.
+type LiveBytes struct {
+	cur atomic.Pointer[Overlay]
+
+	// mu serializes the writers. since holds the edits applied after the Overlay being flattened,
+	// while merging.
+	mu      sync.Mutex
+	since   []Edit
+	merging bool
+	wg      sync.WaitGroup
+}
+
+// NewLiveBytes returns a LiveBytes on the bytestring of base, which it does not modify.
+func NewLiveBytes(base *Bytes) *LiveBytes {
+	l := &LiveBytes{}
+	l.cur.Store(NewOverlay(base))
+	return l
+}
+
+// BulkApply applies the edits in order, so that queries see either none or all of them. It panics,
+// applying none, if the index of an edit is out of range.
+func (l *LiveBytes) BulkApply(edits []Edit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	o := l.cur.Load()
+	for _, e := range edits {
+		if e.I < 0 || e.I >= o.Len() {
+			panic(fmt.Sprintf("wltree: index %v out of range [0, %v)", e.I, o.Len()))
+		}
+	}
+	next := o.clone()
+	for _, e := range edits {
+		next.Update(e.I, e.C)
+	}
+	l.cur.Store(next)
+	if l.merging {
+		l.since = append(l.since, edits...)
+		return
+	}
+	l.merging = true
+	l.wg.Add(1)
+	go l.merge(next)
+}
+
+// merge flattens o and swaps in an Overlay on the result with the edits applied since, until no
+// edits are left to merge.
+func (l *LiveBytes) merge(o *Overlay) {
+	defer l.wg.Done()
+	for {
+		base := o.Flatten()
+		l.mu.Lock()
+		next := NewOverlay(base)
+		for _, e := range l.since {
+			next.Update(e.I, e.C)
+		}
+		l.since = nil
+		l.cur.Store(next)
+		if len(next.pos) == 0 {
+			l.merging = false
+			l.mu.Unlock()
+			return
+		}
+		o = next
+		l.mu.Unlock()
+	}
+}
+
+// Wait waits until the edits applied so far are merged into the Bytes under the current Overlay.
+func (l *LiveBytes) Wait() {
+	l.wg.Wait()
+}
+
+// Snapshot returns the current Overlay, which later edits do not modify, to answer several queries
+// on the same version of s.
+func (l *LiveBytes) Snapshot() *Overlay {
+	return l.cur.Load()
+}
+
+// Len returns the length of s.
+func (l *LiveBytes) Len() int {
+	return l.cur.Load().Len()
+}
+
+// Count returns the count of the character c in s.
+func (l *LiveBytes) Count(c byte) int {
+	return l.cur.Load().Count(c)
+}
+
+// Rank returns the count of the character c in s[0:i].
+// i is clamped to the range [0, Len()].
+func (l *LiveBytes) Rank(c byte, i int) int {
+	return l.cur.Load().Rank(c, i)
+}
+
+// Select returns the index of the r-th occurrence of the character c, or -1 if there is none.
+func (l *LiveBytes) Select(c byte, r int) int {
+	return l.cur.Load().Select(c, r)
+}
+
+// Access returns the i-th character of s. It panics if i is out of range.
+func (l *LiveBytes) Access(i int) byte {
+	return l.cur.Load().Access(i)
+}
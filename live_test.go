@@ -0,0 +1,60 @@
+package wltree
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestLiveBytes(t *testing.T) {
+	for _, ws := range weights {
+		// Keep to 'a' and 'b', so that the readers can check the counts.
+		s := random(2000, ws)
+		for i := range s {
+			s[i] = 'a' + s[i]%2
+		}
+		l := NewLiveBytes(NewBytes(s))
+		done := make(chan struct{})
+		var readers sync.WaitGroup
+		for k := 0; k < 4; k++ {
+			readers.Add(1)
+			go func() {
+				defer readers.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+					// A snapshot holds all the edits of a batch or none.
+					o := l.Snapshot()
+					if got := o.Count('a') + o.Count('b'); got != o.Len() {
+						t.Errorf("Count('a') + Count('b') => got %v, want %v", got, o.Len())
+					}
+				}
+			}()
+		}
+		for batch := 0; batch < 50; batch++ {
+			var edits []Edit
+			for k := rand.Intn(20); k >= 0; k-- {
+				e := Edit{rand.Intn(len(s)), 'a'}
+				if rand.Intn(2) == 0 {
+					e.C = 'b'
+				}
+				edits = append(edits, e)
+				s[e.I] = e.C
+			}
+			l.BulkApply(edits)
+			if batch%10 == 0 {
+				checkDynamic(t, s, l)
+			}
+		}
+		close(done)
+		readers.Wait()
+		l.Wait()
+		if got := len(l.Snapshot().pos); got != 0 {
+			t.Errorf("len(Snapshot().pos) after Wait() => got %v, want 0", got)
+		}
+		checkDynamic(t, s, l)
+	}
+}
@@ -0,0 +1,240 @@
+package wltree
+
+import (
+	"fmt"
+	"iter"
+	"math/bits"
+	"sort"
+)
+
+// WaveletMatrix represents a Wavelet Matrix on int64 keys. Like Int64Keys it answers Rank,
+// Select and Access, but it stores one BitVector of Len() bits per level instead of one per tree
+// node. Keys are numbered densely in ascending order and take ceil(log2 σ) levels for σ distinct
+// keys, which is smaller and faster than a Huffman-shaped tree for large alphabets.
+// See https://doi.org/10.1016/j.is.2014.06.002 for details.
+type WaveletMatrix struct {
+	// levels are the BitVectors of the levels from the most significant bit of the key numbers,
+	// and zeros the number of zeros in each.
+	levels []rankSelect
+	zeros  []int
+
+	// keyset and counts are the distinct keys in ascending order and their occurrences.
+	keyset []int64
+	counts []int
+	n      int
+
+	errorMode ErrorMode
+}
+
+// NewWaveletMatrix makes a Wavelet Matrix from arraylike s whose elements can yield integer keys.
+// s is read once to count the keys and once per level, and is never copied.
+func NewWaveletMatrix(s Interface) *WaveletMatrix {
+	keyset, counts := freq(all(s))
+	return newWaveletMatrix(all(s), keyset, counts)
+}
+
+// NewWaveletMatrixWithOptions is like NewWaveletMatrix, but configured by opts.
+func NewWaveletMatrixWithOptions(s Interface, opts *Options) *WaveletMatrix {
+	w := NewWaveletMatrix(s)
+	if opts != nil {
+		w.errorMode = opts.ErrorMode
+	}
+	return w
+}
+
+// newWaveletMatrix makes a Wavelet Matrix from seq whose distinct keys and their occurrences are
+// keyset and counts.
+func newWaveletMatrix(seq iter.Seq[int64], keyset []int64, counts []int) *WaveletMatrix {
+	sortFreq(keyset, counts)
+	w := &WaveletMatrix{keyset: keyset, counts: counts}
+	for _, count := range counts {
+		w.n += count
+	}
+	id := make(map[int64]int)
+	for i, k := range keyset {
+		id[k] = i
+	}
+
+	depth := 0
+	if len(keyset) > 1 {
+		depth = bits.Len(uint(len(keyset) - 1))
+	}
+	for l := 0; l < depth; l++ {
+		// Level l holds the elements stably sorted by their upper l bits read backwards, so the
+		// elements of each such prefix start where the smaller reversed prefixes end.
+		shift := depth - l
+		start := make([]int, 1<<uint(l)+1)
+		for i, count := range counts {
+			start[reverse(i>>uint(shift), l)+1] += count
+		}
+		for j := 1; j < len(start); j++ {
+			start[j] += start[j-1]
+		}
+
+		b := DefaultBackend(w.n)
+		zeros := w.n
+		for k := range seq {
+			i := id[k]
+			p := reverse(i>>uint(shift), l)
+			if i>>uint(shift-1)&1 == 1 {
+				b.Set(start[p])
+				zeros--
+			}
+			start[p]++
+		}
+		w.levels = append(w.levels, b.Build())
+		w.zeros = append(w.zeros, zeros)
+	}
+	return w
+}
+
+// reverse returns the lowest l bits of x in reverse order.
+func reverse(x, l int) int {
+	if l == 0 {
+		return 0
+	}
+	return int(bits.Reverse(uint(x)) >> uint(bits.UintSize-l))
+}
+
+// id returns the number of the key, or false if the key is not known to w.
+func (w *WaveletMatrix) id(key int64) (int, bool) {
+	i := sort.Search(len(w.keyset), func(i int) bool { return w.keyset[i] >= key })
+	return i, i < len(w.keyset) && w.keyset[i] == key
+}
+
+// descend returns the range of the positions in the last level that s[l:r] with the key maps to.
+func (w *WaveletMatrix) descend(id, l, r int) (int, int) {
+	for j, bv := range w.levels {
+		if id>>uint(len(w.levels)-1-j)&1 == 1 {
+			l, r = w.zeros[j]+bv.Rank1(l), w.zeros[j]+bv.Rank1(r)
+		} else {
+			l, r = bv.Rank0(l), bv.Rank0(r)
+		}
+	}
+	return l, r
+}
+
+// Contains reports whether the key occurs in s.
+func (w *WaveletMatrix) Contains(key int64) bool {
+	_, ok := w.id(key)
+	return ok
+}
+
+// Symbols returns the keys in s in ascending order, and the number of occurrences of each.
+func (w *WaveletMatrix) Symbols() (keys []int64, counts []int) {
+	return append([]int64(nil), w.keyset...), append([]int(nil), w.counts...)
+}
+
+// Len returns the length of s.
+func (w *WaveletMatrix) Len() int {
+	return w.n
+}
+
+// Count returns the count of elements with the key in s.
+func (w *WaveletMatrix) Count(key int64) int {
+	if id, ok := w.id(key); ok {
+		return w.counts[id]
+	}
+	return 0
+}
+
+// Rank returns the count of elements with the key in s[0:i].
+// i is clamped to the range [0, Len()].
+func (w *WaveletMatrix) Rank(key int64, i int) int {
+	id, ok := w.id(key)
+	if !ok {
+		return 0
+	}
+	l, r := w.descend(id, 0, clamp(i, w.n))
+	return r - l
+}
+
+// Select returns i such that Rank(key, i) = r.
+// i.e. it returns the index of r-th occurrence of the element with the key.
+// Errors are reported as selected by Options.ErrorMode, except that Select on an empty matrix
+// always returns -1.
+func (w *WaveletMatrix) Select(key int64, r int) int {
+	if w.n == 0 || w.errorMode == ReturnNotFound && (r < 0 || r >= w.Count(key)) {
+		return -1
+	}
+	id, ok := w.id(key)
+	if !ok {
+		panic(fmt.Sprintf("wltree: no such element with key %v in s.", key))
+	}
+
+	l, _ := w.descend(id, 0, 0)
+	r += l
+	for j := len(w.levels) - 1; j >= 0; j-- {
+		if id>>uint(len(w.levels)-1-j)&1 == 1 {
+			r = w.levels[j].Select1(r - w.zeros[j])
+		} else {
+			r = w.levels[j].Select0(r)
+		}
+	}
+	return r
+}
+
+// SelectChecked is like Select, but reports false instead of panicking or returning garbage when
+// s has no r-th occurrence of the key.
+func (w *WaveletMatrix) SelectChecked(key int64, r int) (int, bool) {
+	if r < 0 || r >= w.Count(key) {
+		return 0, false
+	}
+	return w.Select(key, r), true
+}
+
+// Access returns the key of s[i]. It panics if i is out of range.
+func (w *WaveletMatrix) Access(i int) int64 {
+	if i < 0 || i >= w.n {
+		panic(fmt.Sprintf("wltree: index %v out of range [0, %v)", i, w.n))
+	}
+	id := 0
+	for j, bv := range w.levels {
+		id <<= 1
+		if r := bv.Rank1(i); bv.Rank1(i+1) > r {
+			id |= 1
+			i = w.zeros[j] + r
+		} else {
+			i = bv.Rank0(i)
+		}
+	}
+	return w.keyset[id]
+}
+
+// Sequence is the query interface shared by the representations of a sequence of int64 keys.
+type Sequence interface {
+	// Len returns the length of s.
+	Len() int
+	// Access returns the key of s[i].
+	Access(i int) int64
+	// Rank returns the count of elements with the key in s[0:i].
+	Rank(key int64, i int) int
+	// Select returns the index of the r-th occurrence of the key.
+	Select(key int64, r int) int
+}
+
+// Layout selects the representation made by New.
+type Layout int
+
+const (
+	// TreeLayout makes a Huffman-shaped Wavelet Tree, an *Int64Keys. It is the smallest for skewed
+	// key distributions.
+	TreeLayout Layout = iota
+	// MatrixLayout makes a Wavelet Matrix, a *WaveletMatrix. It suits large alphabets.
+	MatrixLayout
+	// HuffmanMatrixLayout makes a Huffman-shaped Wavelet Matrix, a *HuffmanMatrix. It is about as
+	// small as TreeLayout and about as fast as MatrixLayout.
+	HuffmanMatrixLayout
+)
+
+// New makes a sequence from arraylike s in the representation selected by layout.
+func New(s Interface, layout Layout) Sequence {
+	switch layout {
+	case MatrixLayout:
+		return NewWaveletMatrix(s)
+	case HuffmanMatrixLayout:
+		return NewHuffmanMatrix(s)
+	default:
+		return NewInt64Keys(s)
+	}
+}
@@ -0,0 +1,61 @@
+package wltree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestWaveletMatrix(t *testing.T) {
+	fails := 0
+	for size := 0; size < maxSize && fails < 30; size += 7 {
+		for _, sigma := range []int{1, 2, 3, 5, 8, 100} {
+			ks := make([]int, size)
+			for i := range ks {
+				ks[i] = rand.Intn(sigma)*1000 - 3000
+			}
+			wm := NewWaveletMatrix(intSlice(ks))
+
+			counts := make(map[int64]int)
+			for i := 0; i <= len(ks) && fails < 30; i++ {
+				for _, k := range []int64{-3000, -2000, 0, 96000, 1} {
+					if got, want := wm.Rank(k, i), counts[k]; got != want {
+						t.Errorf("%v.Rank(%v, %v) => got %v, want %v", ks, k, i, got, want)
+						fails++
+					}
+				}
+				if i == len(ks) {
+					break
+				}
+				k := int64(ks[i])
+				if got, want := wm.Select(k, counts[k]), i; got != want {
+					t.Errorf("%v.Select(%v, %v) => got %v, want %v", ks, k, counts[k], got, want)
+					fails++
+				}
+				if got, want := wm.Access(i), k; got != want {
+					t.Errorf("%v.Access(%v) => got %v, want %v", ks, i, got, want)
+					fails++
+				}
+				counts[k]++
+			}
+		}
+	}
+}
+
+func TestAccess(t *testing.T) {
+	s := []byte("abracadabra")
+	wt := NewBytes(s)
+	var seqs = []Sequence{NewInt64Keys(byteSlice(s)), New(byteSlice(s), MatrixLayout)}
+	for i, c := range s {
+		if got := wt.Access(i); got != c {
+			t.Errorf("Bytes: %q.Access(%v) => got %q, want %q", s, i, got, c)
+		}
+		for _, seq := range seqs {
+			if got := seq.Access(i); got != int64(c) {
+				t.Errorf("%T: %q.Access(%v) => got %v, want %v", seq, s, i, got, c)
+			}
+		}
+	}
+	if got := NewString("héllo").Access(1); got != 'é' {
+		t.Errorf("Runes: Access(1) => got %q, want 'é'", got)
+	}
+}
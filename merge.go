@@ -0,0 +1,114 @@
+package wltree
+
+import (
+	"context"
+	"iter"
+)
+
+// Merge returns a Wavelet Tree on the concatenation of the sequences of a and b, built with the
+// options of a and reporting errors like a. If a and b give the same codes to the keys they share,
+// as the parts of Split do, each node of the result is the concatenation of the nodes of a and b
+// with the same code prefix, copied by Select on their ones, and the result keeps their codes.
+// Otherwise it reads the keys from the trees in order and indexes them again, with their counts
+// taken from the trees, which skips the counting pass of construction. Either way the sequences
+// need not be retained, and the keys known to a or b with zero occurrences stay known.
+func Merge(a, b *Int64Keys) *Int64Keys {
+	keyset, counts := mergeFreq(a.keyset, a.counts, b.keyset, b.counts)
+	var w *Int64Keys
+	if codes, ok := mergeCodes(keyset, a, b); ok {
+		w = subTree(keyset, codes, a.opts, a.treeRange(0, a.n), b.treeRange(0, b.n))
+	} else {
+		seq := concatSeq(a.Iter(0, a.n), b.Iter(0, b.n))
+		w = newShapedInt64Keys(context.Background(), seq, keyset, counts, a.opts, new(scratch))
+	}
+	w.errorMode, w.opts = a.errorMode, a.opts
+	return w
+}
+
+// MergeBytes is like Merge for Wavelet Trees on bytestring.
+func MergeBytes(a, b *Bytes) *Bytes {
+	return bytesFrom(Merge(a.int64Keys(), b.int64Keys()))
+}
+
+// mergeCodes returns the codes of keyset, the union of the keys of a and b, and whether a and b
+// agree on them, that is, give the same codes to the keys they share, and together the codes of
+// the leaves of a single code tree.
+func mergeCodes(keyset []int64, a, b *Int64Keys) ([]code, bool) {
+	codes := make([]code, len(keyset))
+	strs := make([]string, len(keyset))
+	i, j := 0, 0
+	for k, key := range keyset {
+		inA := i < len(a.keyset) && a.keyset[i] == key
+		inB := j < len(b.keyset) && b.keyset[j] == key
+		switch {
+		case inA && inB && a.codes[i] != b.codes[j]:
+			return nil, false
+		case inA:
+			codes[k] = a.codes[i]
+		default:
+			codes[k] = b.codes[j]
+		}
+		if inA {
+			i++
+		}
+		if inB {
+			j++
+		}
+		strs[k] = codes[k].String()
+	}
+	if len(keyset) == 0 || checkCodes(strs) != nil {
+		return nil, false
+	}
+	return codes, true
+}
+
+// Split returns Wavelet Trees on s[:i] and s[i:], with i clamped to [0, Len()]. They are
+// SubTree(0, i) and SubTree(i, Len()), so they copy the bits of the nodes of w, keep the codes of
+// w, and thus know all the keys of w, and Merge of the two trees is equal to w. Both trees are
+// built with the options of w and report errors like w.
+func (w *Int64Keys) Split(i int) (*Int64Keys, *Int64Keys) {
+	i = clamp(i, w.n)
+	return w.SubTree(0, i), w.SubTree(i, w.n)
+}
+
+// Split is like Int64Keys.Split for a Wavelet Tree on bytestring.
+func (w *Bytes) Split(i int) (*Bytes, *Bytes) {
+	i = clamp(i, w.n)
+	return w.SubTree(0, i), w.SubTree(i, w.n)
+}
+
+// mergeFreq returns the union of the ascending keysets with the counts of each key summed.
+func mergeFreq(keyset1 []int64, counts1 []int, keyset2 []int64, counts2 []int) (keyset []int64, counts []int) {
+	i, j := 0, 0
+	for i < len(keyset1) || j < len(keyset2) {
+		switch {
+		case j == len(keyset2) || i < len(keyset1) && keyset1[i] < keyset2[j]:
+			keyset, counts = append(keyset, keyset1[i]), append(counts, counts1[i])
+			i++
+		case i == len(keyset1) || keyset2[j] < keyset1[i]:
+			keyset, counts = append(keyset, keyset2[j]), append(counts, counts2[j])
+			j++
+		default:
+			keyset, counts = append(keyset, keyset1[i]), append(counts, counts1[i]+counts2[j])
+			i++
+			j++
+		}
+	}
+	return keyset, counts
+}
+
+// concatSeq returns the sequence of the keys of seq1 followed by those of seq2.
+func concatSeq(seq1, seq2 iter.Seq[int64]) iter.Seq[int64] {
+	return func(yield func(int64) bool) {
+		for k := range seq1 {
+			if !yield(k) {
+				return
+			}
+		}
+		for k := range seq2 {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package wltree
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	for size := 0; size < maxSize; size += 17 {
+		for _, ws := range weights {
+			s := random(size, ws)
+			i := rand.Intn(len(s) + 1)
+			a, b := NewBytesAlphabet(s[:i], []byte("z")), NewBytes(s[i:])
+			got := MergeBytes(a, b)
+			if !bytes.Equal(got.appendTo(nil), s) || !got.Contains('z') {
+				t.Errorf("MergeBytes(%q, %q) => differs from NewBytesAlphabet(%q)", s[:i], s[i:], s)
+			}
+			if _, ok := mergeCodes(got.int64Keys().keyset, a.int64Keys(), b.int64Keys()); !ok {
+				if want := NewBytesAlphabet(s, []byte("z")); !got.Equal(want) {
+					t.Errorf("MergeBytes(%q, %q) => differs from NewBytesAlphabet(%q)", s[:i], s[i:], s)
+				}
+			}
+			for _, opts := range []*Options{nil, {Shape: BalancedShape}, {RunLength: true, ErrorMode: ReturnNotFound}} {
+				ia := NewInt64KeysWithOptions(byteSlice(s[:i]), opts)
+				ib := NewInt64KeysWithOptions(byteSlice(s[i:]), nil)
+				got := Merge(ia, ib)
+				want := NewInt64KeysWithOptions(byteSlice(s), opts)
+				if _, ok := mergeCodes(want.keyset, ia, ib); !ok && !got.Equal(want) {
+					t.Errorf("Merge(%q, %q) => differs from NewInt64KeysWithOptions(%q, %+v)", s[:i], s[i:], s, opts)
+				}
+				for j := range s {
+					if got.Access(j) != int64(s[j]) {
+						t.Errorf("Merge(%q, %q).Access(%v) => %v, want %v", s[:i], s[i:], j, got.Access(j), s[j])
+						break
+					}
+				}
+				if got.opts != ia.opts || got.errorMode != ia.errorMode {
+					t.Errorf("Merge(%q, %q) => options %+v, want %+v", s[:i], s[i:], got.opts, ia.opts)
+				}
+				if err := got.Verify(); err != nil {
+					t.Errorf("Merge(%q, %q).Verify() => %v", s[:i], s[i:], err)
+				}
+			}
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	for size := 0; size < maxSize; size += 17 {
+		for _, ws := range weights {
+			s := random(size, ws)
+			i := rand.Intn(len(s)+3) - 1
+			j := clamp(i, len(s))
+			wt := NewBytesAlphabet(s, []byte("z"))
+			a, b := wt.Split(i)
+			if want := wt.SubTree(0, j); !a.Equal(want) || !bytes.Equal(a.appendTo(nil), s[:j]) {
+				t.Errorf("%q: Split(%v) => first part differs from SubTree(0, %v)", s, i, j)
+			}
+			if want := wt.SubTree(j, len(s)); !b.Equal(want) || !bytes.Equal(b.appendTo(nil), s[j:]) {
+				t.Errorf("%q: Split(%v) => second part differs from SubTree(%v, %v)", s, i, j, len(s))
+			}
+			if got := MergeBytes(a, b); !got.Equal(wt) {
+				t.Errorf("%q: MergeBytes(Split(%v)) => differs from the tree", s, i)
+			}
+			opts := &Options{RunLength: true, ErrorMode: ReturnNotFound}
+			it := NewInt64KeysWithOptions(byteSlice(s), opts)
+			ia, ib := it.Split(i)
+			if ia.opts != it.opts || ib.errorMode != ReturnNotFound {
+				t.Errorf("%q: IntKeys.Split(%v) => options %+v, want %+v", s, i, ia.opts, it.opts)
+			}
+			if err := ia.Verify(); err != nil {
+				t.Errorf("%q: IntKeys.Split(%v) => first part Verify() => %v", s, i, err)
+			}
+			if got := Merge(ia, ib); !got.Equal(it) {
+				t.Errorf("%q: Merge(IntKeys.Split(%v)) => differs from the tree", s, i)
+			}
+		}
+	}
+}
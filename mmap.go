@@ -0,0 +1,156 @@
+package wltree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+)
+
+// Mmap is a read-only Wavelet Tree loaded from a memory-mapped file in the serialized form of
+// MarshalBinary. The bits of its nodes stay in the mapping and are paged in by the operating
+// system as queries touch them; only the codes and small rank directories live on the Go heap.
+type Mmap struct {
+	*Int64Keys
+	data  []byte
+	unmap func() error
+}
+
+// OpenMmap maps the serialized tree in the file at path and returns a tree that answers queries
+// directly against the mapped bytes. The whole file is read once to validate its checksum.
+// On platforms without mmap support the file is read into memory instead.
+// The tree must not be used after Close. The nodes are the packed bits of the file, so a tree
+// built compressed, for example with RRR, is queried uncompressed; ReadFromWithOptions loads it
+// compressed instead, into memory.
+func OpenMmap(path string) (*Mmap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, unmap, err := mmap(f)
+	if err != nil {
+		return nil, err
+	}
+	w, err := view(data, false)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	return &Mmap{Int64Keys: w, data: data, unmap: unmap}, nil
+}
+
+// Bytes returns the tree as a Wavelet Tree on bytestring, sharing the mapping with m. It fails if
+// some key of the tree is not a byte.
+func (m *Mmap) Bytes() (*Bytes, error) {
+	return asBytes(m.Int64Keys)
+}
+
+// LoadFromBytes returns the tree serialized in b by MarshalBinary without copying the bits of its
+// nodes, which keep referring to b. b must not be modified while the tree is in use. Loading
+// validates the checksum and builds small rank directories, but allocates nothing proportional to
+// the size of b. Compressed trees cannot be loaded this way, and the nodes are queried as packed
+// bits whatever options the tree was built with.
+func LoadFromBytes(b []byte) (*Int64Keys, error) {
+	return view(b, false)
+}
+
+// LoadBytesFromBytes is like LoadFromBytes, but returns a Wavelet Tree on bytestring. It fails if
+// some key of the tree is not a byte.
+func LoadBytesFromBytes(b []byte) (*Bytes, error) {
+	w, err := view(b, false)
+	if err != nil {
+		return nil, err
+	}
+	return asBytes(w)
+}
+
+// LoadLazy is like LoadFromBytes, but defers building the rank directory of each node until a
+// query first touches it, so that loading costs only a pass over b to validate its checksum.
+// Unlike LoadFromBytes it does not verify the structure of the tree; use Verify for that.
+func LoadLazy(b []byte) (*Int64Keys, error) {
+	return view(b, true)
+}
+
+// LoadBytesLazy is like LoadLazy, but returns a Wavelet Tree on bytestring. It fails if some key
+// of the tree is not a byte.
+func LoadBytesLazy(b []byte) (*Bytes, error) {
+	w, err := view(b, true)
+	if err != nil {
+		return nil, err
+	}
+	return asBytes(w)
+}
+
+// asBytes converts w into Bytes, or fails if some key of w is not a byte.
+func asBytes(w *Int64Keys) (*Bytes, error) {
+	for _, k := range w.keyset {
+		if k < 0 || k > 255 {
+			return nil, fmt.Errorf("wltree: key %v of serialized tree is not a byte", k)
+		}
+	}
+	return bytesFrom(w), nil
+}
+
+// Close unmaps the file.
+func (m *Mmap) Close() error {
+	return m.unmap()
+}
+
+// view returns the tree serialized in data, with the bits of its nodes referring to data instead
+// of being copied. If lazy, the rank directories of the nodes are built on first use and the tree
+// is not verified.
+func view(data []byte, lazy bool) (*Int64Keys, error) {
+	r := bytes.NewReader(data)
+	head, err := readMagic(r)
+	if err != nil {
+		return nil, err
+	}
+	if string(head) != magic {
+		return nil, ErrCorrupt
+	}
+	length, err := decodeHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	start := len(data) - r.Len()
+	keyset, counts, codes, err := decodeKeys(r, length)
+	if err != nil {
+		return nil, err
+	}
+
+	off := len(data) - r.Len()
+	sizes := nodeSizes(counts, codes)
+	bvs := make(map[string]rankSelect)
+	for _, prefix := range prefixes(sizes) {
+		n := byteLen(sizes[prefix])
+		if n > len(data)-off {
+			return nil, ErrCorrupt
+		}
+		if lazy {
+			bvs[prefix] = &lazyBits{data: data[off : off+n : off+n], size: sizes[prefix]}
+		} else {
+			bvs[prefix] = newPackedBits(data[off:off+n:off+n], sizes[prefix])
+		}
+		off += n
+	}
+	if len(data)-off != 4 {
+		return nil, ErrCorrupt
+	}
+	if binary.LittleEndian.Uint32(data[off:]) != crc32.Checksum(data[start:off], crcTable) {
+		return nil, ErrChecksum
+	}
+
+	w := assemble(keyset, counts, codes, bvs, sizes)
+	if length != uint64(w.n) {
+		return nil, ErrCorrupt
+	}
+	if lazy {
+		return w, nil
+	}
+	if err := w.Verify(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package wltree
+
+import (
+	"io"
+	"os"
+)
+
+// mmap reads the whole of f into memory, for platforms without mmap support.
+func mmap(f *os.File) ([]byte, func() error, error) {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
@@ -0,0 +1,138 @@
+package wltree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenMmap(t *testing.T) {
+	dir := t.TempDir()
+	for size := 0; size < 5000; size += 1 + size/2 {
+		for _, ws := range weights {
+			bs := random(size, ws)
+			path := filepath.Join(dir, "tree")
+			data, err := NewBytes(bs).MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := OpenMmap(path)
+			if err != nil {
+				t.Fatalf("%q: OpenMmap() => %v", bs, err)
+			}
+			wt, err := m.Bytes()
+			if err != nil {
+				t.Fatalf("%q: Bytes() => %v", bs, err)
+			}
+			var counts [256]int
+			for i, c := range bs {
+				if got, want := m.Rank(int64(c), i), counts[c]; got != want {
+					t.Errorf("%q: Rank(%q, %v) => got %v, want %v", bs, c, i, got, want)
+				}
+				if got, want := wt.Select(c, counts[c]), i; got != want {
+					t.Errorf("%q: Select(%q, %v) => got %v, want %v", bs, c, counts[c], got, want)
+				}
+				counts[c]++
+			}
+			if !wt.Equal(NewBytes(bs)) {
+				t.Errorf("%q: mapped tree differs from built tree", bs)
+			}
+			if err := m.Close(); err != nil {
+				t.Errorf("Close() => %v", err)
+			}
+		}
+	}
+
+	path := filepath.Join(dir, "corrupt")
+	if err := os.WriteFile(path, []byte("wltr\x01\x05garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenMmap(path); err == nil {
+		t.Errorf("OpenMmap(corrupt file) => got nil error")
+	}
+}
+
+func TestLoadFromBytes(t *testing.T) {
+	s := []byte("abracadabra")
+	data, err := NewBytes(s).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := LoadBytesFromBytes(data)
+	if err != nil {
+		t.Fatalf("LoadBytesFromBytes() => %v", err)
+	}
+	if !wt.Equal(NewBytes(s)) {
+		t.Errorf("LoadBytesFromBytes() => tree differs from built tree")
+	}
+	if got, want := wt.Select('a', 4), 10; got != want {
+		t.Errorf("Select('a', 4) => got %v, want %v", got, want)
+	}
+
+	wti, err := LoadFromBytes(data)
+	if err != nil {
+		t.Fatalf("LoadFromBytes() => %v", err)
+	}
+	if got, want := wti.Rank('r', len(s)), 2; got != want {
+		t.Errorf("Rank('r', %v) => got %v, want %v", len(s), got, want)
+	}
+
+	for i := 0; i < len(data); i++ {
+		if _, err := LoadFromBytes(data[:i]); err == nil {
+			t.Errorf("LoadFromBytes(%v of %v bytes) => got nil error", i, len(data))
+		}
+	}
+	data, _ = NewInts([]int{-1, 300}).MarshalBinary()
+	if _, err := LoadBytesFromBytes(data); err == nil {
+		t.Errorf("LoadBytesFromBytes(non-byte keys) => got nil error")
+	}
+}
+
+func TestLoadLazy(t *testing.T) {
+	s := []byte("the quick brown fox jumps over the lazy dog")
+	data, err := NewBytes(s).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := LoadBytesLazy(data)
+	if err != nil {
+		t.Fatalf("LoadBytesLazy() => %v", err)
+	}
+	built := func() (n int) {
+		seen := make(map[rankSelect]bool)
+		for _, path := range wt.nodes {
+			for _, bv := range path {
+				if lb := bv.(*lazyBits); lb.built() && !seen[bv] {
+					seen[bv] = true
+					n++
+				}
+			}
+		}
+		return n
+	}
+	if got := built(); got != 0 {
+		t.Errorf("LoadBytesLazy() => %v nodes built, want 0", got)
+	}
+	if got, want := wt.Rank('o', len(s)), 4; got != want {
+		t.Errorf("Rank('o', %v) => got %v, want %v", len(s), got, want)
+	}
+	if got, want := built(), len(wt.nodes['o']); got != want {
+		t.Errorf("Rank('o') => %v nodes built, want %v", got, want)
+	}
+	if err := wt.Verify(); err != nil {
+		t.Errorf("Verify() => %v", err)
+	}
+	if !wt.Equal(NewBytes(s)) {
+		t.Errorf("LoadBytesLazy() => tree differs from built tree")
+	}
+
+	for i := 0; i < len(data); i++ {
+		if _, err := LoadLazy(data[:i]); err == nil {
+			t.Errorf("LoadLazy(%v of %v bytes) => got nil error", i, len(data))
+		}
+	}
+}
@@ -0,0 +1,28 @@
+//go:build unix
+
+package wltree
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmap maps the whole of f read-only, and returns the mapping and a function that unmaps it.
+func mmap(f *os.File) ([]byte, func() error, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	size := fi.Size()
+	if size == 0 {
+		return nil, func() error { return nil }, nil
+	}
+	if int64(int(size)) != size {
+		return nil, nil, ErrTooLong
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
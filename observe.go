@@ -0,0 +1,129 @@
+package wltree
+
+import (
+	"strconv"
+	"time"
+)
+
+// Op is the type of a query reported to an Observer.
+type Op int
+
+const (
+	// OpAccess is Access, reported with the key it returns.
+	OpAccess Op = iota
+	// OpRank is Rank, reported with the key asked for.
+	OpRank
+	// OpSelect is Select, reported with the key asked for.
+	OpSelect
+)
+
+// String returns the name of the query, such as "rank".
+func (op Op) String() string {
+	switch op {
+	case OpAccess:
+		return "access"
+	case OpRank:
+		return "rank"
+	case OpSelect:
+		return "select"
+	}
+	return "Op(" + strconv.Itoa(int(op)) + ")"
+}
+
+// Observer receives a report of each query on an Observed or ObservedBytes, such as to count the
+// queries by type and key and to record their latencies in a metrics system. It must be safe for
+// concurrent use if the queries are concurrent, and should be quick, as it adds to every query.
+type Observer interface {
+	// ObserveQuery reports a query of type op about the key, which took d.
+	ObserveQuery(op Op, key int64, d time.Duration)
+}
+
+// ObserverFunc adapts a function to an Observer.
+type ObserverFunc func(op Op, key int64, d time.Duration)
+
+// ObserveQuery calls f(op, key, d).
+func (f ObserverFunc) ObserveQuery(op Op, key int64, d time.Duration) {
+	f(op, key, d)
+}
+
+// Observed is a Sequence that reports the queries on another Sequence to an Observer. Len is not
+// reported.
+type Observed struct {
+	seq Sequence
+	obs Observer
+}
+
+// NewObserved returns a Sequence that answers queries from seq and reports them to obs.
+func NewObserved(seq Sequence, obs Observer) *Observed {
+	return &Observed{seq, obs}
+}
+
+// Len returns the length of s.
+func (o *Observed) Len() int {
+	return o.seq.Len()
+}
+
+// Access returns the key of s[i].
+func (o *Observed) Access(i int) int64 {
+	start := time.Now()
+	key := o.seq.Access(i)
+	o.obs.ObserveQuery(OpAccess, key, time.Since(start))
+	return key
+}
+
+// Rank returns the count of elements with the key in s[0:i].
+func (o *Observed) Rank(key int64, i int) int {
+	start := time.Now()
+	r := o.seq.Rank(key, i)
+	o.obs.ObserveQuery(OpRank, key, time.Since(start))
+	return r
+}
+
+// Select returns the index of the r-th occurrence of the key.
+func (o *Observed) Select(key int64, r int) int {
+	start := time.Now()
+	i := o.seq.Select(key, r)
+	o.obs.ObserveQuery(OpSelect, key, time.Since(start))
+	return i
+}
+
+// ObservedBytes is like Observed for a Wavelet Tree on bytestring, reporting the characters as
+// keys.
+type ObservedBytes struct {
+	w   *Bytes
+	obs Observer
+}
+
+// NewObservedBytes returns a tree that answers queries from w and reports them to obs.
+func NewObservedBytes(w *Bytes, obs Observer) *ObservedBytes {
+	return &ObservedBytes{w, obs}
+}
+
+// Len returns the length of s.
+func (o *ObservedBytes) Len() int {
+	return o.w.Len()
+}
+
+// Access returns s[i].
+func (o *ObservedBytes) Access(i int) byte {
+	start := time.Now()
+	c := o.w.Access(i)
+	o.obs.ObserveQuery(OpAccess, int64(c), time.Since(start))
+	return c
+}
+
+// Rank returns the count of the character c in s[0:i].
+func (o *ObservedBytes) Rank(c byte, i int) int {
+	start := time.Now()
+	r := o.w.Rank(c, i)
+	o.obs.ObserveQuery(OpRank, int64(c), time.Since(start))
+	return r
+}
+
+// Select returns the index of the r-th occurrence of the character c.
+func (o *ObservedBytes) Select(c byte, r int) int {
+	start := time.Now()
+	i := o.w.Select(c, r)
+	o.obs.ObserveQuery(OpSelect, int64(c), time.Since(start))
+	return i
+}
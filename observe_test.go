@@ -0,0 +1,62 @@
+package wltree
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestObserved(t *testing.T) {
+	type query struct {
+		op  Op
+		key int64
+	}
+	var got []query
+	obs := ObserverFunc(func(op Op, key int64, d time.Duration) {
+		if d < 0 {
+			t.Errorf("ObserveQuery(%v, %v, %v) => negative latency", op, key, d)
+		}
+		got = append(got, query{op, key})
+	})
+
+	for _, ws := range weights {
+		s := random(rand.Intn(maxSize)+1, ws)
+		w := NewBytes(s)
+		o := NewObservedBytes(w, obs)
+		seq := NewObserved(NewInt64Keys(byteSlice(s)), obs)
+		got = got[:0]
+		var want []query
+		for trial := 0; trial < 20; trial++ {
+			i := rand.Intn(len(s))
+			c := s[rand.Intn(len(s))]
+			r := rand.Intn(w.Count(c))
+			for _, q := range []struct {
+				got, want int
+				op        Op
+				key       int64
+			}{
+				{int(o.Access(i)), int(s[i]), OpAccess, int64(s[i])},
+				{o.Rank(c, i), w.Rank(c, i), OpRank, int64(c)},
+				{o.Select(c, r), w.Select(c, r), OpSelect, int64(c)},
+				{int(seq.Access(i)), int(s[i]), OpAccess, int64(s[i])},
+				{seq.Rank(int64(c), i), w.Rank(c, i), OpRank, int64(c)},
+				{seq.Select(int64(c), r), w.Select(c, r), OpSelect, int64(c)},
+			} {
+				if q.got != q.want {
+					t.Errorf("%v(%q, %v) => got %v, want %v", q.op, c, i, q.got, q.want)
+				}
+				want = append(want, query{q.op, q.key})
+			}
+		}
+		if o.Len() != len(s) || seq.Len() != len(s) {
+			t.Errorf("Len() => got %v and %v, want %v", o.Len(), seq.Len(), len(s))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("observed %v, want %v", got, want)
+		}
+	}
+	if got := Op(7).String(); got != "Op(7)" {
+		t.Errorf("Op(7).String() => got %v, want Op(7)", got)
+	}
+}
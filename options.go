@@ -0,0 +1,125 @@
+package wltree
+
+import "context"
+
+// ErrorMode selects how queries report that the requested occurrence does not exist.
+type ErrorMode int
+
+const (
+	// PanicOnError makes Select panic for a key that is not in s. This is the default.
+	PanicOnError ErrorMode = iota
+	// ReturnNotFound makes Select return -1 when s has no such occurrence.
+	ReturnNotFound
+)
+
+// Options configure the construction of a Wavelet Tree.
+// The zero value and a nil *Options both select the defaults.
+type Options struct {
+	// ErrorMode selects how queries on the tree report errors.
+	ErrorMode ErrorMode
+	// Shape selects the shape of the tree.
+	Shape Shape
+	// MaxCodeLen, if positive, limits the codes of HuffmanShape, and thus the depth of queries, to
+	// that many bits, at the cost of a slightly larger tree. It is raised to ceil(log2 σ) for σ
+	// keys if smaller.
+	MaxCodeLen int
+	// RunLength stores the nodes run-length encoded, in space proportional to the number of runs
+	// of equal bits instead of to the length of s. It suits highly repetitive s, and costs a
+	// binary search per node visited by a query.
+	RunLength bool
+	// Sparse stores each node whose ones or zeros are rare enough in Elias-Fano encoding, which
+	// shrinks the deep nodes of rare keys in skewed s. The other nodes are stored as usual.
+	Sparse bool
+	// RankOnly builds BitVectors without select directories, which take a large part of the
+	// default ones. Select still works, but binary searches rank samples instead, which is slower.
+	// It is ignored if Backend is set.
+	RankOnly bool
+	// LazySelect is like RankOnly, but builds sampled select directories for each level on its
+	// first Select, so that only the levels that Select visits pay for them. It is safe for
+	// concurrent queries, and ignored if Backend is set.
+	LazySelect bool
+	// RankSample is the number of bits between the rank samples of the BitVectors built for
+	// RankOnly or LazySelect, rounded up to a multiple of 64. Smaller samples make Rank faster and
+	// the tree larger. It is 2048 if zero.
+	RankSample int
+	// SelectSample is the number of ones, or zeros, between the samples of the select directories
+	// built for LazySelect. Smaller samples make Select faster and the tree larger. It is 4096 if
+	// zero.
+	SelectSample int
+	// Arena allocates the bits of all levels from one contiguous slice, and their rank and select
+	// directories from another, so that a tree is made of a few large objects. It builds the
+	// BitVectors like DefaultBackend, and takes precedence over Backend, RankOnly and LazySelect.
+	Arena bool
+	// Backend builds the BitVectors of the tree, one per level. It is DefaultBackend if nil, and
+	// is not used if RunLength is set. With Workers, the BitVectors of different levels are set
+	// concurrently.
+	Backend Backend
+	// Workers, if greater than one, is the number of goroutines that count the keys of s and
+	// index s. Each level of the tree is indexed by one goroutine, so no more goroutines than
+	// levels run at once. It is ignored if RunLength or Sparse is set, except for counting.
+	Workers int
+	// Progress, if not nil, is called during construction with each phase and the fraction of it
+	// done, from 0 to 1, about every 65536 elements and at its end, so that long builds can report
+	// their progress. The build phase reports each level of the tree instead. Calls do not overlap,
+	// but with Workers they may come from other goroutines.
+	Progress func(phase Phase, fraction float64)
+}
+
+// NewInt64KeysWithOptions is like NewInt64Keys, but configured by opts.
+func NewInt64KeysWithOptions(s Interface, opts *Options) *Int64Keys {
+	ctx := context.Background()
+	keyset, counts := freqOf(s, opts.workers(s.Len()), opts.progress(ctx, PhaseCount))
+	w := newShapedInt64Keys(ctx, all(s), keyset, counts, opts, new(scratch))
+	w.configure(opts)
+	return w
+}
+
+// NewBytesWithOptions is like NewBytes, but configured by opts.
+func NewBytesWithOptions(s []byte, opts *Options) *Bytes {
+	return newBytes(context.Background(), s, nil, opts, new(scratch))
+}
+
+// codes returns the codes of the keys with counts, in ascending order of the keys, as selected by
+// o.
+func (o *Options) codes(counts []int) []string {
+	if o == nil {
+		return HuffmanShape.codes(counts, 0)
+	}
+	return o.Shape.codes(counts, o.MaxCodeLen)
+}
+
+// levelBackend returns the Backend that builds the levels of the nodes with sizes, indexed by code
+// prefix, as selected by o.
+func (o *Options) levelBackend(sizes map[string]int) Backend {
+	if o != nil && o.Arena {
+		return newArena(levelSizes(sizes)).backend
+	}
+	return o.backend()
+}
+
+// nodeBuilder returns the builder of the nodes with sizes, indexed by code prefix, for the keys
+// with counts and codes, as selected by o, along with the levelBuilder that reports the progress
+// of the build and the number of goroutines that may set the bits of different levels.
+func (o *Options) nodeBuilder(sizes map[string]int, counts []int, codes []string) (*levelBuilder, nodeBuilder, int) {
+	// Lay out the wavelet tree nodes level by level, unless they are encoded otherwise. The levels
+	// are separate BitVectors, so they can be filled by separate goroutines.
+	levels := newLevelBuilder(sizes, o.levelBackend(sizes))
+	switch {
+	case o != nil && o.RunLength:
+		return levels, newRunBuilder(sizes), 1
+	case o != nil && o.Sparse:
+		return levels, newSparseBuilder(sizes, nodeOnes(counts, codes), o.backend()), 1
+	}
+	return levels, levels, o.workers(len(levels.builders))
+}
+
+// configure applies opts to the query behavior of w, and keeps them for the trees derived from w.
+func (w *Int64Keys) configure(opts *Options) {
+	if opts == nil {
+		return
+	}
+	w.errorMode = opts.ErrorMode
+	kept := *opts
+	kept.Progress = nil
+	w.opts = &kept
+}
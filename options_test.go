@@ -0,0 +1,42 @@
+package wltree
+
+import "testing"
+
+func TestErrorMode(t *testing.T) {
+	s := []byte("abracadabra")
+	opts := &Options{ErrorMode: ReturnNotFound}
+	wt := NewBytesWithOptions(s, opts)
+	wti := NewInt64KeysWithOptions(byteSlice(s), opts)
+	wtr := NewRunesWithOptions([]rune(string(s)), opts)
+	wtq := NewQuadBytesWithOptions(s, opts)
+	for _, tc := range []struct {
+		c    byte
+		r    int
+		want int
+	}{
+		{'a', 4, 10},
+		{'a', 5, -1},
+		{'a', -1, -1},
+		{'z', 0, -1},
+	} {
+		if got := wt.Select(tc.c, tc.r); got != tc.want {
+			t.Errorf("Bytes: Select(%q, %v) => got %v, want %v", tc.c, tc.r, got, tc.want)
+		}
+		if got := wti.Select(int64(tc.c), tc.r); got != tc.want {
+			t.Errorf("IntKeys: Select(%q, %v) => got %v, want %v", tc.c, tc.r, got, tc.want)
+		}
+		if got := wtr.Select(rune(tc.c), tc.r); got != tc.want {
+			t.Errorf("Runes: Select(%q, %v) => got %v, want %v", tc.c, tc.r, got, tc.want)
+		}
+		if got := wtq.Select(tc.c, tc.r); got != tc.want {
+			t.Errorf("QuadBytes: Select(%q, %v) => got %v, want %v", tc.c, tc.r, got, tc.want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Bytes: Select('z', 0) with default options => did not panic")
+		}
+	}()
+	NewBytesWithOptions(s, nil).Select('z', 0)
+}
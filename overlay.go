@@ -0,0 +1,130 @@
+package wltree
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Overlay is a Wavelet Tree on bytestring whose characters can be replaced by Update without
+// rebuilding it. The replacements are kept sorted by position beside the immutable Bytes, and
+// queries correct its answers with them in time linear in their number, so Overlay suits rare
+// corrections. Flatten folds them into a new Bytes. Select returns -1 when s has no such
+// occurrence.
+type Overlay struct {
+	base *Bytes
+	// pos are the positions of the replaced characters in ascending order, and old and new the
+	// characters of base and those replacing them.
+	pos      []int
+	old, new []byte
+	delta    [256]int
+}
+
+// NewOverlay returns an Overlay on the bytestring of base, which it does not modify.
+func NewOverlay(base *Bytes) *Overlay {
+	return &Overlay{base: base}
+}
+
+// Update replaces s[i] by the character c, and returns the replaced character. It panics if i is
+// out of range.
+func (o *Overlay) Update(i int, c byte) byte {
+	prev := o.Access(i)
+	o.delta[prev]--
+	o.delta[c]++
+	k := sort.SearchInts(o.pos, i)
+	switch {
+	case k < len(o.pos) && o.pos[k] == i && o.old[k] == c:
+		// Back to the character of base.
+		o.pos = append(o.pos[:k], o.pos[k+1:]...)
+		o.old = append(o.old[:k], o.old[k+1:]...)
+		o.new = append(o.new[:k], o.new[k+1:]...)
+	case k < len(o.pos) && o.pos[k] == i:
+		o.new[k] = c
+	case prev != c:
+		o.pos = append(o.pos[:k], append([]int{i}, o.pos[k:]...)...)
+		o.old = append(o.old[:k], append([]byte{prev}, o.old[k:]...)...)
+		o.new = append(o.new[:k], append([]byte{c}, o.new[k:]...)...)
+	}
+	return prev
+}
+
+// Flatten returns a Bytes on s, with all the replacements applied.
+func (o *Overlay) Flatten() *Bytes {
+	s := o.base.appendTo(make([]byte, 0, o.base.Len()))
+	for k, i := range o.pos {
+		s[i] = o.new[k]
+	}
+	return NewBytes(s)
+}
+
+// Len returns the length of s.
+func (o *Overlay) Len() int {
+	return o.base.Len()
+}
+
+// Count returns the count of the character c in s.
+func (o *Overlay) Count(c byte) int {
+	return o.base.Count(c) + o.delta[c]
+}
+
+// Rank returns the count of the character c in s[0:i].
+// i is clamped to the range [0, Len()].
+func (o *Overlay) Rank(c byte, i int) int {
+	i = clamp(i, o.base.Len())
+	r := o.base.Rank(c, i)
+	for k := 0; k < len(o.pos) && o.pos[k] < i; k++ {
+		if o.old[k] == c {
+			r--
+		}
+		if o.new[k] == c {
+			r++
+		}
+	}
+	return r
+}
+
+// Select returns the index of the r-th occurrence of the character c, or -1 if there is none.
+func (o *Overlay) Select(c byte, r int) int {
+	if r < 0 || r >= o.Count(c) {
+		return -1
+	}
+	removed, added := 0, 0
+	for k, i := range o.pos {
+		// Between the previous replacement and i, the occurrences are those of base.
+		before := o.base.Rank(c, i) - removed + added
+		if r < before {
+			break
+		}
+		if o.old[k] == c {
+			removed++
+		}
+		if o.new[k] == c {
+			if r == before {
+				return i
+			}
+			added++
+		}
+	}
+	return o.base.Select(c, r-added+removed)
+}
+
+// Access returns the i-th character of s. It panics if i is out of range.
+func (o *Overlay) Access(i int) byte {
+	if i < 0 || i >= o.base.Len() {
+		panic(fmt.Sprintf("wltree: index %v out of range [0, %v)", i, o.base.Len()))
+	}
+	if k := sort.SearchInts(o.pos, i); k < len(o.pos) && o.pos[k] == i {
+		return o.new[k]
+	}
+	return o.base.Access(i)
+}
+
+// clone returns a copy of o that Update may modify without affecting o.
+func (o *Overlay) clone() *Overlay {
+	return &Overlay{
+		base:  o.base,
+		pos:   append([]int(nil), o.pos...),
+		old:   append([]byte(nil), o.old...),
+		new:   append([]byte(nil), o.new...),
+		delta: o.delta,
+	}
+}
@@ -0,0 +1,63 @@
+package wltree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestOverlay(t *testing.T) {
+	for size := 1; size < maxSize; size += 37 {
+		for _, ws := range weights {
+			s := random(size, ws)
+			o := NewOverlay(NewBytes(s))
+			d := NewDynamicBytes(s)
+			for k := rand.Intn(20); k >= 0; k-- {
+				i, c := rand.Intn(size), random(1, ws)[0]
+				if rand.Intn(4) == 0 {
+					c = s[i]
+				}
+				if got := o.Update(i, c); got != s[i] {
+					t.Errorf("Update(%v, %q) => got %q, want %q", i, c, got, s[i])
+				}
+				if got := d.Update(i, c); got != s[i] {
+					t.Errorf("DynamicBytes.Update(%v, %q) => got %q, want %q", i, c, got, s[i])
+				}
+				s[i] = c
+			}
+			checkOverlay(t, s, o)
+			checkDynamic(t, s, d)
+			if got, want := o.Flatten(), NewBytes(s); !got.Equal(want) {
+				t.Errorf("Flatten() => differs from NewBytes(%q)", s)
+			}
+		}
+	}
+}
+
+func checkOverlay(t *testing.T, s []byte, o *Overlay) {
+	t.Helper()
+	for _, c := range []byte("acfz") {
+		count := 0
+		for i := 0; i <= len(s); i++ {
+			if got := o.Rank(c, i); got != count {
+				t.Errorf("%q: Rank(%q, %v) => got %v, want %v", s, c, i, got, count)
+			}
+			if i < len(s) && s[i] == c {
+				if got := o.Select(c, count); got != i {
+					t.Errorf("%q: Select(%q, %v) => got %v, want %v", s, c, count, got, i)
+				}
+				count++
+			}
+		}
+		if got := o.Count(c); got != count {
+			t.Errorf("%q: Count(%q) => got %v, want %v", s, c, got, count)
+		}
+		if got := o.Select(c, count); got != -1 {
+			t.Errorf("%q: Select(%q, %v) => got %v, want -1", s, c, count, got)
+		}
+	}
+	for i := range s {
+		if got := o.Access(i); got != s[i] {
+			t.Errorf("%q: Access(%v) => got %q, want %q", s, i, got, s[i])
+		}
+	}
+}
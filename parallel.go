@@ -0,0 +1,84 @@
+package wltree
+
+import "sync"
+
+// workers returns the number of goroutines selected by o for n independent tasks.
+func (o *Options) workers(n int) int {
+	if o == nil || o.Workers < 1 {
+		return 1
+	}
+	return max(1, min(o.Workers, n))
+}
+
+// parallel calls f(w, n) for each w in [0, n), on n goroutines if n > 1, and waits for them all.
+func parallel(n int, f func(w, n int)) {
+	if n <= 1 {
+		f(0, 1)
+		return
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f(w, n)
+		}()
+	}
+	wg.Wait()
+}
+
+// shard returns the bounds of the w-th of n nearly equal parts of [0, size).
+func shard(size, w, n int) (lo, hi int) {
+	return size/n*w + min(w, size%n), size/n*(w+1) + min(w+1, size%n)
+}
+
+// countBytes returns the number of occurrences of each character in s, counted by workers
+// goroutines, the first of which reports its progress to p.
+func countBytes(s []byte, workers int, p progress) [256]int {
+	parts := make([][256]int, workers)
+	parallel(workers, func(w, n int) {
+		lo, hi := shard(len(s), w, n)
+		p.steps(lo, hi, w == 0, func(lo, hi int) {
+			for _, c := range s[lo:hi] {
+				parts[w][c]++
+			}
+		})
+	})
+	var freqs [256]int
+	for _, part := range parts {
+		for c, count := range part {
+			freqs[c] += count
+		}
+	}
+	return freqs
+}
+
+// freqOf is like freq over the keys of s, counted by workers goroutines, the first of which
+// reports its progress to p.
+func freqOf(s Interface, workers int, p progress) (keyset []int64, counts []int) {
+	size := s.Len()
+	if workers <= 1 {
+		return freq(p.seq(all(s), size, true))
+	}
+	parts := make([]map[int64]int, workers)
+	parallel(workers, func(w, n int) {
+		lo, hi := shard(size, w, n)
+		parts[w] = make(map[int64]int)
+		p.steps(lo, hi, w == 0, func(lo, hi int) {
+			for i := lo; i < hi; i++ {
+				parts[w][s.Key(i)]++
+			}
+		})
+	})
+	freqs := parts[0]
+	for _, part := range parts[1:] {
+		for k, count := range part {
+			freqs[k] += count
+		}
+	}
+	for k, count := range freqs {
+		keyset = append(keyset, k)
+		counts = append(counts, count)
+	}
+	return keyset, counts
+}
@@ -0,0 +1,45 @@
+package wltree
+
+import "testing"
+
+func TestWorkers(t *testing.T) {
+	for _, opts := range []*Options{{}, {Arena: true}, {RankOnly: true}, {Sparse: true}, {RunLength: true}} {
+		for size := 0; size < maxSize; size += 13 {
+			for _, ws := range weights {
+				bs := random(size, ws)
+				serial := *opts
+				concurrent := *opts
+				concurrent.Workers = 3
+				if got, want := NewBytesWithOptions(bs, &concurrent), NewBytesWithOptions(bs, &serial); !got.Equal(want) {
+					t.Errorf("%+v: NewBytesWithOptions(%q) => differs from serial construction", concurrent, bs)
+				}
+				got := NewInt64KeysWithOptions(byteSlice(bs), &concurrent)
+				want := NewInt64KeysWithOptions(byteSlice(bs), &serial)
+				if !got.Equal(want) {
+					t.Errorf("%+v: NewInt64KeysWithOptions(%q) => differs from serial construction", concurrent, bs)
+				}
+				if err := got.Verify(); err != nil {
+					t.Errorf("%+v: NewInt64KeysWithOptions(%q).Verify() => %v", concurrent, bs, err)
+				}
+			}
+		}
+	}
+}
+
+func TestShard(t *testing.T) {
+	for size := 0; size < 50; size++ {
+		for n := 1; n < 10; n++ {
+			next := 0
+			for w := 0; w < n; w++ {
+				lo, hi := shard(size, w, n)
+				if lo != next || hi < lo || hi-lo > size/n+1 {
+					t.Errorf("shard(%v, %v, %v) => got [%v, %v), want from %v", size, w, n, lo, hi, next)
+				}
+				next = hi
+			}
+			if next != size {
+				t.Errorf("shard(%v, _, %v) => ends at %v, want %v", size, n, next, size)
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package wltree
+
+// PersistentBytes is a version of a Wavelet Tree on bytestring that is never modified: Insert,
+// Delete and Update return a new version, which shares with the old one all the nodes and bit
+// chunks the edit does not touch. Every version therefore stays queryable for as long as it is
+// referenced, and an edit costs O(log n) time and space per level like on DynamicBytes. Select
+// returns -1 when s has no such occurrence.
+type PersistentBytes struct {
+	t *dynTree
+}
+
+// NewPersistentBytes makes the first version of a PersistentBytes from bytestring.
+func NewPersistentBytes(s []byte) *PersistentBytes {
+	t := newDynTree(256)
+	for i, c := range s {
+		t.insert(i, int(c), false)
+	}
+	return &PersistentBytes{t}
+}
+
+// Len returns the length of s.
+func (w *PersistentBytes) Len() int {
+	return w.t.n
+}
+
+// Count returns the count of the character c in s.
+func (w *PersistentBytes) Count(c byte) int {
+	return w.t.count(int(c))
+}
+
+// Rank returns the count of the character c in s[0:i].
+// i is clamped to the range [0, Len()].
+func (w *PersistentBytes) Rank(c byte, i int) int {
+	return w.t.rank(int(c), i)
+}
+
+// Select returns the index of the r-th occurrence of the character c, or -1 if there is none.
+func (w *PersistentBytes) Select(c byte, r int) int {
+	return w.t.selectRank(int(c), r)
+}
+
+// Access returns the i-th character of s. It panics if i is out of range.
+func (w *PersistentBytes) Access(i int) byte {
+	w.t.check(i, w.t.n)
+	return byte(w.t.access(i))
+}
+
+// Insert returns the version of w with the character c inserted before s[i], or at the end of s if
+// i is Len(). It panics if i is out of range.
+func (w *PersistentBytes) Insert(i int, c byte) *PersistentBytes {
+	w.t.check(i, w.t.n+1)
+	return &PersistentBytes{w.t.insert(i, int(c), true)}
+}
+
+// Delete returns the version of w with s[i] deleted, and the deleted character. It panics if i is
+// out of range.
+func (w *PersistentBytes) Delete(i int) (*PersistentBytes, byte) {
+	w.t.check(i, w.t.n)
+	id, t := w.t.delete(i, true)
+	return &PersistentBytes{t}, byte(id)
+}
+
+// Update returns the version of w with s[i] replaced by the character c, and the replaced
+// character. It panics if i is out of range.
+func (w *PersistentBytes) Update(i int, c byte) (*PersistentBytes, byte) {
+	w.t.check(i, w.t.n)
+	id, t := w.t.delete(i, true)
+	return &PersistentBytes{t.insert(i, int(c), true)}, byte(id)
+}
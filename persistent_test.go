@@ -0,0 +1,44 @@
+package wltree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPersistentBytes(t *testing.T) {
+	for _, ws := range weights {
+		s := random(300, ws)
+		versions := []*PersistentBytes{NewPersistentBytes(s)}
+		strs := [][]byte{s}
+		for op := 0; op < 300; op++ {
+			v := rand.Intn(len(versions))
+			wt, s := versions[v], append([]byte(nil), strs[v]...)
+			switch {
+			case len(s) > 0 && rand.Intn(3) == 0:
+				i := rand.Intn(len(s))
+				next, got := wt.Delete(i)
+				if got != s[i] {
+					t.Errorf("Delete(%v) => got %q, want %q", i, got, s[i])
+				}
+				wt, s = next, append(s[:i], s[i+1:]...)
+			case len(s) > 0 && rand.Intn(2) == 0:
+				i, c := rand.Intn(len(s)), random(1, ws)[0]
+				next, got := wt.Update(i, c)
+				if got != s[i] {
+					t.Errorf("Update(%v, %q) => got %q, want %q", i, c, got, s[i])
+				}
+				wt = next
+				s[i] = c
+			default:
+				i, c := rand.Intn(len(s)+1), random(1, ws)[0]
+				wt = wt.Insert(i, c)
+				s = append(s[:i], append([]byte{c}, s[i:]...)...)
+			}
+			versions, strs = append(versions, wt), append(strs, s)
+		}
+		// Every version answers as of its own edits.
+		for v := 0; v < len(versions); v += 17 {
+			checkDynamic(t, strs[v], versions[v])
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package wltree
+
+import (
+	"context"
+	"iter"
+	"strconv"
+)
+
+// Phase is a phase of the construction of a tree, reported to Options.Progress.
+type Phase int
+
+const (
+	// PhaseCount counts the occurrences of the keys of s.
+	PhaseCount Phase = iota
+	// PhaseSet sets the bits of the nodes from the keys of s.
+	PhaseSet
+	// PhaseBuild builds the BitVectors of the nodes from their bits.
+	PhaseBuild
+)
+
+// String returns the name of the phase, such as "count".
+func (p Phase) String() string {
+	switch p {
+	case PhaseCount:
+		return "count"
+	case PhaseSet:
+		return "set"
+	case PhaseBuild:
+		return "build"
+	}
+	return "Phase(" + strconv.Itoa(int(p)) + ")"
+}
+
+// progressStep is the number of elements between reports of progress.
+const progressStep = 1 << 16
+
+// progress reports the progress of a phase of construction to f, unless f is nil, and stops it
+// early once ctx, if not nil, is done. ctx is nil unless it can be done.
+type progress struct {
+	f     func(phase Phase, fraction float64)
+	phase Phase
+	ctx   context.Context
+}
+
+// progress returns the progress of the phase of construction configured by o, which stops early
+// once ctx is done.
+func (o *Options) progress(ctx context.Context, phase Phase) progress {
+	p := progress{phase: phase}
+	if o != nil {
+		p.f = o.Progress
+	}
+	if ctx.Done() != nil {
+		p.ctx = ctx
+	}
+	return p
+}
+
+// canceled reports whether the phase is to stop early.
+func (p progress) canceled() bool {
+	return p.ctx != nil && p.ctx.Err() != nil
+}
+
+// at reports that done of total elements are done.
+func (p progress) at(done, total int) {
+	if p.f == nil {
+		return
+	}
+	fraction := 1.0
+	if total > 0 {
+		fraction = float64(done) / float64(total)
+	}
+	p.f(p.phase, fraction)
+}
+
+// steps calls f on consecutive pieces of [lo, hi) until the phase is canceled, reporting the
+// progress before each piece and at the end if report is set. It calls f(lo, hi) at once if there
+// is neither progress to report nor a context to check.
+func (p progress) steps(lo, hi int, report bool, f func(lo, hi int)) {
+	report = report && p.f != nil
+	if !report && p.ctx == nil {
+		f(lo, hi)
+		return
+	}
+	for i := lo; i < hi; i += progressStep {
+		if p.canceled() {
+			return
+		}
+		if report {
+			p.at(i-lo, hi-lo)
+		}
+		f(i, min(i+progressStep, hi))
+	}
+	if report {
+		p.at(hi-lo, hi-lo)
+	}
+}
+
+// seq returns seq of total keys, which stops early once the phase is canceled, and reports the
+// progress every progressStep keys and at the end if report is set.
+func (p progress) seq(seq iter.Seq[int64], total int, report bool) iter.Seq[int64] {
+	report = report && p.f != nil
+	if !report && p.ctx == nil {
+		return seq
+	}
+	return func(yield func(int64) bool) {
+		i := 0
+		for k := range seq {
+			if i%progressStep == 0 {
+				if p.canceled() {
+					return
+				}
+				if report {
+					p.at(i, total)
+				}
+			}
+			if !yield(k) {
+				return
+			}
+			i++
+		}
+		if report {
+			p.at(total, total)
+		}
+	}
+}
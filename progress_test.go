@@ -0,0 +1,41 @@
+package wltree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	s := random(3*progressStep+rand.Intn(progressStep), weights[rand.Intn(len(weights))])
+	for _, opts := range []Options{
+		{},
+		{Workers: 4},
+		{RunLength: true},
+		{Sparse: true},
+	} {
+		for name, build := range map[string]func(opts *Options){
+			"Bytes":     func(opts *Options) { NewBytesWithOptions(s, opts) },
+			"Int64Keys": func(opts *Options) { NewInt64KeysWithOptions(byteSlice(s), opts) },
+			"Builder":   func(opts *Options) { NewBuilder(opts).Int64Keys(byteSlice(s)) },
+		} {
+			var phases []Phase
+			var last float64
+			opts.Progress = func(phase Phase, fraction float64) {
+				if len(phases) == 0 || phases[len(phases)-1] != phase {
+					if len(phases) > 0 && last != 1 {
+						t.Errorf("%v %+v: %v ended at %v, want 1", name, opts, phases[len(phases)-1], last)
+					}
+					phases, last = append(phases, phase), 0
+				}
+				if fraction < last || fraction > 1 {
+					t.Errorf("%v %+v: %v at %v after %v", name, opts, phase, fraction, last)
+				}
+				last = fraction
+			}
+			build(&opts)
+			if len(phases) != 3 || phases[0] != PhaseCount || phases[1] != PhaseSet || phases[2] != PhaseBuild || last != 1 {
+				t.Errorf("%v %+v: got phases %v ending at %v, want [count set build] ending at 1", name, opts, phases, last)
+			}
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package wltree
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+// QuadBytes represents a 4-ary Wavelet Tree on bytestring. Each node stores a sequence of 2-bit
+// digits instead of bits, so a query visits ceil(log4 σ) nodes for σ distinct bytes, half as many
+// as a balanced binary tree, at the cost of rank directories for four digits per node.
+type QuadBytes struct {
+	// levels[l][p] is the node at depth l for the keys whose first l digits are p.
+	levels [][]*quadVector
+	depth  int
+
+	// keyset and counts are the distinct bytes in ascending order and their occurrences, and id
+	// maps each byte to its index in keyset.
+	keyset []byte
+	counts []int
+	id     [256]int
+	n      int
+
+	errorMode ErrorMode
+}
+
+// NewQuadBytes makes a 4-ary Wavelet Tree from s.
+func NewQuadBytes(s []byte) *QuadBytes {
+	w := &QuadBytes{n: len(s)}
+	var counts [256]int
+	for _, c := range s {
+		counts[c]++
+	}
+	for c, count := range counts {
+		w.id[c] = -1
+		if count > 0 {
+			w.id[c] = len(w.keyset)
+			w.keyset = append(w.keyset, byte(c))
+			w.counts = append(w.counts, count)
+		}
+	}
+	if len(w.keyset) > 1 {
+		w.depth = (bits.Len(uint(len(w.keyset)-1)) + 1) / 2
+	}
+
+	// Size the node of each prefix of the digits of the keys.
+	index := make([][]int, w.depth)
+	w.levels = make([][]*quadVector, w.depth)
+	for l := 0; l < w.depth; l++ {
+		shift := 2 * uint(w.depth-l)
+		sizes := make([]int, (len(w.keyset)-1)>>shift+1)
+		for id, count := range w.counts {
+			sizes[id>>shift] += count
+		}
+		w.levels[l] = make([]*quadVector, len(sizes))
+		for p, size := range sizes {
+			w.levels[l][p] = newQuadVector(size)
+		}
+		index[l] = make([]int, len(sizes))
+	}
+
+	for _, c := range s {
+		id := w.id[c]
+		for l := 0; l < w.depth; l++ {
+			p := id >> (2 * uint(w.depth-l))
+			w.levels[l][p].set(index[l][p], w.digit(id, l))
+			index[l][p]++
+		}
+	}
+	for _, level := range w.levels {
+		for _, v := range level {
+			v.build()
+		}
+	}
+	return w
+}
+
+// NewQuadBytesWithOptions is like NewQuadBytes, but configured by opts. Only ErrorMode applies.
+func NewQuadBytesWithOptions(s []byte, opts *Options) *QuadBytes {
+	w := NewQuadBytes(s)
+	if opts != nil {
+		w.errorMode = opts.ErrorMode
+	}
+	return w
+}
+
+// digit returns the digit of the key id at depth l.
+func (w *QuadBytes) digit(id, l int) int {
+	return id >> (2 * uint(w.depth-1-l)) & 3
+}
+
+// Contains reports whether the character c occurs in s.
+func (w *QuadBytes) Contains(c byte) bool {
+	return w.id[c] >= 0
+}
+
+// Len returns the length of s.
+func (w *QuadBytes) Len() int {
+	return w.n
+}
+
+// Count returns the count of the character c in s.
+func (w *QuadBytes) Count(c byte) int {
+	if id := w.id[c]; id >= 0 {
+		return w.counts[id]
+	}
+	return 0
+}
+
+// Rank returns the count of the character c in s[0:i].
+// i is clamped to the range [0, Len()].
+func (w *QuadBytes) Rank(c byte, i int) int {
+	i = clamp(i, w.n)
+	id := w.id[c]
+	if id < 0 {
+		return 0
+	}
+	for l := 0; l < w.depth; l++ {
+		i = w.levels[l][id>>(2*uint(w.depth-l))].rank(w.digit(id, l), i)
+	}
+	return i
+}
+
+// Select returns i such that Rank(c, i) = r.
+// i.e. it returns the index of r-th occurrence of the character c.
+// Errors are reported as selected by Options.ErrorMode, except that Select on an empty tree
+// always returns -1.
+func (w *QuadBytes) Select(c byte, r int) int {
+	if w.n == 0 || w.errorMode == ReturnNotFound && (r < 0 || r >= w.Count(c)) {
+		return -1
+	}
+	id := w.id[c]
+	if id < 0 {
+		panic(fmt.Sprintf("wltree: no such character %q in s.", c))
+	}
+	for l := w.depth - 1; l >= 0; l-- {
+		r = w.levels[l][id>>(2*uint(w.depth-l))].selectDigit(w.digit(id, l), r)
+	}
+	return r
+}
+
+// SelectChecked is like Select, but reports false instead of panicking or returning garbage when
+// s has no r-th occurrence of the character c.
+func (w *QuadBytes) SelectChecked(c byte, r int) (int, bool) {
+	if r < 0 || r >= w.Count(c) {
+		return 0, false
+	}
+	return w.Select(c, r), true
+}
+
+// Access returns s[i]. It panics if i is out of range.
+func (w *QuadBytes) Access(i int) byte {
+	if i < 0 || i >= w.n {
+		panic(fmt.Sprintf("wltree: index %v out of range [0, %v)", i, w.n))
+	}
+	id := 0
+	for l := 0; l < w.depth; l++ {
+		v := w.levels[l][id]
+		d := v.digit(i)
+		i = v.rank(d, i)
+		id = id<<2 | d
+	}
+	return w.keyset[id]
+}
+
+// quadBlock is the number of digits between the samples of the rank directory of quadVector.
+const quadBlock = 256
+
+// quadVector is a sequence of 2-bit digits packed 32 per word, with the ranks of each digit
+// sampled every quadBlock digits.
+type quadVector struct {
+	words []uint64
+	size  int
+	ranks [][4]int
+}
+
+func newQuadVector(size int) *quadVector {
+	return &quadVector{words: make([]uint64, (size+31)/32), size: size}
+}
+
+// set sets the i-th digit, which must still be zero, to d.
+func (v *quadVector) set(i, d int) {
+	v.words[i/32] |= uint64(d) << (2 * uint(i%32))
+}
+
+// build builds the rank directory after all digits are set.
+func (v *quadVector) build() {
+	v.ranks = make([][4]int, v.size/quadBlock+1)
+	for b := 1; b < len(v.ranks); b++ {
+		v.ranks[b] = v.ranks[b-1]
+		for _, x := range v.words[(b-1)*quadBlock/32 : b*quadBlock/32] {
+			for d := 0; d < 4; d++ {
+				v.ranks[b][d] += matches(x, d, 32)
+			}
+		}
+	}
+}
+
+// matches returns the number of digits d among the first k digits of the word x.
+func matches(x uint64, d, k int) int {
+	const lo = 0x5555555555555555
+	y := x ^ uint64(d)*lo
+	eq := ^(y | y>>1) & lo
+	if k < 32 {
+		eq &= 1<<(2*uint(k)) - 1
+	}
+	return bits.OnesCount64(eq)
+}
+
+// digit returns the i-th digit.
+func (v *quadVector) digit(i int) int {
+	return int(v.words[i/32] >> (2 * uint(i%32)) & 3)
+}
+
+// rank returns the number of digits d among the first i digits.
+func (v *quadVector) rank(d, i int) int {
+	b := i / quadBlock
+	r := v.ranks[b][d]
+	for j := b * quadBlock / 32; j < i/32; j++ {
+		r += matches(v.words[j], d, 32)
+	}
+	if i%32 != 0 {
+		r += matches(v.words[i/32], d, i%32)
+	}
+	return r
+}
+
+// selectDigit returns the position of the r-th digit d.
+func (v *quadVector) selectDigit(d, r int) int {
+	if r < 0 {
+		panic("wltree: select with negative rank")
+	}
+	b := sort.Search(len(v.ranks), func(b int) bool { return v.ranks[b][d] > r }) - 1
+	r -= v.ranks[b][d]
+	for j := b * quadBlock / 32; j < len(v.words); j++ {
+		if n := matches(v.words[j], d, 32); r >= n {
+			r -= n
+			continue
+		}
+		for k := 0; k < 32; k++ {
+			if int(v.words[j]>>(2*uint(k))&3) == d {
+				if r == 0 {
+					if pos := 32*j + k; pos < v.size {
+						return pos
+					}
+					break
+				}
+				r--
+			}
+		}
+		break
+	}
+	panic("wltree: select beyond the last digit")
+}
@@ -0,0 +1,40 @@
+package wltree
+
+import "testing"
+
+func TestQuadBytes(t *testing.T) {
+	fails := 0
+	alphabets := append(weights, map[byte]int{'x': 1}, map[byte]int{})
+	for c := 0; c < 256; c += 3 {
+		alphabets[len(alphabets)-1][byte(c)] = 1 + c%7
+	}
+	for size := 0; size < 4*maxSize && fails < 30; size += 211 {
+		for _, ws := range alphabets {
+			bs := random(size, ws)
+			wt := NewQuadBytes(bs)
+
+			var counts [256]int
+			for i := 0; i <= len(bs) && fails < 30; i++ {
+				for c := 0; c < 256; c++ {
+					c := byte(c)
+					if got, want := wt.Rank(c, i), counts[c]; got != want {
+						t.Errorf("%q.Rank(%v, %v) => got %v, want %v", bs, string(c), i, got, want)
+						fails++
+					}
+				}
+				if i != len(bs) {
+					c := bs[i]
+					if got, want := wt.Select(c, counts[c]), i; got != want {
+						t.Errorf("%q.Select(%v, %v) => got %v, want %v", bs, string(c), counts[c], got, want)
+						fails++
+					}
+					if got := wt.Access(i); got != c {
+						t.Errorf("%q.Access(%v) => got %v, want %v", bs, i, string(got), string(c))
+						fails++
+					}
+					counts[c]++
+				}
+			}
+		}
+	}
+}
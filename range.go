@@ -0,0 +1,444 @@
+package wltree
+
+import (
+	"iter"
+	"slices"
+)
+
+// Mode returns the most frequent key in s[l:r] and the number of its occurrences.
+// If several keys are equally frequent, any one of them is returned. It returns count 0 for an
+// empty range.
+func (w *Int64Keys) Mode(l, r int) (key int64, count int) {
+	l, r = clampRange(l, r, w.n)
+	if w.root == nil {
+		return 0, 0
+	}
+	w.root.mode(l, r, &key, &count)
+	return key, count
+}
+
+// Mode returns the most frequent character in s[l:r] and the number of its occurrences.
+// If several characters are equally frequent, any one of them is returned. It returns count 0 for
+// an empty range.
+func (w *Bytes) Mode(l, r int) (c byte, count int) {
+	l, r = clampRange(l, r, w.n)
+	if w.root == nil {
+		return 0, 0
+	}
+	var key int64
+	w.root.mode(l, r, &key, &count)
+	return byte(key), count
+}
+
+// children maps the interval [l, r) of n to the corresponding intervals of its children.
+func (n *node) children(l, r int) (l0, r0, l1, r1 int) {
+	l1, r1 = n.bv.Rank1(l), n.bv.Rank1(r)
+	return l - l1, r - r1, l1, r1
+}
+
+// mode updates best and count with the most frequent key in the interval [l, r) of n if it occurs
+// more than count times. Subtrees whose interval is not wider than count are pruned, and the wider
+// child is visited first so that the bound tightens quickly.
+func (n *node) mode(l, r int, best *int64, count *int) {
+	if r-l <= *count {
+		return
+	}
+	if n.leaf() {
+		*best, *count = n.key, r-l
+		return
+	}
+	l0, r0, l1, r1 := n.children(l, r)
+	if r0-l0 >= r1-l1 {
+		n.child[0].mode(l0, r0, best, count)
+		n.child[1].mode(l1, r1, best, count)
+	} else {
+		n.child[1].mode(l1, r1, best, count)
+		n.child[0].mode(l0, r0, best, count)
+	}
+}
+
+// DistinctCount returns the number of distinct keys in s[l:r].
+func (w *Int64Keys) DistinctCount(l, r int) int {
+	l, r = clampRange(l, r, w.n)
+	if w.root == nil {
+		return 0
+	}
+	return w.root.distinct(l, r)
+}
+
+// DistinctCount returns the number of distinct characters in s[l:r].
+func (w *Bytes) DistinctCount(l, r int) int {
+	l, r = clampRange(l, r, w.n)
+	if w.root == nil {
+		return 0
+	}
+	return w.root.distinct(l, r)
+}
+
+// distinct returns the number of leaves reachable from n with the interval [l, r). Only the
+// non-empty branches are traversed.
+func (n *node) distinct(l, r int) int {
+	if l >= r {
+		return 0
+	}
+	if n.leaf() {
+		return 1
+	}
+	l0, r0, l1, r1 := n.children(l, r)
+	return n.child[0].distinct(l0, r0) + n.child[1].distinct(l1, r1)
+}
+
+// Frequent returns the keys occurring at least min times in s[l:r] and their counts, in no
+// particular order.
+func (w *Int64Keys) Frequent(l, r, min int) (keys []int64, counts []int) {
+	l, r = clampRange(l, r, w.n)
+	if w.root == nil {
+		return nil, nil
+	}
+	w.root.frequent(l, r, min, func(key int64, count int) {
+		keys = append(keys, key)
+		counts = append(counts, count)
+	})
+	return keys, counts
+}
+
+// Frequent returns the characters occurring at least min times in s[l:r] and their counts, in no
+// particular order.
+func (w *Bytes) Frequent(l, r, min int) (cs []byte, counts []int) {
+	l, r = clampRange(l, r, w.n)
+	if w.root == nil {
+		return nil, nil
+	}
+	w.root.frequent(l, r, min, func(key int64, count int) {
+		cs = append(cs, byte(key))
+		counts = append(counts, count)
+	})
+	return cs, counts
+}
+
+// frequent calls f for each leaf reachable from n whose interval is at least min wide. Subtrees
+// whose interval is already narrower than min are pruned.
+func (n *node) frequent(l, r, min int, f func(key int64, count int)) {
+	if r-l < min || l >= r {
+		return
+	}
+	if n.leaf() {
+		f(n.key, r-l)
+		return
+	}
+	l0, r0, l1, r1 := n.children(l, r)
+	n.child[0].frequent(l0, r0, min, f)
+	n.child[1].frequent(l1, r1, min, f)
+}
+
+// AnagramEqual reports whether s[l1:r1] and s[l2:r2] contain exactly the same multiset of keys.
+func (w *Int64Keys) AnagramEqual(l1, r1, l2, r2 int) bool {
+	l1, r1 = clampRange(l1, r1, w.n)
+	l2, r2 = clampRange(l2, r2, w.n)
+	if w.root == nil {
+		return true
+	}
+	return w.root.equal(l1, r1, l2, r2)
+}
+
+// AnagramEqual reports whether s[l1:r1] and s[l2:r2] contain exactly the same multiset of
+// characters.
+func (w *Bytes) AnagramEqual(l1, r1, l2, r2 int) bool {
+	l1, r1 = clampRange(l1, r1, w.n)
+	l2, r2 = clampRange(l2, r2, w.n)
+	if w.root == nil {
+		return true
+	}
+	return w.root.equal(l1, r1, l2, r2)
+}
+
+// AnagramDiff returns the keys whose counts in s[l1:r1] and s[l2:r2] differ, and for each of
+// them the count in s[l1:r1] minus the count in s[l2:r2].
+func (w *Int64Keys) AnagramDiff(l1, r1, l2, r2 int) (keys []int64, diffs []int) {
+	l1, r1 = clampRange(l1, r1, w.n)
+	l2, r2 = clampRange(l2, r2, w.n)
+	if w.root == nil {
+		return nil, nil
+	}
+	w.root.diff(l1, r1, l2, r2, func(key int64, diff int) {
+		keys = append(keys, key)
+		diffs = append(diffs, diff)
+	})
+	return keys, diffs
+}
+
+// AnagramDiff returns the characters whose counts in s[l1:r1] and s[l2:r2] differ, and for each
+// of them the count in s[l1:r1] minus the count in s[l2:r2].
+func (w *Bytes) AnagramDiff(l1, r1, l2, r2 int) (cs []byte, diffs []int) {
+	l1, r1 = clampRange(l1, r1, w.n)
+	l2, r2 = clampRange(l2, r2, w.n)
+	if w.root == nil {
+		return nil, nil
+	}
+	w.root.diff(l1, r1, l2, r2, func(key int64, diff int) {
+		cs = append(cs, byte(key))
+		diffs = append(diffs, diff)
+	})
+	return cs, diffs
+}
+
+// equal reports whether the intervals [l1, r1) and [l2, r2) of n reach every leaf with the same
+// width. Subtrees reached with the same interval, or with two empty ones, are pruned.
+func (n *node) equal(l1, r1, l2, r2 int) bool {
+	if r1-l1 != r2-l2 {
+		return false
+	}
+	if n.leaf() || l1 == l2 || l1 >= r1 {
+		return true
+	}
+	a0, b0, a1, b1 := n.children(l1, r1)
+	c0, d0, c1, d1 := n.children(l2, r2)
+	return n.child[0].equal(a0, b0, c0, d0) && n.child[1].equal(a1, b1, c1, d1)
+}
+
+// diff calls f for each leaf reachable from n whose widths in the intervals [l1, r1) and [l2, r2)
+// differ, with the difference of the widths. Subtrees pruned by equal are pruned here as well.
+func (n *node) diff(l1, r1, l2, r2 int, f func(key int64, diff int)) {
+	if (l1 == l2 && r1 == r2) || (l1 >= r1 && l2 >= r2) {
+		return
+	}
+	if n.leaf() {
+		if r1-l1 != r2-l2 {
+			f(n.key, (r1-l1)-(r2-l2))
+		}
+		return
+	}
+	a0, b0, a1, b1 := n.children(l1, r1)
+	c0, d0, c1, d1 := n.children(l2, r2)
+	n.child[0].diff(a0, b0, c0, d0, f)
+	n.child[1].diff(a1, b1, c1, d1, f)
+}
+
+// Inversions returns the number of pairs i < j in s[l:r] such that Key(i) > Key(j). On a tree
+// whose subtrees hold contiguous ranges of keys, as with BalancedShape and AlphabeticShape, it
+// takes O((r-l) log σ) time and no extra space. On Huffman-shaped trees, the elements under a node
+// whose children hold overlapping keys are counted with a Fenwick tree over the keys of the node,
+// which takes O((r-l) (depth + log σ)) time and O(σ) space.
+func (w *Int64Keys) Inversions(l, r int) int {
+	l, r = clampRange(l, r, w.n)
+	if w.root == nil {
+		return 0
+	}
+	return w.root.inversions(l, r)
+}
+
+// Inversions returns the number of pairs i < j in s[l:r] such that s[i] > s[j], with the costs
+// of Int64Keys.Inversions.
+func (w *Bytes) Inversions(l, r int) int {
+	l, r = clampRange(l, r, w.n)
+	if w.root == nil {
+		return 0
+	}
+	return w.root.inversions(l, r)
+}
+
+// inversions returns the number of inversions in the interval [l, r) of n.
+// Where the keys of the children of a node do not overlap, the pairs split between them are
+// counted by scanning the node's bits, as in the classic wavelet tree traversal. Huffman codes do
+// not keep keys in order, so under a node whose children overlap, the elements are counted by
+// overlapInversions instead.
+func (n *node) inversions(l, r int) int {
+	if n.leaf() || r-l < 2 {
+		return 0
+	}
+	var inv int
+	switch {
+	case n.child[0].hi < n.child[1].lo:
+		// A 1 followed by a 0 is an inversion.
+		ones, prev := 0, n.bv.Rank1(l)
+		for i := l; i < r; i++ {
+			next := n.bv.Rank1(i + 1)
+			if next > prev {
+				ones++
+			} else {
+				inv += ones
+			}
+			prev = next
+		}
+	case n.child[1].hi < n.child[0].lo:
+		// A 0 followed by a 1 is an inversion.
+		zeros, prev := 0, n.bv.Rank1(l)
+		for i := l; i < r; i++ {
+			next := n.bv.Rank1(i + 1)
+			if next > prev {
+				inv += zeros
+			} else {
+				zeros++
+			}
+			prev = next
+		}
+	default:
+		return n.overlapInversions(l, r)
+	}
+	l0, r0, l1, r1 := n.children(l, r)
+	return inv + n.child[0].inversions(l0, r0) + n.child[1].inversions(l1, r1)
+}
+
+// overlapInversions returns the number of inversions in the interval [l, r) of n by decoding its
+// elements from the right and counting, in a Fenwick tree over the ranks of the keys of n, those
+// seen with smaller keys.
+func (n *node) overlapInversions(l, r int) int {
+	keys := n.leafKeys(nil)
+	slices.Sort(keys)
+	fenwick := make([]int, len(keys)+1)
+	inv := 0
+	for i := r - 1; i >= l; i-- {
+		k, _ := slices.BinarySearch(keys, n.access(i))
+		for j := k; j > 0; j &= j - 1 {
+			inv += fenwick[j]
+		}
+		for j := k + 1; j < len(fenwick); j += j & -j {
+			fenwick[j]++
+		}
+	}
+	return inv
+}
+
+// leafKeys appends the keys of the leaves of n to keys.
+func (n *node) leafKeys(keys []int64) []int64 {
+	if n.leaf() {
+		return append(keys, n.key)
+	}
+	return n.child[1].leafKeys(n.child[0].leafKeys(keys))
+}
+
+// access returns the key of the i-th element of n.
+func (n *node) access(i int) int64 {
+	for !n.leaf() {
+		if r := n.bv.Rank1(i); n.bv.Rank1(i+1) > r {
+			i, n = r, n.child[1]
+		} else {
+			i, n = i-r, n.child[0]
+		}
+	}
+	return n.key
+}
+
+// RankLessThan returns the count of elements with keys smaller than key in s[0:i].
+func (w *Int64Keys) RankLessThan(key int64, i int) int {
+	i = clamp(i, w.n)
+	if w.root == nil {
+		return 0
+	}
+	return w.root.lessThan(key, i)
+}
+
+// RankLessThan returns the count of characters smaller than c in s[0:i].
+func (w *Bytes) RankLessThan(c byte, i int) int {
+	i = clamp(i, w.n)
+	if w.root == nil {
+		return 0
+	}
+	return w.root.lessThan(int64(c), i)
+}
+
+// lessThan returns the number of keys smaller than key in the first i elements of n. Subtrees
+// whose keys are all smaller, or none smaller, than key are resolved without descending.
+func (n *node) lessThan(key int64, i int) int {
+	switch {
+	case i == 0 || n.lo >= key:
+		return 0
+	case n.hi < key:
+		return i
+	}
+	return n.child[0].lessThan(key, n.bv.Rank0(i)) + n.child[1].lessThan(key, n.bv.Rank1(i))
+}
+
+// RangeCount returns the count of elements of s[l:r] with keys in [lo, hi). It takes O(log σ) time
+// on a tree whose subtrees hold contiguous ranges of keys, as with BalancedShape and
+// AlphabeticShape, and may visit more nodes on Huffman-shaped trees.
+func (w *Int64Keys) RangeCount(l, r int, lo, hi int64) int {
+	l, r = clampRange(l, r, w.n)
+	if w.root == nil {
+		return 0
+	}
+	return w.root.rangeCount(l, r, lo, hi)
+}
+
+// rangeCount returns the number of keys in [lo, hi) in the interval [l, r) of n. Subtrees whose
+// keys are all in, or all out of, the range are resolved without descending.
+func (n *node) rangeCount(l, r int, lo, hi int64) int {
+	switch {
+	case l >= r || n.hi < lo || n.lo >= hi:
+		return 0
+	case lo <= n.lo && n.hi < hi:
+		return r - l
+	}
+	l0, r0, l1, r1 := n.children(l, r)
+	return n.child[0].rangeCount(l0, r0, lo, hi) + n.child[1].rangeCount(l1, r1, lo, hi)
+}
+
+// RangeKeys returns the distinct keys in [lo, hi) that occur in s[l:r], with their counts there.
+// The keys come in ascending order on trees whose subtrees hold contiguous ranges of keys, and in
+// no particular order otherwise.
+func (w *Int64Keys) RangeKeys(l, r int, lo, hi int64) iter.Seq2[int64, int] {
+	return func(yield func(int64, int) bool) {
+		l, r := clampRange(l, r, w.n)
+		if w.root != nil {
+			w.root.rangeKeys(l, r, lo, hi, yield)
+		}
+	}
+}
+
+// rangeKeys yields the leaves with keys in [lo, hi) reachable from n with the interval [l, r),
+// and reports whether to go on.
+func (n *node) rangeKeys(l, r int, lo, hi int64, yield func(int64, int) bool) bool {
+	switch {
+	case l >= r || n.hi < lo || n.lo >= hi:
+		return true
+	case n.leaf():
+		return yield(n.key, r-l)
+	}
+	l0, r0, l1, r1 := n.children(l, r)
+	return n.child[0].rangeKeys(l0, r0, lo, hi, yield) && n.child[1].rangeKeys(l1, r1, lo, hi, yield)
+}
+
+// RangeElements returns the positions of the elements of s[l:r] with keys in [lo, hi), and their
+// keys. The elements come grouped by key, in the order of RangeKeys, and in ascending order of
+// position within each key. Each element takes O(log σ) time to map back to its position.
+func (w *Int64Keys) RangeElements(l, r int, lo, hi int64) iter.Seq2[int, int64] {
+	return func(yield func(int, int64) bool) {
+		l, r := clampRange(l, r, w.n)
+		if w.root != nil {
+			w.root.rangeElements(l, r, lo, hi, nil, yield)
+		}
+	}
+}
+
+// branch is a step down the tree, from the node to its child on the bit.
+type branch struct {
+	n   *node
+	bit int
+}
+
+// rangeElements yields the positions in the root, reached from n by path, of the elements with
+// keys in [lo, hi) in the interval [l, r) of n, and reports whether to go on.
+func (n *node) rangeElements(l, r int, lo, hi int64, path []branch, yield func(int, int64) bool) bool {
+	switch {
+	case l >= r || n.hi < lo || n.lo >= hi:
+		return true
+	case n.leaf():
+		for j := l; j < r; j++ {
+			i := j
+			for k := len(path) - 1; k >= 0; k-- {
+				if path[k].bit == 1 {
+					i = path[k].n.bv.Select1(i)
+				} else {
+					i = path[k].n.bv.Select0(i)
+				}
+			}
+			if !yield(i, n.key) {
+				return false
+			}
+		}
+		return true
+	}
+	l0, r0, l1, r1 := n.children(l, r)
+	return n.child[0].rangeElements(l0, r0, lo, hi, append(path, branch{n, 0}), yield) &&
+		n.child[1].rangeElements(l1, r1, lo, hi, append(path, branch{n, 1}), yield)
+}
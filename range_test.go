@@ -0,0 +1,346 @@
+package wltree
+
+import (
+	"math/rand"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestMode(t *testing.T) {
+	fails := 0
+
+	for size := 0; size < 64; size++ {
+		for _, ws := range weights {
+			bs := random(size, ws)
+			wt := NewBytes(bs)
+			wti := NewInt64Keys(byteSlice(bs))
+
+			for l := 0; l <= len(bs) && fails < 30; l++ {
+				for r := l; r <= len(bs); r++ {
+					var counts [256]int
+					for _, c := range bs[l:r] {
+						counts[c]++
+					}
+					max := 0
+					for _, n := range counts {
+						if n > max {
+							max = n
+						}
+					}
+
+					c, count := wt.Mode(l, r)
+					if count != max || counts[c] != max {
+						t.Errorf("Bytes: %q.Mode(%v, %v) => got (%q, %v), want count %v", bs, l, r, c, count, max)
+						fails++
+					}
+					key, count := wti.Mode(l, r)
+					if count != max || key < 0 || key > 255 || counts[key] != max {
+						t.Errorf("IntKeys: %q.Mode(%v, %v) => got (%v, %v), want count %v", bs, l, r, key, count, max)
+						fails++
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDistinctCount(t *testing.T) {
+	fails := 0
+
+	for size := 0; size < 64; size++ {
+		for _, ws := range weights {
+			bs := random(size, ws)
+			wt := NewBytes(bs)
+			wti := NewInt64Keys(byteSlice(bs))
+
+			for l := 0; l <= len(bs) && fails < 30; l++ {
+				seen := make(map[byte]bool)
+				for r := l; r <= len(bs); r++ {
+					if r > l {
+						seen[bs[r-1]] = true
+					}
+					if got, want := wt.DistinctCount(l, r), len(seen); got != want {
+						t.Errorf("Bytes: %q.DistinctCount(%v, %v) => got %v, want %v", bs, l, r, got, want)
+						fails++
+					}
+					if got, want := wti.DistinctCount(l, r), len(seen); got != want {
+						t.Errorf("IntKeys: %q.DistinctCount(%v, %v) => got %v, want %v", bs, l, r, got, want)
+						fails++
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestFrequent(t *testing.T) {
+	fails := 0
+
+	for size := 0; size < 64; size++ {
+		for _, ws := range weights {
+			bs := random(size, ws)
+			wt := NewBytes(bs)
+			wti := NewInt64Keys(byteSlice(bs))
+
+			for l := 0; l <= len(bs) && fails < 30; l++ {
+				var counts [256]int
+				for r := l; r <= len(bs); r++ {
+					if r > l {
+						counts[bs[r-1]]++
+					}
+					for _, min := range []int{0, 1, 2, 5, 20} {
+						want := 0
+						for _, n := range counts {
+							if n > 0 && n >= min {
+								want++
+							}
+						}
+
+						cs, ns := wt.Frequent(l, r, min)
+						if len(cs) != want {
+							t.Errorf("Bytes: %q.Frequent(%v, %v, %v) => got %q, want %v characters", bs, l, r, min, cs, want)
+							fails++
+						}
+						for i, c := range cs {
+							if ns[i] != counts[c] {
+								t.Errorf("Bytes: %q.Frequent(%v, %v, %v) => got count %v for %q, want %v", bs, l, r, min, ns[i], c, counts[c])
+								fails++
+							}
+						}
+						keys, ns := wti.Frequent(l, r, min)
+						if len(keys) != want {
+							t.Errorf("IntKeys: %q.Frequent(%v, %v, %v) => got %v, want %v keys", bs, l, r, min, keys, want)
+							fails++
+						}
+						for i, key := range keys {
+							if ns[i] != counts[key] {
+								t.Errorf("IntKeys: %q.Frequent(%v, %v, %v) => got count %v for %v, want %v", bs, l, r, min, ns[i], key, counts[key])
+								fails++
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestAnagram(t *testing.T) {
+	fails := 0
+
+	for size := 0; size < 48; size++ {
+		for _, ws := range weights {
+			bs := random(size, ws)
+			wt := NewBytes(bs)
+			wti := NewInt64Keys(byteSlice(bs))
+
+			for i := 0; i < 200 && fails < 30; i++ {
+				l1, r1 := randomRange(len(bs))
+				l2, r2 := randomRange(len(bs))
+				if i%2 == 0 && r1-l1 <= len(bs)-l2 {
+					r2 = l2 + r1 - l1
+				}
+				var diffs [256]int
+				for _, c := range bs[l1:r1] {
+					diffs[c]++
+				}
+				for _, c := range bs[l2:r2] {
+					diffs[c]--
+				}
+				want := true
+				for _, d := range diffs {
+					if d != 0 {
+						want = false
+					}
+				}
+
+				if got := wt.AnagramEqual(l1, r1, l2, r2); got != want {
+					t.Errorf("Bytes: %q.AnagramEqual(%v, %v, %v, %v) => got %v, want %v", bs, l1, r1, l2, r2, got, want)
+					fails++
+				}
+				if got := wti.AnagramEqual(l1, r1, l2, r2); got != want {
+					t.Errorf("IntKeys: %q.AnagramEqual(%v, %v, %v, %v) => got %v, want %v", bs, l1, r1, l2, r2, got, want)
+					fails++
+				}
+
+				var got [256]int
+				cs, ds := wt.AnagramDiff(l1, r1, l2, r2)
+				for j, c := range cs {
+					got[c] = ds[j]
+				}
+				if got != diffs {
+					t.Errorf("Bytes: %q.AnagramDiff(%v, %v, %v, %v) => got (%q, %v)", bs, l1, r1, l2, r2, cs, ds)
+					fails++
+				}
+				got = [256]int{}
+				keys, ds := wti.AnagramDiff(l1, r1, l2, r2)
+				for j, key := range keys {
+					got[key] = ds[j]
+				}
+				if got != diffs {
+					t.Errorf("IntKeys: %q.AnagramDiff(%v, %v, %v, %v) => got (%v, %v)", bs, l1, r1, l2, r2, keys, ds)
+					fails++
+				}
+			}
+		}
+	}
+}
+
+// randomRange returns a random range l, r such that 0 <= l <= r <= n.
+func randomRange(n int) (l, r int) {
+	l = rand.Intn(n + 1)
+	r = l + rand.Intn(n-l+1)
+	return l, r
+}
+
+func TestInversions(t *testing.T) {
+	fails := 0
+
+	for size := 0; size < 48; size++ {
+		for _, ws := range weights {
+			bs := random(size, ws)
+			wt := NewBytes(bs)
+			wti := NewInt64Keys(byteSlice(bs))
+
+			for l := 0; l <= len(bs) && fails < 30; l++ {
+				want := 0
+				for r := l; r <= len(bs); r++ {
+					if r > l {
+						for _, c := range bs[l : r-1] {
+							if c > bs[r-1] {
+								want++
+							}
+						}
+					}
+					if got := wt.Inversions(l, r); got != want {
+						t.Errorf("Bytes: %q.Inversions(%v, %v) => got %v, want %v", bs, l, r, got, want)
+						fails++
+					}
+					if got := wti.Inversions(l, r); got != want {
+						t.Errorf("IntKeys: %q.Inversions(%v, %v) => got %v, want %v", bs, l, r, got, want)
+						fails++
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestInversionsHuffman(t *testing.T) {
+	// A Huffman-shaped tree over the whole byte range has nodes with overlapping children, which
+	// must be counted in space proportional to the keys, not to the range.
+	s := make([]byte, 1<<18)
+	for i := range s {
+		s[i] = byte(rand.Intn(256) & rand.Intn(256))
+	}
+	wt := NewBytes(s)
+	balanced := NewInt64KeysWithOptions(byteSlice(s), &Options{Shape: BalancedShape})
+	for trial := 0; trial < 4; trial++ {
+		l, r := randomRange(len(s))
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		got := wt.Inversions(l, r)
+		runtime.ReadMemStats(&after)
+		if want := balanced.Inversions(l, r); got != want {
+			t.Errorf("Inversions(%v, %v) => got %v, want %v", l, r, got, want)
+		}
+		if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 1<<16 {
+			t.Errorf("Inversions(%v, %v) => allocated %v bytes, want at most %v", l, r, alloc, 1<<16)
+		}
+	}
+}
+
+func TestRankLessThan(t *testing.T) {
+	fails := 0
+
+	for size := 0; size < 128; size++ {
+		for _, ws := range weights {
+			bs := random(size, ws)
+			wt := NewBytes(bs)
+			wti := NewInt64Keys(byteSlice(bs))
+
+			var counts [257]int
+			for i := 0; i <= len(bs) && fails < 30; i++ {
+				less := 0
+				for c := 0; c < 256; c++ {
+					if got, want := wt.RankLessThan(byte(c), i), less; got != want {
+						t.Errorf("Bytes: %q.RankLessThan(%q, %v) => got %v, want %v", bs, byte(c), i, got, want)
+						fails++
+					}
+					if got, want := wti.RankLessThan(int64(c), i), less; got != want {
+						t.Errorf("IntKeys: %q.RankLessThan(%v, %v) => got %v, want %v", bs, c, i, got, want)
+						fails++
+					}
+					less += counts[c]
+				}
+				if got, want := wti.RankLessThan(256, i), i; got != want {
+					t.Errorf("IntKeys: %q.RankLessThan(256, %v) => got %v, want %v", bs, i, got, want)
+					fails++
+				}
+				if i != len(bs) {
+					counts[bs[i]]++
+				}
+			}
+		}
+	}
+}
+
+func TestRangeCount(t *testing.T) {
+	for size := 0; size < 128; size += 7 {
+		for _, ws := range weights {
+			bs := random(size, ws)
+			for _, shape := range []Shape{HuffmanShape, BalancedShape} {
+				wt := NewInt64KeysWithOptions(byteSlice(bs), &Options{Shape: shape})
+				for k := 0; k < 20; k++ {
+					l, r := randomRange(len(bs))
+					lo, hi := int64(rand.Intn(130)-1), int64(rand.Intn(130)-1)
+					want := make(map[int64]int)
+					for _, c := range bs[l:r] {
+						if lo <= int64(c) && int64(c) < hi {
+							want[int64(c)]++
+						}
+					}
+					count := 0
+					for _, c := range want {
+						count += c
+					}
+					if got := wt.RangeCount(l, r, lo, hi); got != count {
+						t.Errorf("%q.RangeCount(%v, %v, %v, %v) => got %v, want %v", bs, l, r, lo, hi, got, count)
+					}
+					got := make(map[int64]int)
+					prev := int64(-1)
+					for key, c := range wt.RangeKeys(l, r, lo, hi) {
+						if shape == BalancedShape && key <= prev {
+							t.Errorf("%q.RangeKeys(%v, %v, %v, %v) => %v after %v", bs, l, r, lo, hi, key, prev)
+						}
+						got[key], prev = c, key
+					}
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("%q.RangeKeys(%v, %v, %v, %v) => got %v, want %v", bs, l, r, lo, hi, got, want)
+					}
+					if shape != BalancedShape {
+						continue
+					}
+					var wantPos, gotPos []int
+					for c := lo; c < hi; c++ {
+						for i := l; i < r; i++ {
+							if int64(bs[i]) == c {
+								wantPos = append(wantPos, i)
+							}
+						}
+					}
+					for i, key := range wt.RangeElements(l, r, lo, hi) {
+						if key != int64(bs[i]) {
+							t.Errorf("%q.RangeElements(%v, %v, %v, %v) => got key %v at %v", bs, l, r, lo, hi, key, i)
+						}
+						gotPos = append(gotPos, i)
+					}
+					if !reflect.DeepEqual(gotPos, wantPos) {
+						t.Errorf("%q.RangeElements(%v, %v, %v, %v) => got %v, want %v", bs, l, r, lo, hi, gotPos, wantPos)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package wltree
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+)
+
+// NewBytesFromReader constructs a Wavelet Tree from the bytes read from r until EOF.
+// The input is read twice, once to count the characters and once to index them. If r is an
+// io.ReadSeeker it is rewound for the second pass, otherwise the input is first spilled to a
+// temporary file. Either way the input is never held in memory as a whole.
+func NewBytesFromReader(r io.Reader) (*Bytes, error) {
+	rs, ok := r.(io.ReadSeeker)
+	if !ok {
+		f, err := os.CreateTemp("", "wltree")
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		if _, err := io.Copy(f, r); err != nil {
+			return nil, err
+		}
+		rs = f
+	}
+
+	// The second pass rewinds to where the input started: the current offset of r, or the
+	// beginning of the spilled file.
+	var (
+		start int64
+		err   error
+	)
+	if ok {
+		if start, err = rs.Seek(0, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+
+	// seq yields the bytes of rs from start, and stops at the first error other than io.EOF,
+	// leaving it in err.
+	seq := func(yield func(int64) bool) {
+		if _, err = rs.Seek(start, io.SeekStart); err != nil {
+			return
+		}
+		br := bufio.NewReader(rs)
+		for {
+			c, e := br.ReadByte()
+			if e != nil {
+				if e != io.EOF {
+					err = e
+				}
+				return
+			}
+			if !yield(int64(c)) {
+				return
+			}
+		}
+	}
+
+	var dense [256]int
+	size := 0
+	for k := range seq {
+		if size == maxLen {
+			return nil, ErrTooLong
+		}
+		size++
+		dense[k]++
+	}
+	if err != nil {
+		return nil, err
+	}
+	x := newByteIndexer(&dense, nil, nil, new(scratch))
+	for c := range seq {
+		x.add(byte(c), 0, 1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return x.build(context.Background(), nil), nil
+}
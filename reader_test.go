@@ -0,0 +1,40 @@
+package wltree
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestNewBytesFromReader(t *testing.T) {
+	s := []byte(strings.Repeat("abracadabra", 1000))
+	for _, r := range []io.Reader{
+		bytes.NewReader(s),
+		iotest.HalfReader(bytes.NewReader(s)),
+	} {
+		wt, err := NewBytesFromReader(r)
+		if err != nil {
+			t.Fatalf("NewBytesFromReader(%T) => %v", r, err)
+		}
+
+		var counts [256]int
+		for i, c := range s {
+			if got, want := wt.Rank(c, i), counts[c]; got != want {
+				t.Errorf("%T: Rank(%q, %v) => got %v, want %v", r, c, i, got, want)
+			}
+			if got, want := wt.Select(c, counts[c]), i; got != want {
+				t.Errorf("%T: Select(%q, %v) => got %v, want %v", r, c, counts[c], got, want)
+			}
+			counts[c]++
+		}
+	}
+
+	errRead := errors.New("read failed")
+	r := io.MultiReader(bytes.NewReader(s), iotest.ErrReader(errRead))
+	if _, err := NewBytesFromReader(r); err != errRead {
+		t.Errorf("NewBytesFromReader(failing reader) => got error %v, want %v", err, errRead)
+	}
+}
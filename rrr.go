@@ -0,0 +1,236 @@
+package wltree
+
+import (
+	"math/bits"
+	"sort"
+)
+
+const (
+	// rrrBlock is the number of bits in a block of rrrBits, and rrrSuper the number of blocks
+	// between the samples of its rank directory.
+	rrrBlock = 15
+	rrrSuper = 64
+)
+
+var (
+	// binomial[n][k] is n choose k.
+	binomial [rrrBlock + 1][rrrBlock + 1]int
+	// rrrWidth[k] is the number of bits of the offset of a block with k ones.
+	rrrWidth [rrrBlock + 1]int
+)
+
+func init() {
+	for n := range binomial {
+		binomial[n][0] = 1
+		for k := 1; k <= n; k++ {
+			binomial[n][k] = binomial[n-1][k-1] + binomial[n-1][k]
+		}
+	}
+	for k := range rrrWidth {
+		rrrWidth[k] = bits.Len(uint(binomial[rrrBlock][k] - 1))
+	}
+}
+
+// RRR is a Backend that builds H0-compressed bit vectors in the style of Raman, Raman and Rao:
+// each block of 15 bits is stored as its number of ones and its index among the blocks with that
+// many ones, so that a tree takes space close to the zero-order entropy of s. Queries decode a
+// few blocks, and are slower than with DefaultBackend.
+func RRR(size int) BitVectorBuilder {
+	return &rrrBuilder{words: make([]uint64, (size+63)/64), size: size}
+}
+
+type rrrBuilder struct {
+	words []uint64
+	size  int
+}
+
+func (b *rrrBuilder) Set(i int) {
+	b.words[i/64] |= 1 << uint(i%64)
+}
+
+func (b *rrrBuilder) Build() RankSelect {
+	nblocks := (b.size + rrrBlock - 1) / rrrBlock
+	v := &rrrBits{
+		size:    b.size,
+		classes: make([]byte, (nblocks+1)/2),
+		ranks:   make([]int, nblocks/rrrSuper+1),
+		offsets: make([]int, nblocks/rrrSuper+1),
+	}
+	rank, off := 0, 0
+	for j := 0; j < nblocks; j++ {
+		if j%rrrSuper == 0 {
+			v.ranks[j/rrrSuper], v.offsets[j/rrrSuper] = rank, off
+		}
+		x := b.block(j)
+		k := bits.OnesCount16(x)
+		v.classes[j/2] |= byte(k) << (4 * uint(j%2))
+		v.bits.write(off, rrrWidth[k], encodeBlock(x, k))
+		rank += k
+		off += rrrWidth[k]
+	}
+	if nblocks%rrrSuper == 0 {
+		v.ranks[nblocks/rrrSuper], v.offsets[nblocks/rrrSuper] = rank, off
+	}
+	return v
+}
+
+// block returns the j-th block of bits.
+func (b *rrrBuilder) block(j int) uint16 {
+	var x uint16
+	for i := 0; i < rrrBlock && j*rrrBlock+i < b.size; i++ {
+		p := j*rrrBlock + i
+		x |= uint16(b.words[p/64]>>uint(p%64)&1) << uint(i)
+	}
+	return x
+}
+
+// encodeBlock returns the index of the block x with k ones among all such blocks in ascending
+// order.
+func encodeBlock(x uint16, k int) uint64 {
+	var off int
+	for j := rrrBlock - 1; j >= 0 && k > 0; j-- {
+		if x&(1<<uint(j)) != 0 {
+			off += binomial[j][k]
+			k--
+		}
+	}
+	return uint64(off)
+}
+
+// decodeBlock returns the block with k ones whose index is off.
+func decodeBlock(off uint64, k int) uint16 {
+	var x uint16
+	o := int(off)
+	for j := rrrBlock - 1; j >= 0 && k > 0; j-- {
+		if o >= binomial[j][k] {
+			x |= 1 << uint(j)
+			o -= binomial[j][k]
+			k--
+		}
+	}
+	return x
+}
+
+// rrrBits is an H0-compressed bit vector built by RRR.
+type rrrBits struct {
+	size int
+	// classes holds the number of ones of each block, 4 bits each, and bits the offsets of the
+	// blocks, with widths given by their classes.
+	classes []byte
+	bits    bitStream
+	// ranks and offsets are the number of ones before each superblock and the position of its
+	// first offset in bits.
+	ranks   []int
+	offsets []int
+}
+
+func (v *rrrBits) class(j int) int {
+	return int(v.classes[j/2] >> (4 * uint(j%2)) & 15)
+}
+
+// seek returns the number of ones before block j, and the position of its offset.
+func (v *rrrBits) seek(j int) (rank, off int) {
+	s := j / rrrSuper
+	rank, off = v.ranks[s], v.offsets[s]
+	for b := s * rrrSuper; b < j; b++ {
+		k := v.class(b)
+		rank += k
+		off += rrrWidth[k]
+	}
+	return rank, off
+}
+
+func (v *rrrBits) decode(j, off int) uint16 {
+	k := v.class(j)
+	return decodeBlock(v.bits.read(off, rrrWidth[k]), k)
+}
+
+func (v *rrrBits) Len() int {
+	return v.size
+}
+
+func (v *rrrBits) Rank1(i int) int {
+	j := i / rrrBlock
+	rank, off := v.seek(j)
+	if i%rrrBlock != 0 {
+		rank += bits.OnesCount16(v.decode(j, off) & (1<<uint(i%rrrBlock) - 1))
+	}
+	return rank
+}
+
+func (v *rrrBits) Rank0(i int) int {
+	return i - v.Rank1(i)
+}
+
+func (v *rrrBits) Select1(r int) int {
+	return v.selectBit(r, func(j, ones int) int { return ones }, func(x uint16) uint16 { return x })
+}
+
+func (v *rrrBits) Select0(r int) int {
+	return v.selectBit(r, func(j, ones int) int { return j*rrrBlock - ones }, func(x uint16) uint16 { return ^x })
+}
+
+// selectBit returns the position of the r-th bit of the blocks transformed by flip, where
+// before(j, ones) is the number of such bits before block j, which has ones ones before it.
+func (v *rrrBits) selectBit(r int, before func(j, ones int) int, flip func(uint16) uint16) int {
+	if r < 0 {
+		panic("wltree: select with negative rank")
+	}
+	// The last superblock with no more than r such bits before it holds the r-th bit.
+	s := sort.Search(len(v.ranks), func(s int) bool { return before(s*rrrSuper, v.ranks[s]) > r }) - 1
+	rank, off := v.ranks[s], v.offsets[s]
+	nblocks := (v.size + rrrBlock - 1) / rrrBlock
+	for j := s * rrrSuper; j < nblocks; j++ {
+		k := v.class(j)
+		if before(j+1, rank+k) <= r {
+			rank += k
+			off += rrrWidth[k]
+			continue
+		}
+		x := flip(v.decode(j, off))
+		for r -= before(j, rank); ; r-- {
+			t := bits.TrailingZeros16(x)
+			if r == 0 {
+				if pos := j*rrrBlock + t; pos < v.size {
+					return pos
+				}
+				break
+			}
+			x &^= 1 << uint(t)
+		}
+		break
+	}
+	panic("wltree: select beyond the last bit")
+}
+
+// bitStream is a sequence of bits packed LSB first into words, read and written in fields of up
+// to 64 bits.
+type bitStream []uint64
+
+// write writes the n low bits of x at position off, growing the stream as needed.
+func (s *bitStream) write(off, n int, x uint64) {
+	if n == 0 {
+		return
+	}
+	for len(*s) <= (off+n-1)/64 {
+		*s = append(*s, 0)
+	}
+	w, b := off/64, uint(off%64)
+	(*s)[w] |= x << b
+	if b+uint(n) > 64 {
+		(*s)[w+1] |= x >> (64 - b)
+	}
+}
+
+// read returns the n bits at position off.
+func (s bitStream) read(off, n int) uint64 {
+	if n == 0 {
+		return 0
+	}
+	w, b := off/64, uint(off%64)
+	x := s[w] >> b
+	if b+uint(n) > 64 {
+		x |= s[w+1] << (64 - b)
+	}
+	return x & (1<<uint(n) - 1)
+}
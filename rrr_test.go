@@ -0,0 +1,52 @@
+package wltree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRRR(t *testing.T) {
+	fails := 0
+	for _, size := range []int{0, 1, 14, 15, 16, 100, 959, 960, 961, 5000} {
+		for _, density := range []float64{0, 0.01, 0.5, 0.99, 1} {
+			want := make(boolBits, size)
+			b := RRR(size)
+			for i := range want {
+				if rand.Float64() < density {
+					want[i] = true
+					b.Set(i)
+				}
+			}
+			got := b.Build()
+			if got.Len() != size {
+				t.Errorf("RRR(%v).Len() => got %v", size, got.Len())
+			}
+			ones := 0
+			for i := 0; i <= size && fails < 30; i++ {
+				if r := got.Rank1(i); r != ones {
+					t.Errorf("RRR(%v, %v).Rank1(%v) => got %v, want %v", size, density, i, r, ones)
+					fails++
+				}
+				if i == size {
+					break
+				}
+				if want[i] {
+					if p := got.Select1(ones); p != i {
+						t.Errorf("RRR(%v, %v).Select1(%v) => got %v, want %v", size, density, ones, p, i)
+						fails++
+					}
+					ones++
+				} else if p := got.Select0(i - ones); p != i {
+					t.Errorf("RRR(%v, %v).Select0(%v) => got %v, want %v", size, density, i-ones, p, i)
+					fails++
+				}
+			}
+		}
+	}
+
+	s := random(maxSize, weights[1])
+	wt := NewBytesWithOptions(s, &Options{Backend: RRR})
+	if !wt.Equal(NewBytes(s)) {
+		t.Errorf("NewBytesWithOptions(RRR) => tree differs from default backend")
+	}
+}
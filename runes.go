@@ -0,0 +1,137 @@
+package wltree
+
+import "fmt"
+
+// Runes represents a Wavelet Tree on Unicode code points.
+type Runes struct {
+	keys *Int64Keys
+}
+
+// NewRunes constructs a Wavelet Tree from a slice of runes.
+func NewRunes(s []rune) *Runes {
+	return &Runes{keys: NewInt64Keys(runeSlice(s))}
+}
+
+// NewRunesWithOptions is like NewRunes, but configured by opts.
+func NewRunesWithOptions(s []rune, opts *Options) *Runes {
+	return &Runes{keys: NewInt64KeysWithOptions(runeSlice(s), opts)}
+}
+
+// NewString constructs a Wavelet Tree from the code points of a UTF-8 string.
+// Positions in the tree count code points, not bytes.
+func NewString(s string) *Runes {
+	return NewRunes([]rune(s))
+}
+
+// Contains reports whether the character c occurs in s.
+func (w *Runes) Contains(c rune) bool {
+	return w.keys.Contains(int64(c))
+}
+
+// Symbols returns the characters known to the tree in ascending order, and the number of
+// occurrences of each of them in s.
+func (w *Runes) Symbols() (cs []rune, counts []int) {
+	keys, counts := w.keys.Symbols()
+	return runes(keys), counts
+}
+
+// Len returns the number of characters in s.
+func (w *Runes) Len() int {
+	return w.keys.Len()
+}
+
+// Count returns the count of the character c in s.
+func (w *Runes) Count(c rune) int {
+	return w.keys.Count(int64(c))
+}
+
+// Rank returns the count of the character c in s[0:i].
+func (w *Runes) Rank(c rune, i int) int {
+	return w.keys.Rank(int64(c), i)
+}
+
+// Select returns i such that Rank(c, i) = r.
+// i.e. it returns the index of r-th occurrence of the character c.
+// Note that r is 0-origined, so wt.Select('é', 2) returns the index of the third 'é'.
+// Errors are reported as selected by Options.ErrorMode, except that Select on an empty tree
+// always returns -1.
+func (w *Runes) Select(c rune, r int) int {
+	if w.keys.errorMode == PanicOnError && w.keys.n > 0 && !w.Contains(c) {
+		panic(fmt.Sprintf("wltree: no such character %q in s.", c))
+	}
+	return w.keys.Select(int64(c), r)
+}
+
+// SelectChecked is like Select, but reports false instead of panicking or returning garbage when
+// s has no r-th occurrence of the character c.
+func (w *Runes) SelectChecked(c rune, r int) (int, bool) {
+	return w.keys.SelectChecked(int64(c), r)
+}
+
+// Access returns the i-th character of s. It panics if i is out of range.
+func (w *Runes) Access(i int) rune {
+	return rune(w.keys.Access(i))
+}
+
+// Mode returns the most frequent character in s[l:r] and the number of its occurrences.
+// If several characters are equally frequent, any one of them is returned. It returns count 0 for
+// an empty range.
+func (w *Runes) Mode(l, r int) (c rune, count int) {
+	key, count := w.keys.Mode(l, r)
+	return rune(key), count
+}
+
+// DistinctCount returns the number of distinct characters in s[l:r].
+func (w *Runes) DistinctCount(l, r int) int {
+	return w.keys.DistinctCount(l, r)
+}
+
+// Frequent returns the characters occurring at least min times in s[l:r] and their counts, in no
+// particular order.
+func (w *Runes) Frequent(l, r, min int) (cs []rune, counts []int) {
+	keys, counts := w.keys.Frequent(l, r, min)
+	return runes(keys), counts
+}
+
+// AnagramEqual reports whether s[l1:r1] and s[l2:r2] contain exactly the same multiset of
+// characters.
+func (w *Runes) AnagramEqual(l1, r1, l2, r2 int) bool {
+	return w.keys.AnagramEqual(l1, r1, l2, r2)
+}
+
+// AnagramDiff returns the characters whose counts in s[l1:r1] and s[l2:r2] differ, and for each
+// of them the count in s[l1:r1] minus the count in s[l2:r2].
+func (w *Runes) AnagramDiff(l1, r1, l2, r2 int) (cs []rune, diffs []int) {
+	keys, diffs := w.keys.AnagramDiff(l1, r1, l2, r2)
+	return runes(keys), diffs
+}
+
+// Inversions returns the number of pairs i < j in s[l:r] such that s[i] > s[j].
+func (w *Runes) Inversions(l, r int) int {
+	return w.keys.Inversions(l, r)
+}
+
+// RankLessThan returns the count of characters smaller than c in s[0:i].
+func (w *Runes) RankLessThan(c rune, i int) int {
+	return w.keys.RankLessThan(int64(c), i)
+}
+
+func runes(keys []int64) []rune {
+	if keys == nil {
+		return nil
+	}
+	cs := make([]rune, len(keys))
+	for i, key := range keys {
+		cs[i] = rune(key)
+	}
+	return cs
+}
+
+type runeSlice []rune
+
+func (r runeSlice) Len() int {
+	return len(r)
+}
+func (r runeSlice) Key(i int) int64 {
+	return int64(r[i])
+}
@@ -0,0 +1,29 @@
+package wltree
+
+import "testing"
+
+func TestRunes(t *testing.T) {
+	s := "héllo wörld, héllo wéb"
+	rs := []rune(s)
+	wt := NewString(s)
+
+	var counts = make(map[rune]int)
+	for i := 0; i <= len(rs); i++ {
+		for _, c := range "héloöwx" {
+			if got, want := wt.Rank(c, i), counts[c]; got != want {
+				t.Errorf("%q.Rank(%q, %v) => got %v, want %v", s, c, i, got, want)
+			}
+		}
+		if i != len(rs) {
+			c := rs[i]
+			if got, want := wt.Select(c, counts[c]), i; got != want {
+				t.Errorf("%q.Select(%q, %v) => got %v, want %v", s, c, counts[c], got, want)
+			}
+			counts[c]++
+		}
+	}
+
+	if c, count := wt.Mode(0, len(rs)); c != 'l' || count != 5 {
+		t.Errorf("%q.Mode(0, %v) => got (%q, %v), want ('l', 5)", s, len(rs), c, count)
+	}
+}
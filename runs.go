@@ -0,0 +1,79 @@
+package wltree
+
+import "sort"
+
+// runBits is a read-only bit vector of n bits stored as its runs of ones.
+type runBits struct {
+	n int
+	// starts are the positions where the runs start, and ones[k] the number of ones before the
+	// k-th run, with ones[len(starts)] the total.
+	starts []int
+	ones   []int
+}
+
+func (b *runBits) Len() int {
+	return b.n
+}
+
+func (b *runBits) Rank1(i int) int {
+	// The last run starting before i.
+	k := sort.SearchInts(b.starts, i) - 1
+	if k < 0 {
+		return 0
+	}
+	return b.ones[k] + min(i-b.starts[k], b.ones[k+1]-b.ones[k])
+}
+
+func (b *runBits) Rank0(i int) int {
+	return i - b.Rank1(i)
+}
+
+func (b *runBits) Select1(r int) int {
+	if r < 0 || r >= b.ones[len(b.starts)] {
+		panic("wltree: select beyond the last bit")
+	}
+	// The last run with no more than r ones before it.
+	k := sort.Search(len(b.starts), func(k int) bool { return b.ones[k] > r }) - 1
+	return b.starts[k] + r - b.ones[k]
+}
+
+func (b *runBits) Select0(r int) int {
+	if r < 0 {
+		panic("wltree: select with negative rank")
+	}
+	// The r-th zero follows the runs with no more than r zeros before them.
+	k := sort.Search(len(b.starts), func(k int) bool { return b.starts[k]-b.ones[k] > r })
+	return r + b.ones[k]
+}
+
+// runBuilder builds the nodes of a wavelet tree as runBits.
+type runBuilder struct {
+	nodes map[string]*runBits
+}
+
+// newRunBuilder returns a runBuilder for the nodes with sizes, indexed by code prefix.
+func newRunBuilder(sizes map[string]int) *runBuilder {
+	b := &runBuilder{nodes: make(map[string]*runBits)}
+	for prefix, size := range sizes {
+		b.nodes[prefix] = &runBits{n: size, ones: []int{0}}
+	}
+	return b
+}
+
+func (b *runBuilder) set(prefix string, i int) {
+	n := b.nodes[prefix]
+	k := len(n.starts)
+	if k == 0 || n.starts[k-1]+n.ones[k]-n.ones[k-1] != i {
+		n.starts = append(n.starts, i)
+		n.ones = append(n.ones, n.ones[k])
+	}
+	n.ones[len(n.ones)-1]++
+}
+
+func (b *runBuilder) build() map[string]rankSelect {
+	bvs := make(map[string]rankSelect)
+	for prefix, n := range b.nodes {
+		bvs[prefix] = n
+	}
+	return bvs
+}
@@ -0,0 +1,54 @@
+package wltree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRunLength(t *testing.T) {
+	opts := &Options{RunLength: true}
+	fails := 0
+	for size := 0; size < maxSize && fails < 30; size += 13 {
+		// Runs of random lengths of random characters.
+		var bs []byte
+		for len(bs) < size {
+			c := "abcdef"[rand.Intn(6)]
+			for n := rand.Intn(40); n > 0 && len(bs) < size; n-- {
+				bs = append(bs, c)
+			}
+		}
+		wt := NewBytesWithOptions(bs, opts)
+
+		var counts [256]int
+		for i := 0; i <= len(bs) && fails < 30; i++ {
+			for _, c := range []byte("abcdefg") {
+				if got, want := wt.Rank(c, i), counts[c]; got != want {
+					t.Errorf("%q.Rank(%v, %v) => got %v, want %v", bs, string(c), i, got, want)
+					fails++
+				}
+			}
+			if i != len(bs) {
+				c := bs[i]
+				if got, want := wt.Select(c, counts[c]), i; got != want {
+					t.Errorf("%q.Select(%v, %v) => got %v, want %v", bs, string(c), counts[c], got, want)
+					fails++
+				}
+				counts[c]++
+			}
+		}
+		if !wt.Equal(NewBytes(bs)) {
+			t.Errorf("%q: run-length tree differs from plain tree", bs)
+		}
+	}
+
+	// Every node of a tree on one long run per character holds at most one run of ones.
+	bs := []byte("aaaaaaaaaabbbbbbbbbbccccccccccdddddddddd")
+	wt := NewBytesWithOptions(bs, opts)
+	for _, path := range wt.nodes {
+		for _, bv := range path {
+			if runs := len(bv.(*runBits).starts); runs > 1 {
+				t.Errorf("%q: node with %v runs of ones, want at most 1", bs, runs)
+			}
+		}
+	}
+}
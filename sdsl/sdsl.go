@@ -0,0 +1,110 @@
+/*
+Package sdsl reads and writes bit vectors in the serialization format of SDSL-lite
+(https://github.com/simongog/sdsl-lite), so that the bits of wavelet tree nodes can be exchanged
+with indexes built by C++ pipelines.
+
+Only the bit_vector (int_vector<1>) layout is covered: the length in bits as a little-endian
+uint64, followed by the bits in little-endian uint64 words, least significant bit first. Whole
+wt_huff and wt_int indexes can neither be imported nor exported: their rank and select supports
+and node tables vary between SDSL versions and are not interpreted. What can be exchanged are the
+bits of single nodes, with FromRankSelect for the nodes of a wltree.Node, and RankSelect for
+querying bit vectors read from SDSL.
+*/
+package sdsl
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/mozu0/wltree"
+)
+
+// ErrTooLong is the error for a bit vector longer than this platform can hold in memory.
+var ErrTooLong = errors.New("sdsl: bit vector too long")
+
+// BitVector is a bit vector in the word layout of SDSL-lite.
+type BitVector struct {
+	// Len is the number of bits.
+	Len int
+	// Words holds the bits, bit i in Words[i/64] at position i%64.
+	Words []uint64
+}
+
+// Get returns the i-th bit of b.
+func (b *BitVector) Get(i int) bool {
+	return b.Words[i/64]&(1<<uint(i%64)) != 0
+}
+
+// Set sets the i-th bit of b.
+func (b *BitVector) Set(i int) {
+	b.Words[i/64] |= 1 << uint(i%64)
+}
+
+// New returns a bit vector of n zero bits.
+func New(n int) *BitVector {
+	return &BitVector{Len: n, Words: make([]uint64, (n+63)/64)}
+}
+
+// ReadBitVector reads a bit_vector serialized by SDSL-lite from r.
+func ReadBitVector(r io.Reader) (*BitVector, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, err
+	}
+	n := binary.LittleEndian.Uint64(buf[:])
+	if n > uint64(maxInt-63) {
+		return nil, ErrTooLong
+	}
+	b := &BitVector{Len: int(n)}
+	// The words are read one by one, so that a corrupt length cannot make it allocate much more
+	// memory than r actually holds.
+	for i := 0; i < (b.Len+63)/64; i++ {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		b.Words = append(b.Words, binary.LittleEndian.Uint64(buf[:]))
+	}
+	return b, nil
+}
+
+// WriteBitVector writes b to w as a bit_vector serialized by SDSL-lite.
+func WriteBitVector(w io.Writer, b *BitVector) error {
+	buf := make([]byte, 8*(1+(b.Len+63)/64))
+	binary.LittleEndian.PutUint64(buf, uint64(b.Len))
+	for i := 0; i < (b.Len+63)/64; i++ {
+		word := b.Words[i]
+		if i == b.Len/64 && b.Len%64 != 0 {
+			// SDSL keeps the bits beyond the length zero.
+			word &= 1<<uint(b.Len%64) - 1
+		}
+		binary.LittleEndian.PutUint64(buf[8*(i+1):], word)
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// FromRankSelect returns the bits of rs, such as the bits of a node given by wltree.Node.Bits.
+func FromRankSelect(rs wltree.RankSelect) *BitVector {
+	b := New(rs.Len())
+	for r, ones := 0, rs.Rank1(rs.Len()); r < ones; r++ {
+		b.Set(rs.Select1(r))
+	}
+	return b
+}
+
+// RankSelect returns the bits of b indexed for rank and select by wltree.DefaultBackend.
+func (b *BitVector) RankSelect() wltree.RankSelect {
+	v := wltree.DefaultBackend(b.Len)
+	for i := 0; i < b.Len; i++ {
+		if b.Get(i) {
+			v.Set(i)
+		}
+	}
+	return v.Build()
+}
+
+const maxInt = int(^uint(0) >> 1)
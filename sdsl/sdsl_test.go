@@ -0,0 +1,71 @@
+package sdsl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mozu0/wltree"
+)
+
+func TestBitVector(t *testing.T) {
+	b := New(70)
+	for _, i := range []int{0, 3, 63, 64, 69} {
+		b.Set(i)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBitVector(&buf, b); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		70, 0, 0, 0, 0, 0, 0, 0,
+		0x09, 0, 0, 0, 0, 0, 0, 0x80,
+		0x21, 0, 0, 0, 0, 0, 0, 0,
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("WriteBitVector() => got % x, want % x", buf.Bytes(), want)
+	}
+
+	got, err := ReadBitVector(&buf)
+	if err != nil {
+		t.Fatalf("ReadBitVector() => %v", err)
+	}
+	if got.Len != b.Len {
+		t.Errorf("ReadBitVector() => got length %v, want %v", got.Len, b.Len)
+	}
+	for i := 0; i < b.Len; i++ {
+		if got.Get(i) != b.Get(i) {
+			t.Errorf("ReadBitVector() => bit %v is %v, want %v", i, got.Get(i), b.Get(i))
+		}
+	}
+
+	if _, err := ReadBitVector(bytes.NewReader(want[:20])); err == nil {
+		t.Errorf("ReadBitVector(truncated) => got nil error")
+	}
+}
+
+func TestRankSelect(t *testing.T) {
+	w := wltree.NewBytes([]byte("abracadabra"))
+	w.Visit(0, w.Len(), func(nd wltree.Node) bool {
+		bits := nd.Bits()
+		if bits == nil {
+			return true
+		}
+		b := FromRankSelect(bits)
+		if b.Len != bits.Len() {
+			t.Errorf("FromRankSelect() => got length %v, want %v", b.Len, bits.Len())
+		}
+		for i := 0; i < b.Len; i++ {
+			if got, want := b.Get(i), bits.Rank1(i+1) > bits.Rank1(i); got != want {
+				t.Errorf("FromRankSelect() => bit %v is %v, want %v", i, got, want)
+			}
+		}
+		back := b.RankSelect()
+		for i := 0; i <= b.Len; i++ {
+			if got, want := back.Rank1(i), bits.Rank1(i); got != want {
+				t.Errorf("RankSelect().Rank1(%v) => got %v, want %v", i, got, want)
+			}
+		}
+		return true
+	})
+}
@@ -0,0 +1,103 @@
+package wltree
+
+import (
+	"iter"
+	"math"
+)
+
+// NewSeq makes a Wavelet Tree on int64 keys from the sequence of keys yielded by seq, which may be
+// a one-shot stream. seq is ranged over once, and its elements are buffered as the indices of
+// their keys in order of first occurrence, in 1, 2 or 4 bytes each as the number of distinct keys
+// grows, which takes less memory than a slice of the keys.
+func NewSeq[K int | int64](seq iter.Seq[K]) *Int64Keys {
+	return NewSeqLen(seq, 0)
+}
+
+// NewSeqLen is like NewSeq, but sizes the buffer for n elements, the expected length of seq. seq
+// may yield more or fewer elements than n.
+func NewSeqLen[K int | int64](seq iter.Seq[K], n int) *Int64Keys {
+	b := newSeqBuffer(n)
+	for k := range seq {
+		b.add(int64(k))
+	}
+	return newInt64Keys(b.all(), append([]int64(nil), b.keys...), append([]int(nil), b.counts...))
+}
+
+// seqBuffer holds a sequence of keys as the indices of the keys in keys, numbered in order of
+// first occurrence. The indices are in narrow while there are at most 1<<8 keys, in mid while
+// there are at most 1<<16, and in wide afterwards.
+type seqBuffer struct {
+	index  map[int64]int
+	keys   []int64
+	counts []int
+
+	narrow []uint8
+	mid    []uint16
+	wide   []uint32
+	n      int
+}
+
+func newSeqBuffer(n int) *seqBuffer {
+	return &seqBuffer{index: make(map[int64]int), narrow: make([]uint8, 0, max(n, 0))}
+}
+
+// add appends key to the sequence, widening the indices when a new key does not fit them.
+func (b *seqBuffer) add(key int64) {
+	if b.n == maxLen {
+		panic(ErrTooLong)
+	}
+	id, ok := b.index[key]
+	if !ok {
+		id = len(b.keys)
+		if uint64(id) > math.MaxUint32 {
+			panic(ErrTooLong)
+		}
+		b.index[key] = id
+		b.keys = append(b.keys, key)
+		b.counts = append(b.counts, 0)
+		switch id {
+		case 1 << 8:
+			b.mid = make([]uint16, len(b.narrow), max(cap(b.narrow), 2*len(b.narrow)))
+			for i, x := range b.narrow {
+				b.mid[i] = uint16(x)
+			}
+			b.narrow = nil
+		case 1 << 16:
+			b.wide = make([]uint32, len(b.mid), max(cap(b.mid), 2*len(b.mid)))
+			for i, x := range b.mid {
+				b.wide[i] = uint32(x)
+			}
+			b.mid = nil
+		}
+	}
+	b.counts[id]++
+	b.n++
+	switch {
+	case len(b.keys) <= 1<<8:
+		b.narrow = append(b.narrow, uint8(id))
+	case len(b.keys) <= 1<<16:
+		b.mid = append(b.mid, uint16(id))
+	default:
+		b.wide = append(b.wide, uint32(id))
+	}
+}
+
+// all returns the sequence of keys held in b.
+func (b *seqBuffer) all() iter.Seq[int64] {
+	return func(yield func(int64) bool) {
+		for i := 0; i < b.n; i++ {
+			var id int
+			switch {
+			case b.wide != nil:
+				id = int(b.wide[i])
+			case b.mid != nil:
+				id = int(b.mid[i])
+			default:
+				id = int(b.narrow[i])
+			}
+			if !yield(b.keys[id]) {
+				return
+			}
+		}
+	}
+}
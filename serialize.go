@@ -0,0 +1,576 @@
+package wltree
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+	"sort"
+)
+
+// The serialized form of a Wavelet Tree is, with all integers as varints unless noted:
+//
+//	magic "wltr"
+//	format version
+//	length of the sequence
+//	payload
+//	CRC-32 (Castagnoli) of the payload, 4 bytes little endian
+//
+// where the payload of format version 1 is:
+//
+//	number of keys
+//	for each key in ascending order: key, count, length of code, code as '0' and '1' bytes
+//	for each internal node in lexicographic order of code prefix: its bits, 8 per byte, LSB first
+//
+// The shape of the tree and the size of each node follow from the codes and counts, so they are
+// not stored.
+
+const (
+	magic   = "wltr"
+	version = 1
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	// ErrCorrupt is the error for serialized data that does not describe a valid Wavelet Tree.
+	ErrCorrupt = errors.New("wltree: corrupt serialized tree")
+	// ErrChecksum is the error for serialized data whose payload does not match its checksum.
+	ErrChecksum = errors.New("wltree: checksum mismatch in serialized tree")
+	// ErrVersion is the error for serialized data in a format version this package cannot read.
+	ErrVersion = errors.New("wltree: unsupported format version of serialized tree")
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (w *Int64Keys) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := w.encode(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The serialized form does not record the
+// options the tree was built with, so the nodes are stored with DefaultBackend and the query
+// options of w are reset to their defaults. UnmarshalBinaryWithOptions stores them otherwise.
+func (w *Int64Keys) UnmarshalBinary(data []byte) error {
+	return w.UnmarshalBinaryWithOptions(data, nil)
+}
+
+// UnmarshalBinaryWithOptions is like UnmarshalBinary, but stores the nodes and configures w as
+// selected by opts, as NewInt64KeysWithOptions does, so that, for example, a tree built with RRR
+// is loaded compressed again. The options of the shape and of the construction are ignored.
+func (w *Int64Keys) UnmarshalBinaryWithOptions(data []byte, opts *Options) error {
+	v, err := decode(bytes.NewReader(data), opts)
+	if err != nil {
+		return err
+	}
+	*w = *v
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (w *Bytes) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := w.encode(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler like Int64Keys.UnmarshalBinary.
+func (w *Bytes) UnmarshalBinary(data []byte) error {
+	return w.UnmarshalBinaryWithOptions(data, nil)
+}
+
+// UnmarshalBinaryWithOptions is like Int64Keys.UnmarshalBinaryWithOptions.
+func (w *Bytes) UnmarshalBinaryWithOptions(data []byte, opts *Options) error {
+	v, err := decodeBytes(bytes.NewReader(data), opts)
+	if err != nil {
+		return err
+	}
+	*w = *v
+	return nil
+}
+
+// WriteTo implements io.WriterTo. It writes the same serialized form as MarshalBinary, streaming
+// it to out instead of building it in memory.
+func (w *Int64Keys) WriteTo(out io.Writer) (int64, error) {
+	cw := &countingWriter{w: out}
+	err := w.encode(cw)
+	return cw.n, err
+}
+
+// ReadFrom implements io.ReaderFrom. It reads a tree in the serialized form of MarshalBinary from
+// r, replacing w. If r is not an io.ByteReader, it is buffered and may be read beyond the end of
+// the tree. The nodes are stored with DefaultBackend, as by UnmarshalBinary.
+func (w *Int64Keys) ReadFrom(r io.Reader) (int64, error) {
+	return w.ReadFromWithOptions(r, nil)
+}
+
+// ReadFromWithOptions is like ReadFrom, but stores the nodes and configures w as selected by opts,
+// as UnmarshalBinaryWithOptions does.
+func (w *Int64Keys) ReadFromWithOptions(r io.Reader, opts *Options) (int64, error) {
+	cr := &countingReader{r: byteReaderOf(r)}
+	v, err := decode(cr, opts)
+	if err != nil {
+		return cr.n, err
+	}
+	*w = *v
+	return cr.n, nil
+}
+
+// WriteTo implements io.WriterTo. It writes the same serialized form as MarshalBinary, streaming
+// it to out instead of building it in memory.
+func (w *Bytes) WriteTo(out io.Writer) (int64, error) {
+	cw := &countingWriter{w: out}
+	err := w.encode(cw)
+	return cw.n, err
+}
+
+// ReadFrom implements io.ReaderFrom. It reads a tree in the serialized form of MarshalBinary from
+// r, replacing w. If r is not an io.ByteReader, it is buffered and may be read beyond the end of
+// the tree. The nodes are stored with DefaultBackend, as by UnmarshalBinary.
+func (w *Bytes) ReadFrom(r io.Reader) (int64, error) {
+	return w.ReadFromWithOptions(r, nil)
+}
+
+// ReadFromWithOptions is like Int64Keys.ReadFromWithOptions.
+func (w *Bytes) ReadFromWithOptions(r io.Reader, opts *Options) (int64, error) {
+	cr := &countingReader{r: byteReaderOf(r)}
+	v, err := decodeBytes(cr, opts)
+	if err != nil {
+		return cr.n, err
+	}
+	*w = *v
+	return cr.n, nil
+}
+
+// GobEncode implements gob.GobEncoder with the serialized form of MarshalBinary.
+func (w *Int64Keys) GobEncode() ([]byte, error) {
+	return w.MarshalBinary()
+}
+
+// GobDecode implements gob.GobDecoder.
+func (w *Int64Keys) GobDecode(data []byte) error {
+	return w.UnmarshalBinary(data)
+}
+
+// GobEncode implements gob.GobEncoder with the serialized form of MarshalBinary.
+func (w *Bytes) GobEncode() ([]byte, error) {
+	return w.MarshalBinary()
+}
+
+// GobDecode implements gob.GobDecoder.
+func (w *Bytes) GobDecode(data []byte) error {
+	return w.UnmarshalBinary(data)
+}
+
+func (w *Int64Keys) encode(out io.Writer) error {
+	codes := make([]string, len(w.keyset))
+	for i := range w.keyset {
+		codes[i] = w.codes[i].String()
+	}
+	return encode(out, w.keyset, w.counts, codes, func(k int64) []rankSelect {
+		i, _ := w.find(k)
+		return w.nodes[i]
+	})
+}
+
+func (w *Bytes) encode(out io.Writer) error {
+	keyset := make([]int64, len(w.keyset))
+	codes := make([]string, len(w.keyset))
+	for i, c := range w.keyset {
+		keyset[i] = int64(c)
+		codes[i] = w.codes[c].String()
+	}
+	return encode(out, keyset, w.counts, codes, func(k int64) []rankSelect {
+		return w.nodes[k]
+	})
+}
+
+// encode writes the tree of the keys in keyset with their counts and codes to out. path returns
+// the BitVectors along the code of a key.
+func encode(out io.Writer, keyset []int64, counts []int, codes []string,
+	path func(k int64) []rankSelect) error {
+	var buf [binary.MaxVarintLen64]byte
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	bw := bufio.NewWriter(out)
+	bw.WriteString(magic)
+	bw.Write(buf[:binary.PutUvarint(buf[:], version)])
+	bw.Write(buf[:binary.PutUvarint(buf[:], uint64(total))])
+
+	// The payload is written through pw, which also feeds the checksum.
+	crc := crc32.New(crcTable)
+	pw := bufio.NewWriter(io.MultiWriter(bw, crc))
+	putUvarint := func(x uint64) {
+		pw.Write(buf[:binary.PutUvarint(buf[:], x)])
+	}
+	putUvarint(uint64(len(keyset)))
+	bvs := make(map[string]rankSelect)
+	for i, k := range keyset {
+		pw.Write(buf[:binary.PutVarint(buf[:], k)])
+		putUvarint(uint64(counts[i]))
+		putUvarint(uint64(len(codes[i])))
+		pw.WriteString(codes[i])
+		for j, bv := range path(k) {
+			bvs[codes[i][:j]] = bv
+		}
+	}
+
+	sizes := nodeSizes(counts, codes)
+	for _, prefix := range prefixes(sizes) {
+		writeBits(pw, bvs[prefix], sizes[prefix])
+	}
+	if err := pw.Flush(); err != nil {
+		return err
+	}
+
+	binary.LittleEndian.PutUint32(buf[:4], crc.Sum32())
+	bw.Write(buf[:4])
+	return bw.Flush()
+}
+
+// writeBits writes the first n bits of bv to w, 8 per byte, LSB first. Bits stored packed are
+// copied from their bytes, shifted if they do not start a byte, and others are first unpacked from
+// the positions of their ones.
+func writeBits(w *bufio.Writer, bv rankSelect, n int) {
+	data, off, ok := packedData(bv)
+	if !ok {
+		data, off = make([]byte, byteLen(n)), 0
+		for r, ones := 0, bv.Rank1(n); r < ones; r++ {
+			i := bv.Select1(r)
+			data[i/8] |= 1 << uint(i%8)
+		}
+	}
+	data, shift := data[off/8:], uint(off%8)
+	m := byteLen(n)
+	if m == 0 {
+		return
+	}
+	if shift == 0 {
+		w.Write(data[:m-1])
+	} else {
+		for k := 0; k < m-1; k++ {
+			w.WriteByte(data[k]>>shift | data[k+1]<<(8-shift))
+		}
+	}
+	// The last byte may hold bits beyond n, from the next node of the level.
+	last := data[m-1] >> shift
+	if shift > 0 && m < len(data) {
+		last |= data[m] << (8 - shift)
+	}
+	if rest := n - 8*(m-1); rest < 8 {
+		last &= 1<<uint(rest) - 1
+	}
+	w.WriteByte(last)
+}
+
+// byteReader is what decode reads serialized trees from.
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// byteReaderOf returns r if it is a byteReader, or r buffered otherwise.
+func byteReaderOf(r io.Reader) byteReader {
+	if br, ok := r.(byteReader); ok {
+		return br
+	}
+	return bufio.NewReader(r)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type countingReader struct {
+	r byteReader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+// decode reads a tree written by encode from r, with the nodes stored and the queries configured
+// as selected by opts.
+func decode(r byteReader, opts *Options) (*Int64Keys, error) {
+	head, err := readMagic(r)
+	if err != nil {
+		return nil, err
+	}
+	if string(head) != magic {
+		dr, err := decompress(head, r)
+		if err != nil {
+			return nil, err
+		}
+		return decode(byteReaderOf(dr), opts)
+	}
+	length, err := decodeHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// The payload is read through cr, which also feeds the checksum.
+	cr := &crcReader{r: r, crc: crc32.New(crcTable)}
+	w, err := decodePayload(cr, length, opts)
+	if err != nil {
+		return nil, err
+	}
+	var sum [4]byte
+	if _, err := io.ReadFull(r, sum[:]); err != nil {
+		return nil, corrupt(err)
+	}
+	if binary.LittleEndian.Uint32(sum[:]) != cr.crc.Sum32() {
+		return nil, ErrChecksum
+	}
+	if length != uint64(w.n) {
+		return nil, ErrCorrupt
+	}
+	if err := w.Verify(); err != nil {
+		return nil, err
+	}
+	w.configure(opts)
+	return w, nil
+}
+
+// readMagic reads as many bytes as the magic from r.
+func readMagic(r io.Reader) ([]byte, error) {
+	head := make([]byte, len(magic))
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, corrupt(err)
+	}
+	return head, nil
+}
+
+// decodeHeader reads the rest of the header of a tree written by encode, which follows the magic,
+// from r, and returns the length of the sequence.
+func decodeHeader(r byteReader) (length uint64, err error) {
+	v, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, corrupt(err)
+	}
+	if v != version {
+		return 0, ErrVersion
+	}
+	length, err = binary.ReadUvarint(r)
+	if err != nil {
+		return 0, corrupt(err)
+	}
+	return length, nil
+}
+
+// decodePayload reads the payload of a tree of length elements written by encode from r, with the
+// nodes stored as selected by opts.
+func decodePayload(r byteReader, length uint64, opts *Options) (*Int64Keys, error) {
+	keyset, counts, codes, err := decodeKeys(r, length)
+	if err != nil {
+		return nil, err
+	}
+
+	// The bits are read, in chunks as they arrive, before the BitVectors are allocated, so that
+	// counts larger than the input can back fail without allocating for them.
+	sizes := nodeSizes(counts, codes)
+	ps := prefixes(sizes)
+	bits := make([][]byte, len(ps))
+	for j, prefix := range ps {
+		if bits[j], err = readFull(r, byteLen(sizes[prefix])); err != nil {
+			return nil, corrupt(err)
+		}
+	}
+	_, b, _ := opts.nodeBuilder(sizes, counts, codes)
+	for j, prefix := range ps {
+		for i := 0; i < sizes[prefix]; i++ {
+			if bits[j][i/8]&(1<<uint(i%8)) != 0 {
+				b.set(prefix, i)
+			}
+		}
+		bits[j] = nil
+	}
+
+	return assemble(keyset, counts, codes, b.build(), sizes), nil
+}
+
+// byteLen returns the number of bytes that hold n bits.
+func byteLen(n int) int {
+	return n/8 + (n%8+7)/8
+}
+
+// decodeKeys reads the keys with their counts and codes, which start the payload written by
+// encode, from r. The counts must sum to length.
+func decodeKeys(r byteReader, length uint64) (keyset []int64, counts []int, codes []string, err error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, nil, nil, corrupt(err)
+	}
+
+	total := 0
+	for i := uint64(0); i < size; i++ {
+		k, err := binary.ReadVarint(r)
+		if err != nil {
+			return nil, nil, nil, corrupt(err)
+		}
+		if i > 0 && k <= keyset[i-1] {
+			return nil, nil, nil, ErrCorrupt
+		}
+		count, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, nil, nil, corrupt(err)
+		}
+		if count > length-uint64(total) || count > uint64(maxLen-total) {
+			return nil, nil, nil, ErrCorrupt
+		}
+		total += int(count)
+		codeLen, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, nil, nil, corrupt(err)
+		}
+		if codeLen > size {
+			return nil, nil, nil, ErrCorrupt
+		}
+		code, err := readFull(r, int(codeLen))
+		if err != nil {
+			return nil, nil, nil, corrupt(err)
+		}
+		keyset = append(keyset, k)
+		counts = append(counts, int(count))
+		codes = append(codes, string(code))
+	}
+	if uint64(total) != length {
+		return nil, nil, nil, ErrCorrupt
+	}
+	if err := checkCodes(codes); err != nil {
+		return nil, nil, nil, err
+	}
+	return keyset, counts, codes, nil
+}
+
+// crcReader is a byteReader that feeds everything read through it to crc.
+type crcReader struct {
+	r   byteReader
+	crc hash.Hash32
+	buf [1]byte
+}
+
+func (c *crcReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.crc.Write(p[:n])
+	return n, err
+}
+
+func (c *crcReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.buf[0] = b
+		c.crc.Write(c.buf[:])
+	}
+	return b, err
+}
+
+// decodeBytes is like decode for a tree whose keys must all be bytes.
+func decodeBytes(r byteReader, opts *Options) (*Bytes, error) {
+	w, err := decode(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	return asBytes(w)
+}
+
+// checkCodes returns an error unless codes are the codes of the leaves of a full binary tree,
+// that is, distinct binary strings of at most maxCodeBits bits none of which is a prefix of
+// another, and whose proper prefixes all have both possible extensions.
+func checkCodes(codes []string) error {
+	leaves := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) > maxCodeBits {
+			return ErrCorrupt
+		}
+		for i := range code {
+			if code[i] != '0' && code[i] != '1' {
+				return ErrCorrupt
+			}
+		}
+		if leaves[code] {
+			return ErrCorrupt
+		}
+		leaves[code] = true
+	}
+	internal := make(map[string]bool)
+	for _, code := range codes {
+		for j := range code {
+			if leaves[code[:j]] {
+				return ErrCorrupt
+			}
+			internal[code[:j]] = true
+		}
+	}
+	for prefix := range internal {
+		for _, b := range []string{"0", "1"} {
+			if !leaves[prefix+b] && !internal[prefix+b] {
+				return ErrCorrupt
+			}
+		}
+	}
+	return nil
+}
+
+// prefixes returns the code prefixes of the nodes in sizes in lexicographic order, which is the
+// preorder of the tree.
+func prefixes(sizes map[string]int) []string {
+	var ps []string
+	for prefix := range sizes {
+		ps = append(ps, prefix)
+	}
+	sort.Strings(ps)
+	return ps
+}
+
+// readFull reads n bytes from r. The buffer grows as the data arrives, so that a corrupt length
+// cannot make it allocate much more memory than r actually holds.
+func readFull(r io.Reader, n int) ([]byte, error) {
+	const chunk = 1 << 20
+	var buf []byte
+	for len(buf) < n {
+		m := n - len(buf)
+		if m > chunk {
+			m = chunk
+		}
+		buf = append(buf, make([]byte, m)...)
+		if _, err := io.ReadFull(r, buf[len(buf)-m:]); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// corrupt turns the error of a read that ended early into ErrCorrupt.
+func corrupt(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrCorrupt
+	}
+	return err
+}
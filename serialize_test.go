@@ -0,0 +1,254 @@
+package wltree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"math"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+)
+
+func TestMarshalBinary(t *testing.T) {
+	for size := 0; size < 64; size++ {
+		for _, ws := range weights {
+			bs := random(size, ws)
+
+			wt := NewBytes(bs)
+			data, err := wt.MarshalBinary()
+			if err != nil {
+				t.Fatalf("Bytes: %q.MarshalBinary() => %v", bs, err)
+			}
+			var got Bytes
+			if err := got.UnmarshalBinary(data); err != nil {
+				t.Errorf("Bytes: %q.UnmarshalBinary() => %v", bs, err)
+			} else if !got.Equal(wt) {
+				t.Errorf("Bytes: %q round trip => trees differ", bs)
+			}
+
+			wti := NewInt64Keys(byteSlice(bs))
+			data, err = wti.MarshalBinary()
+			if err != nil {
+				t.Fatalf("IntKeys: %q.MarshalBinary() => %v", bs, err)
+			}
+			var goti Int64Keys
+			if err := goti.UnmarshalBinary(data); err != nil {
+				t.Errorf("IntKeys: %q.UnmarshalBinary() => %v", bs, err)
+			} else if !goti.Equal(wti) {
+				t.Errorf("IntKeys: %q round trip => trees differ", bs)
+			}
+		}
+	}
+}
+
+func TestMarshalBinaryBackends(t *testing.T) {
+	// The serialized form does not depend on how the nodes are stored.
+	for _, opts := range []*Options{
+		{RunLength: true}, {Sparse: true}, {LazySelect: true}, {Arena: true},
+		{Backend: func(size int) BitVectorBuilder { return make(boolBits, size) }},
+	} {
+		for _, ws := range weights {
+			bs := random(rand.Intn(maxSize), ws)
+			want, err := NewBytes(bs).MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := NewBytesWithOptions(bs, opts).MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%+v: %q.MarshalBinary() => got %v, want %v", opts, bs, got, want)
+			}
+		}
+	}
+}
+
+func TestUnmarshalBinaryWithOptions(t *testing.T) {
+	bs := random(1<<14, weights[0])
+	for _, opts := range []*Options{{Backend: RRR}, {Sparse: true}, {RunLength: true, ErrorMode: ReturnNotFound}} {
+		want := NewBytesWithOptions(bs, opts)
+		data, err := want.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Bytes
+		if err := got.UnmarshalBinaryWithOptions(data, opts); err != nil {
+			t.Fatalf("%+v: UnmarshalBinaryWithOptions() => %v", opts, err)
+		}
+		if !got.Equal(want) || got.errorMode != want.errorMode {
+			t.Errorf("%+v: UnmarshalBinaryWithOptions() => differs from the tree", opts)
+		}
+		if got.SizeInBytes() != want.SizeInBytes() {
+			t.Errorf("%+v: UnmarshalBinaryWithOptions().SizeInBytes() => %v, want %v", opts, got.SizeInBytes(), want.SizeInBytes())
+		}
+		var gotInts Int64Keys
+		if _, err := gotInts.ReadFromWithOptions(iotest.OneByteReader(bytes.NewReader(data)), opts); err != nil {
+			t.Fatalf("%+v: ReadFromWithOptions() => %v", opts, err)
+		}
+		if wantInts := NewInt64KeysWithOptions(byteSlice(bs), opts); gotInts.SizeInBytes() != wantInts.SizeInBytes() {
+			t.Errorf("%+v: ReadFromWithOptions().SizeInBytes() => %v, want %v", opts, gotInts.SizeInBytes(), wantInts.SizeInBytes())
+		}
+	}
+}
+
+func TestUnmarshalBinaryCorrupt(t *testing.T) {
+	data, err := NewInts([]int{-3, 5, 5, 1 << 30, 7, 5, -3}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(data); i++ {
+		var w Int64Keys
+		if err := w.UnmarshalBinary(data[:i]); err == nil {
+			t.Errorf("UnmarshalBinary(%v of %v bytes) => got nil error", i, len(data))
+		}
+	}
+
+	var w Bytes
+	if err := w.UnmarshalBinary(data); err == nil {
+		t.Errorf("Bytes: UnmarshalBinary(non-byte keys) => got nil error")
+	}
+
+	// Random corruption must be rejected or yield a valid tree, but never panic.
+	for i := 0; i < 1000; i++ {
+		corrupted := append([]byte(nil), data...)
+		corrupted[rand.Intn(len(data))] ^= byte(1 << uint(rand.Intn(8)))
+		var w Int64Keys
+		if err := w.UnmarshalBinary(corrupted); err == nil {
+			if err := w.Verify(); err != nil {
+				t.Errorf("UnmarshalBinary(%v) => invalid tree: %v", corrupted, err)
+			}
+		}
+	}
+}
+
+func TestUnmarshalBinaryOversized(t *testing.T) {
+	// A tree that declares 1<<50 elements over two keys in a few bytes, with and without a length
+	// header that agrees, must be rejected before the bits of its root are allocated.
+	for _, length := range []uint64{1 << 50, 1<<50 - 1, 7} {
+		data := append([]byte(magic), version)
+		data = binary.AppendUvarint(data, length)
+		data = binary.AppendUvarint(data, 2)
+		for k, code := range []string{"0", "1"} {
+			data = binary.AppendVarint(data, int64(k))
+			data = binary.AppendUvarint(data, 1<<49)
+			data = binary.AppendUvarint(data, 1)
+			data = append(data, code...)
+		}
+		data = append(data, 0xff, 0, 0, 0, 0)
+
+		var w Int64Keys
+		if err := w.UnmarshalBinary(data); err != ErrCorrupt {
+			t.Errorf("UnmarshalBinary(%v) => got error %v, want %v", data, err, ErrCorrupt)
+		}
+		if _, err := w.ReadFrom(iotest.OneByteReader(bytes.NewReader(data))); err != ErrCorrupt {
+			t.Errorf("ReadFrom(%v) => got error %v, want %v", data, err, ErrCorrupt)
+		}
+		if _, err := LoadFromBytes(data); err != ErrCorrupt {
+			t.Errorf("LoadFromBytes(%v) => got error %v, want %v", data, err, ErrCorrupt)
+		}
+	}
+}
+
+func TestWriteToReadFrom(t *testing.T) {
+	var buf bytes.Buffer
+	trees := []*Bytes{NewBytes([]byte("abracadabra")), NewBytes(nil), NewBytes([]byte("xxx"))}
+	var sizes []int64
+	for _, w := range trees {
+		n, err := w.WriteTo(&buf)
+		if err != nil {
+			t.Fatalf("WriteTo() => %v", err)
+		}
+		data, _ := w.MarshalBinary()
+		if n != int64(len(data)) {
+			t.Errorf("WriteTo() => wrote %v bytes, want %v", n, len(data))
+		}
+		sizes = append(sizes, n)
+	}
+
+	// bytes.Buffer is an io.ByteReader, so the trees can be read back one after another.
+	for i, want := range trees {
+		var got Bytes
+		n, err := got.ReadFrom(&buf)
+		if err != nil {
+			t.Fatalf("ReadFrom() => %v", err)
+		}
+		if n != sizes[i] {
+			t.Errorf("ReadFrom() => read %v bytes, want %v", n, sizes[i])
+		}
+		if !got.Equal(want) {
+			t.Errorf("ReadFrom() => tree %v differs", i)
+		}
+	}
+
+	wi := NewInts([]int{5, -1, 5, math.MaxInt})
+	buf.Reset()
+	if _, err := wi.WriteTo(&buf); err != nil {
+		t.Fatalf("IntKeys: WriteTo() => %v", err)
+	}
+	var got Int64Keys
+	if _, err := got.ReadFrom(iotest.OneByteReader(&buf)); err != nil {
+		t.Fatalf("IntKeys: ReadFrom() => %v", err)
+	}
+	if !got.Equal(wi) {
+		t.Errorf("IntKeys: ReadFrom() => tree differs")
+	}
+}
+
+func TestGob(t *testing.T) {
+	type snapshot struct {
+		Name  string
+		Text  *Bytes
+		Words *Int64Keys
+	}
+	want := snapshot{
+		Name:  "doc",
+		Text:  NewBytes([]byte("abracadabra")),
+		Words: NewInts([]int{3, 1, 4, 1, 5, 9, 2, 6}),
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(want); err != nil {
+		t.Fatalf("Encode() => %v", err)
+	}
+	var got snapshot
+	if err := gob.NewDecoder(&buf).Decode(&got); err != nil {
+		t.Fatalf("Decode() => %v", err)
+	}
+	if got.Name != want.Name || !got.Text.Equal(want.Text) || !got.Words.Equal(want.Words) {
+		t.Errorf("gob round trip => got %+v, want %+v", got, want)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	data, err := NewBytes([]byte("abracadabra")).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data[:4]); got != "wltr" {
+		t.Errorf("MarshalBinary() => magic %q, want %q", got, "wltr")
+	}
+	if data[4] != 1 || data[5] != 11 {
+		t.Errorf("MarshalBinary() => version %v and length %v, want 1 and 11", data[4], data[5])
+	}
+
+	for _, tc := range []struct {
+		name  string
+		patch func(b []byte)
+		want  error
+	}{
+		{"magic", func(b []byte) { b[0] = 'W' }, ErrCorrupt},
+		{"version", func(b []byte) { b[4] = 2 }, ErrVersion},
+		{"length", func(b []byte) { b[5] = 12 }, ErrCorrupt},
+		{"payload", func(b []byte) { b[len(b)-5] ^= 0x10 }, ErrChecksum},
+		{"checksum", func(b []byte) { b[len(b)-1] ^= 0x01 }, ErrChecksum},
+	} {
+		b := append([]byte(nil), data...)
+		tc.patch(b)
+		var w Bytes
+		if err := w.UnmarshalBinary(b); err != tc.want {
+			t.Errorf("UnmarshalBinary(bad %v) => got error %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Package server serves queries on Wavelet Trees of bytestrings as JSON over HTTP, so that indexes
+can be put behind a service without writing the routing and marshaling each time.
+
+A Handler serves the indexes added to it by name at the following routes, where a character c is
+either a single byte or its value as a number, such as 10 or 0x0a:
+
+	GET /{name}                  {"len": Len()}
+	GET /{name}/rank?c=&i=       {"result": Rank(c, i)}
+	GET /{name}/select?c=&r=     {"result": Select(c, r)}, or -1 if there is no such occurrence
+	GET /{name}/access?i=        {"result": Access(i)}
+	GET /{name}/extract?l=&r=    {"result": Extract(l, r)}, encoded in base64
+
+Errors are reported as {"error": message}, with the status 404 for an unknown index, 400 for
+bad parameters, and 413 for an extract of more than Handler.MaxExtract bytes once clamped to the
+sequence.
+
+Example
+
+	h := server.NewHandler()
+	h.Add("text", wltree.NewBytes([]byte("abracadabra")))
+	http.ListenAndServe(":8080", h)
+	// GET /text/rank?c=a&i=8 => {"result":4}
+*/
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/mozu0/wltree"
+	"github.com/mozu0/wltree/internal/char"
+)
+
+// Handler is an http.Handler serving queries on named indexes. It is safe for concurrent use,
+// including adding and removing indexes while serving.
+type Handler struct {
+	// MaxExtract is the most bytes that an extract returns; longer ones are refused. It is
+	// DefaultMaxExtract for a new Handler, and must not be changed while serving.
+	MaxExtract int
+
+	mux     *http.ServeMux
+	mu      sync.RWMutex
+	indexes map[string]*wltree.Bytes
+}
+
+// DefaultMaxExtract is the default of Handler.MaxExtract.
+const DefaultMaxExtract = 1 << 20
+
+// NewHandler returns a Handler with no indexes.
+func NewHandler() *Handler {
+	h := &Handler{
+		MaxExtract: DefaultMaxExtract,
+		mux:        http.NewServeMux(),
+		indexes:    make(map[string]*wltree.Bytes),
+	}
+	h.handle("GET /{name}", func(wt *wltree.Bytes, q *query) (string, any) {
+		return "len", wt.Len()
+	})
+	h.handle("GET /{name}/rank", func(wt *wltree.Bytes, q *query) (string, any) {
+		c, i := q.char("c"), q.int("i")
+		return "result", wt.Rank(c, i)
+	})
+	h.handle("GET /{name}/select", func(wt *wltree.Bytes, q *query) (string, any) {
+		c, r := q.char("c"), q.int("r")
+		i, ok := wt.SelectChecked(c, r)
+		if !ok {
+			i = -1
+		}
+		return "result", i
+	})
+	h.handle("GET /{name}/access", func(wt *wltree.Bytes, q *query) (string, any) {
+		i := q.int("i")
+		if q.err == nil && (i < 0 || i >= wt.Len()) {
+			q.fail("index %v out of range [0, %v)", i, wt.Len())
+		}
+		if q.err != nil {
+			return "", nil
+		}
+		return "result", wt.Access(i)
+	})
+	h.handle("GET /{name}/extract", func(wt *wltree.Bytes, q *query) (string, any) {
+		l, r := q.int("l"), q.int("r")
+		if q.err != nil {
+			return "", nil
+		}
+		// Clamp the range as Extract does, so that only the bytes it would return count.
+		l, r = min(max(l, 0), wt.Len()), min(max(r, 0), wt.Len())
+		if r-l > h.MaxExtract {
+			q.failStatus(http.StatusRequestEntityTooLarge, "extract of %v bytes exceeds the limit of %v",
+				r-l, h.MaxExtract)
+			return "", nil
+		}
+		return "result", wt.Extract(l, r)
+	})
+	return h
+}
+
+// Add serves wt under name, replacing any index of the same name.
+func (h *Handler) Add(name string, wt *wltree.Bytes) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.indexes[name] = wt
+}
+
+// Remove stops serving the index under name.
+func (h *Handler) Remove(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.indexes, name)
+}
+
+// ServeHTTP serves a query.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// handle routes pattern to f, which answers a query on the named index with a key and a value of
+// the response, unless it records an error in q.
+func (h *Handler) handle(pattern string, f func(wt *wltree.Bytes, q *query) (string, any)) {
+	h.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		h.mu.RLock()
+		wt := h.indexes[name]
+		h.mu.RUnlock()
+		if wt == nil {
+			reply(w, http.StatusNotFound, "error", fmt.Sprintf("unknown index %q", name))
+			return
+		}
+		q := &query{r: r}
+		key, value := f(wt, q)
+		if q.err != nil {
+			reply(w, q.status, "error", q.err.Error())
+			return
+		}
+		reply(w, http.StatusOK, key, value)
+	})
+}
+
+// reply writes the JSON object {key: value} with the status.
+func reply(w http.ResponseWriter, status int, key string, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{key: value})
+}
+
+// query parses the parameters of a request, recording the first error and its status.
+type query struct {
+	r      *http.Request
+	err    error
+	status int
+}
+
+// fail records an error of bad parameters unless one is recorded.
+func (q *query) fail(format string, args ...any) {
+	q.failStatus(http.StatusBadRequest, format, args...)
+}
+
+// failStatus records an error with the status unless one is recorded.
+func (q *query) failStatus(status int, format string, args ...any) {
+	if q.err == nil {
+		q.err, q.status = fmt.Errorf(format, args...), status
+	}
+}
+
+// int parses the parameter key as an int.
+func (q *query) int(key string) int {
+	v := q.r.URL.Query().Get(key)
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		q.fail("invalid parameter %v=%q", key, v)
+	}
+	return i
+}
+
+// char parses the parameter key as a character given as a single byte or as its value.
+func (q *query) char(key string) byte {
+	v := q.r.URL.Query().Get(key)
+	c, ok := char.Parse(v)
+	if !ok {
+		q.fail("invalid parameter %v=%q", key, v)
+	}
+	return c
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/mozu0/wltree"
+)
+
+func TestHandler(t *testing.T) {
+	h := NewHandler()
+	h.Add("text", wltree.NewBytes([]byte("abracadabra")))
+	h.Add("gone", wltree.NewBytes([]byte("x")))
+	h.Remove("gone")
+	for _, tc := range []struct {
+		path   string
+		status int
+		want   map[string]any
+	}{
+		{"/text", 200, map[string]any{"len": 11.0}},
+		{"/text/rank?c=a&i=8", 200, map[string]any{"result": 4.0}},
+		{"/text/rank?c=0x61&i=100", 200, map[string]any{"result": 5.0}},
+		{"/text/select?c=a&r=2", 200, map[string]any{"result": 5.0}},
+		{"/text/select?c=z&r=0", 200, map[string]any{"result": -1.0}},
+		{"/text/access?i=1", 200, map[string]any{"result": 98.0}},
+		{"/text/extract?l=3&r=8", 200, map[string]any{"result": "YWNhZGE="}},
+		{"/text/access?i=11", 400, map[string]any{"error": "index 11 out of range [0, 11)"}},
+		{"/text/rank?c=ab&i=1", 400, map[string]any{"error": `invalid parameter c="ab"`}},
+		{"/text/rank?c=a", 400, map[string]any{"error": `invalid parameter i=""`}},
+		{"/gone/rank?c=a&i=1", 404, map[string]any{"error": `unknown index "gone"`}},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		var got map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Errorf("GET %v => %v", tc.path, err)
+			continue
+		}
+		if rec.Code != tc.status || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("GET %v => got %v %v, want %v %v", tc.path, rec.Code, got, tc.status, tc.want)
+		}
+	}
+
+	h.MaxExtract = 5
+	for _, tc := range []struct {
+		path   string
+		status int
+	}{
+		{"/text/extract?l=3&r=8", 200},
+		{"/text/extract?l=-100&r=5", 200},
+		{"/text/extract?l=6&r=1000", 200},
+		{"/text/extract?l=0&r=6", 413},
+		{"/text/extract?l=-1&r=1000", 413},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if rec.Code != tc.status {
+			t.Errorf("GET %v with MaxExtract 5 => got %v, want %v", tc.path, rec.Code, tc.status)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/text/rank?c=a&i=1", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST => got %v, want %v", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,204 @@
+package wltree
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// Shape selects how the codes of the keys, and thus the shape of the tree, are chosen.
+type Shape int
+
+const (
+	// HuffmanShape gives frequent keys short codes, which minimizes the size of the tree and the
+	// average query depth. The codes are canonical, so the same keys and counts always make the
+	// same tree. This is the default.
+	HuffmanShape Shape = iota
+	// BalancedShape gives every key a code of ceil(log2 σ) or one bit fewer for σ keys, ordered
+	// like the keys. It skips the Huffman pass and bounds the depth of every query.
+	BalancedShape
+	// AlphabeticShape gives the keys optimal alphabetic codes, ordered like the keys, as the
+	// Hu-Tucker algorithm does. It adapts to skewed frequencies nearly as well as HuffmanShape
+	// while keeping each subtree on a contiguous range of keys. Construction takes O(σ²) time.
+	AlphabeticShape
+)
+
+// codes returns the codes of the keys with counts, in ascending order of the keys. Huffman codes
+// are limited to maxLen bits if it is positive, and all codes to maxCodeBits.
+func (s Shape) codes(counts []int, maxLen int) []string {
+	switch s {
+	case BalancedShape:
+		codes := make([]string, len(counts))
+		balanced(codes, "")
+		return codes
+	case AlphabeticShape:
+		codes := alphabetic(counts)
+		for _, code := range codes {
+			if len(code) > maxCodeBits {
+				return BalancedShape.codes(counts, 0)
+			}
+		}
+		return codes
+	default:
+		if maxLen <= 0 || maxLen > maxCodeBits {
+			maxLen = maxCodeBits
+		}
+		lengths := huffmanLengths(counts)
+		longest := 0
+		for _, l := range lengths {
+			longest = max(longest, l)
+		}
+		if longest > maxLen {
+			lengths = packageMerge(counts, max(maxLen, bits.Len(uint(len(counts)-1))))
+		}
+		return canonical(lengths)
+	}
+}
+
+// canonical returns the canonical prefix codes with the given lengths: shorter codes come first,
+// codes of equal length are in the order of their keys, and each code is the successor of the
+// previous one, extended with zeros to its length.
+func canonical(lengths []int) []string {
+	order := make([]int, len(lengths))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return lengths[order[i]] < lengths[order[j]] })
+
+	result := make([]string, len(lengths))
+	var code []byte
+	for n, i := range order {
+		if n > 0 {
+			j := len(code) - 1
+			for ; code[j] == '1'; j-- {
+				code[j] = '0'
+			}
+			code[j] = '1'
+		}
+		for len(code) < lengths[i] {
+			code = append(code, '0')
+		}
+		result[i] = string(code)
+	}
+	return result
+}
+
+// packageMerge returns the lengths of the optimal prefix codes of at most maxLen bits for the
+// keys with counts, by the package-merge algorithm. maxLen must be at least ceil(log2 σ).
+func packageMerge(counts []int, maxLen int) []int {
+	// An item is a single key, or a package of two items of the list one level deeper.
+	type item struct {
+		weight int
+		key    int
+	}
+	leaves := make([]item, len(counts))
+	for i, c := range counts {
+		leaves[i] = item{c, i}
+	}
+	sort.SliceStable(leaves, func(i, j int) bool { return leaves[i].weight < leaves[j].weight })
+
+	lists := [][]item{leaves}
+	for l := 1; l < maxLen; l++ {
+		prev := lists[len(lists)-1]
+		list := make([]item, 0, len(leaves)+len(prev)/2)
+		i := 0
+		for j := 1; j < len(prev); j += 2 {
+			p := item{prev[j-1].weight + prev[j].weight, -1}
+			for ; i < len(leaves) && leaves[i].weight <= p.weight; i++ {
+				list = append(list, leaves[i])
+			}
+			list = append(list, p)
+		}
+		list = append(list, leaves[i:]...)
+		lists = append(lists, list)
+	}
+
+	// The cheapest 2σ-2 items of the last list are selected, along with the items that their
+	// packages were made of; each selected occurrence of a key lengthens its code by one bit.
+	lengths := make([]int, len(counts))
+	selected := 2*len(counts) - 2
+	for l := len(lists) - 1; l >= 0; l-- {
+		packages := 0
+		for _, x := range lists[l][:selected] {
+			if x.key < 0 {
+				packages++
+			} else {
+				lengths[x.key]++
+			}
+		}
+		selected = 2 * packages
+	}
+	return lengths
+}
+
+// balanced assigns codes starting with prefix to the keys of codes, halving them at each level.
+func balanced(codes []string, prefix string) {
+	if len(codes) == 1 {
+		codes[0] = prefix
+		return
+	}
+	m := len(codes) / 2
+	balanced(codes[:m], prefix+"0")
+	balanced(codes[m:], prefix+"1")
+}
+
+// alphabetic returns the optimal alphabetic codes for the keys with counts. The Garsia-Wachs
+// algorithm finds the depths of the leaves of an optimal tree, which are then laid out left to
+// right in key order.
+func alphabetic(counts []int) []string {
+	type item struct {
+		weight int
+		leaf   int
+		child  [2]*item
+	}
+	items := make([]*item, len(counts))
+	for i, c := range counts {
+		items[i] = &item{weight: c, leaf: i}
+	}
+	for k := 1; len(items) > 1; {
+		// Combine the leftmost pair whose left neighbor is no lighter than their right neighbor.
+		for k+1 < len(items) && items[k-1].weight > items[k+1].weight {
+			k++
+		}
+		x := &item{weight: items[k-1].weight + items[k].weight, leaf: -1, child: [2]*item{items[k-1], items[k]}}
+		items = append(items[:k-1], items[k+1:]...)
+		// Move it left past the lighter items.
+		j := k - 1
+		for j > 0 && items[j-1].weight < x.weight {
+			j--
+		}
+		items = append(items[:j], append([]*item{x}, items[j:]...)...)
+		k = max(1, j-1)
+	}
+
+	depths := make([]int, len(counts))
+	var walk func(x *item, depth int)
+	walk = func(x *item, depth int) {
+		if x.leaf >= 0 {
+			depths[x.leaf] = depth
+			return
+		}
+		walk(x.child[0], depth+1)
+		walk(x.child[1], depth+1)
+	}
+	walk(items[0], 0)
+
+	// Rebuild a tree with the same depths in key order, merging equally deep adjacent subtrees,
+	// each of which covers a range of keys.
+	type subtree struct{ depth, lo, hi int }
+	codes := make([]string, len(counts))
+	var stack []subtree
+	for i, d := range depths {
+		stack = append(stack, subtree{d, i, i + 1})
+		for len(stack) > 1 && stack[len(stack)-1].depth == stack[len(stack)-2].depth {
+			l, r := stack[len(stack)-2], stack[len(stack)-1]
+			for j := l.lo; j < l.hi; j++ {
+				codes[j] = "0" + codes[j]
+			}
+			for j := r.lo; j < r.hi; j++ {
+				codes[j] = "1" + codes[j]
+			}
+			stack = append(stack[:len(stack)-2], subtree{l.depth - 1, l.lo, r.hi})
+		}
+	}
+	return codes
+}
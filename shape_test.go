@@ -0,0 +1,190 @@
+package wltree
+
+import (
+	"bytes"
+	"math/bits"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestBalancedShape(t *testing.T) {
+	opts := &Options{Shape: BalancedShape}
+	fails := 0
+	for size := 0; size < maxSize && fails < 30; size += 5 {
+		for _, ws := range weights {
+			bs := random(size, ws)
+			wt := NewBytesWithOptions(bs, opts)
+
+			if keys, _ := wt.Symbols(); len(keys) > 1 {
+				depth := bits.Len(uint(len(keys) - 1))
+				for i, c := range keys {
+					if code := wt.codes[c].String(); len(code) > depth || len(code) < depth-1 {
+						t.Errorf("%q: code of %q = %q, want %v or %v bits", bs, c, code, depth-1, depth)
+					}
+					if i > 0 && wt.codes[keys[i-1]].String() > wt.codes[c].String() {
+						t.Errorf("%q: codes of %q and %q out of order", bs, keys[i-1], c)
+					}
+				}
+			}
+
+			var counts [256]int
+			for i := 0; i <= len(bs) && fails < 30; i++ {
+				less := 0
+				for c := 0; c < 256; c++ {
+					c := byte(c)
+					if got, want := wt.Rank(c, i), counts[c]; got != want {
+						t.Errorf("%q.Rank(%v, %v) => got %v, want %v", bs, string(c), i, got, want)
+						fails++
+					}
+					if got, want := wt.RankLessThan(c, i), less; got != want {
+						t.Errorf("%q.RankLessThan(%v, %v) => got %v, want %v", bs, string(c), i, got, want)
+						fails++
+					}
+					less += counts[c]
+				}
+				if i != len(bs) {
+					c := bs[i]
+					if got, want := wt.Select(c, counts[c]), i; got != want {
+						t.Errorf("%q.Select(%v, %v) => got %v, want %v", bs, string(c), counts[c], got, want)
+						fails++
+					}
+					counts[c]++
+				}
+			}
+		}
+	}
+}
+
+func TestAlphabeticShape(t *testing.T) {
+	for trial := 0; trial < 200; trial++ {
+		counts := make([]int, 1+rand.Intn(10))
+		for i := range counts {
+			counts[i] = rand.Intn(20)
+		}
+		codes := AlphabeticShape.codes(counts, 0)
+		cost := 0
+		for i, code := range codes {
+			if i > 0 && (codes[i-1] >= code || strings.HasPrefix(code, codes[i-1])) {
+				t.Errorf("codes(%v) => %v, not ordered and prefix-free", counts, codes)
+			}
+			cost += len(code) * counts[i]
+		}
+		if err := checkCodes(codes); err != nil {
+			t.Errorf("codes(%v) => %v: %v", counts, codes, err)
+		}
+		if want := alphabeticCost(counts); cost != want {
+			t.Errorf("codes(%v) => %v of cost %v, want cost %v", counts, codes, cost, want)
+		}
+	}
+
+	s := []byte(strings.Repeat("a", 50) + strings.Repeat("m", 3) + "bcdxyz")
+	rand.Shuffle(len(s), func(i, j int) { s[i], s[j] = s[j], s[i] })
+	wt := NewBytesWithOptions(s, &Options{Shape: AlphabeticShape})
+	for i := 0; i <= len(s); i++ {
+		for _, c := range []byte("abmnz{") {
+			want := 0
+			for _, d := range s[:i] {
+				if d < c {
+					want++
+				}
+			}
+			if got := wt.RankLessThan(c, i); got != want {
+				t.Errorf("%q.RankLessThan(%q, %v) => got %v, want %v", s, c, i, got, want)
+			}
+		}
+	}
+}
+
+// alphabeticCost returns the cost of the optimal alphabetic tree on counts by dynamic programming.
+func alphabeticCost(counts []int) int {
+	n := len(counts)
+	sum := make([]int, n+1)
+	for i, c := range counts {
+		sum[i+1] = sum[i] + c
+	}
+	cost := make([][]int, n+1)
+	for i := range cost {
+		cost[i] = make([]int, n+1)
+	}
+	for size := 2; size <= n; size++ {
+		for lo := 0; lo+size <= n; lo++ {
+			hi := lo + size
+			best := -1
+			for m := lo + 1; m < hi; m++ {
+				if c := cost[lo][m] + cost[m][hi]; best < 0 || c < best {
+					best = c
+				}
+			}
+			cost[lo][hi] = best + sum[hi] - sum[lo]
+		}
+	}
+	return cost[0][n]
+}
+
+func TestCanonical(t *testing.T) {
+	for trial := 0; trial < 200; trial++ {
+		counts := make([]int, 1+rand.Intn(30))
+		for i := range counts {
+			counts[i] = 1 + rand.Intn(100)
+		}
+		codes := HuffmanShape.codes(counts, 0)
+		for i, l := range huffmanLengths(counts) {
+			if len(codes[i]) != l {
+				t.Errorf("codes(%v)[%v] => %q, want length %v", counts, i, codes[i], l)
+			}
+		}
+		if err := checkCodes(codes); err != nil {
+			t.Errorf("codes(%v) => %v: %v", counts, codes, err)
+		}
+		for i := range codes {
+			for j := i + 1; j < len(codes); j++ {
+				if len(codes[i]) == len(codes[j]) && codes[i] > codes[j] {
+					t.Errorf("codes(%v) => %v, not canonical", counts, codes)
+				}
+			}
+		}
+	}
+
+	s := random(maxSize, weights[1])
+	a, _ := NewBytes(s).MarshalBinary()
+	b, _ := NewBytes(append([]byte(nil), s...)).MarshalBinary()
+	if !bytes.Equal(a, b) {
+		t.Errorf("MarshalBinary() of identical trees differ")
+	}
+}
+
+func TestMaxCodeLen(t *testing.T) {
+	// Fibonacci counts make the deepest Huffman trees.
+	counts := []int{1, 1}
+	for len(counts) < 30 {
+		counts = append(counts, counts[len(counts)-1]+counts[len(counts)-2])
+	}
+	for _, limit := range []int{1, 5, 8, 20, 29, 40} {
+		codes := HuffmanShape.codes(counts, limit)
+		if err := checkCodes(codes); err != nil {
+			t.Errorf("codes(limit %v) => %v: %v", limit, codes, err)
+		}
+		for _, code := range codes {
+			if want := max(limit, 5); len(code) > want {
+				t.Errorf("codes(limit %v) => %q longer than %v", limit, code, want)
+			}
+		}
+	}
+
+	s := random(maxSize, map[byte]int{'a': 1000, 'b': 100, 'c': 10, 'd': 3, 'e': 1, 'f': 1})
+	wt := NewBytesWithOptions(s, &Options{MaxCodeLen: 3})
+	var counts2 [256]int
+	for i, c := range s {
+		if wt.codes[c].len > 3 {
+			t.Errorf("code of %q => %q, longer than 3", c, wt.codes[c])
+		}
+		if got, want := wt.Select(c, counts2[c]), i; got != want {
+			t.Errorf("%q.Select(%q, %v) => got %v, want %v", s, c, counts2[c], got, want)
+		}
+		counts2[c]++
+		if got, want := wt.Rank(c, i+1), counts2[c]; got != want {
+			t.Errorf("%q.Rank(%q, %v) => got %v, want %v", s, c, i+1, got, want)
+		}
+	}
+}
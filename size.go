@@ -0,0 +1,278 @@
+package wltree
+
+import (
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// EstimateSize returns the approximate size in bytes of the Wavelet Tree that NewInt64Keys makes
+// from s whose distinct keys occur counts times, as returned by Symbols, without building it. It
+// counts the bits and directories of the levels and the nodes of the tree, but not the rounding of
+// the allocator, so it suits admission control, such as rejecting inputs that would not fit.
+func EstimateSize(counts []int) int {
+	keys := len(counts)
+	return int(unsafe.Sizeof(Int64Keys{})) +
+		keys*int(unsafe.Sizeof(int64(0))+unsafe.Sizeof(0)+unsafe.Sizeof(code{})+unsafe.Sizeof([]rankSelect(nil))) +
+		estimateNodes(counts)
+}
+
+// EstimateSizeBytes is like EstimateSize for the Wavelet Tree that NewBytes makes from s.
+func EstimateSizeBytes(s []byte) int {
+	freqs := countBytes(s, 1, progress{})
+	var counts []int
+	for _, count := range freqs {
+		if count > 0 {
+			counts = append(counts, count)
+		}
+	}
+	return int(unsafe.Sizeof(Bytes{})) + len(counts)*int(1+unsafe.Sizeof(0)) + estimateNodes(counts)
+}
+
+// estimateNodes returns the approximate size in bytes of the nodes of the tree on keys with the
+// counts, with their levels built by DefaultBackend, and of the paths of the keys through them.
+func estimateNodes(counts []int) int {
+	if len(counts) == 0 {
+		return 0
+	}
+	codes := (*Options)(nil).codes(counts)
+	sizes := levelSizes(nodeSizes(counts, codes))
+	ones := make([]int, len(sizes))
+	size := 0
+	for i, c := range codes {
+		for d := range c {
+			if c[d] == '1' {
+				ones[d] += counts[i]
+			}
+		}
+		size += len(c) * int(unsafe.Sizeof(rankSelect(nil)))
+	}
+	for d := range sizes {
+		size += levelBytes(sizes[d], ones[d])
+	}
+	// A full binary tree on the keys has one fewer internal node, each a slice of its level.
+	size += (2*len(counts) - 1) * int(unsafe.Sizeof(node{}))
+	size += (len(counts) - 1) * int(unsafe.Sizeof(levelSlice{}))
+	return size
+}
+
+// levelBytes returns the approximate size in bytes of a level of size bits, of which ones are set,
+// built by DefaultBackend: the bits, the rank samples and the select samples of ones and zeros.
+func levelBytes(size, ones int) int {
+	words := size/defaultBlockBits + 1 + ones/selectSample + 1 + (size-ones)/selectSample + 1
+	return int(unsafe.Sizeof(lazySelectBits{})+unsafe.Sizeof(packedBits{})) + (size+7)/8 +
+		words*int(unsafe.Sizeof(0))
+}
+
+// SizeInBytes returns the approximate size in bytes of w: its bits, including those mapped from a
+// file, their rank and select directories, counted as built even if they are built lazily, its
+// nodes and the rank caches of CacheRanks. BitVectors built by a Backend of another package count
+// as their bits alone, unless they have a method SizeInBytes() int.
+func (w *Int64Keys) SizeInBytes() int {
+	size := int(unsafe.Sizeof(*w)) + cap(w.keyset)*int(unsafe.Sizeof(int64(0))) +
+		cap(w.counts)*int(unsafe.Sizeof(0)) + cap(w.codes)*int(unsafe.Sizeof(code{})) +
+		cap(w.nodes)*int(unsafe.Sizeof([]rankSelect(nil))) + cap(w.hot)*int(unsafe.Sizeof(w.hot[0]))
+	for _, path := range w.nodes {
+		size += cap(path) * int(unsafe.Sizeof(rankSelect(nil)))
+	}
+	for _, h := range w.hot {
+		if h != nil {
+			size += bitsBytes(h)
+		}
+	}
+	return size + nodesBytes(w.root)
+}
+
+// SizeInBytes is like Int64Keys.SizeInBytes.
+func (w *Bytes) SizeInBytes() int {
+	size := int(unsafe.Sizeof(*w)) + cap(w.keyset) + cap(w.counts)*int(unsafe.Sizeof(0))
+	for _, path := range w.nodes {
+		size += cap(path) * int(unsafe.Sizeof(rankSelect(nil)))
+	}
+	for _, h := range w.hot {
+		if h != nil {
+			size += bitsBytes(h)
+		}
+	}
+	return size + nodesBytes(w.root)
+}
+
+// nodesBytes returns the size in bytes of the tree rooted at root, counting each level shared by
+// the nodes at a depth once.
+func nodesBytes(root *node) int {
+	size := 0
+	var levels []bool
+	var walk func(n *node, depth int)
+	walk = func(n *node, depth int) {
+		size += int(unsafe.Sizeof(*n))
+		if n.leaf() {
+			return
+		}
+		if s, ok := n.bv.(*levelSlice); ok {
+			for len(levels) <= depth {
+				levels = append(levels, false)
+			}
+			size += int(unsafe.Sizeof(*s))
+			if !levels[depth] {
+				size += bitsBytes(s.bv)
+				levels[depth] = true
+			}
+		} else {
+			size += bitsBytes(n.bv)
+		}
+		walk(n.child[0], depth+1)
+		walk(n.child[1], depth+1)
+	}
+	if root != nil {
+		walk(root, 0)
+	}
+	return size
+}
+
+// bitsBytes returns the size in bytes of the bit vector bv.
+func bitsBytes(bv rankSelect) int {
+	const word, word64 = int(unsafe.Sizeof(0)), int(unsafe.Sizeof(uint64(0)))
+	switch b := bv.(type) {
+	case *packedBits:
+		return int(unsafe.Sizeof(*b)) + cap(b.data) + cap(b.ranks)*word
+	case *lazySelectBits:
+		ones := b.Rank1(b.size)
+		dirs := ones/b.sample + 1 + (b.size-ones)/b.sample + 1
+		return int(unsafe.Sizeof(*b)) + bitsBytes(b.packedBits) + dirs*word
+	case *lazyBits:
+		return int(unsafe.Sizeof(*b)+unsafe.Sizeof(packedBits{})) + cap(b.data) + (b.size/blockBits+1)*word
+	case *rrrBits:
+		return int(unsafe.Sizeof(*b)) + cap(b.classes) + cap(b.bits)*word64 + (cap(b.ranks)+cap(b.offsets))*word
+	case *runBits:
+		return int(unsafe.Sizeof(*b)) + (cap(b.starts)+cap(b.ones))*word
+	case *eliasFano:
+		size := int(unsafe.Sizeof(*b)) + cap(b.lows)*word64
+		if b.highs != nil {
+			size += bitsBytes(b.highs)
+		}
+		return size
+	case complement:
+		return bitsBytes(b.bv)
+	case interface{ SizeInBytes() int }:
+		return b.SizeInBytes()
+	case interface{ Len() int }:
+		return (b.Len() + 7) / 8
+	}
+	return 0
+}
+
+// Stats describes the shape of a Wavelet Tree.
+type Stats struct {
+	// Nodes is the number of internal nodes, each of which holds a bit for each element below it.
+	Nodes int
+	// Bits is the total number of bits of the nodes.
+	Bits int
+	// Levels holds the number of bits of the nodes at each depth, from the root down.
+	Levels []int
+	// MaxDepth is the length of the longest code, that is, the most nodes that a query visits.
+	MaxDepth int
+}
+
+// Stats returns the shape of w.
+func (w *Int64Keys) Stats() Stats {
+	return treeStats(w.root)
+}
+
+// Stats returns the shape of w.
+func (w *Bytes) Stats() Stats {
+	return treeStats(w.root)
+}
+
+// treeStats returns the shape of the tree rooted at root.
+func treeStats(root *node) Stats {
+	var st Stats
+	var walk func(n *node, depth int)
+	walk = func(n *node, depth int) {
+		if n.leaf() {
+			st.MaxDepth = max(st.MaxDepth, depth)
+			return
+		}
+		for len(st.Levels) <= depth {
+			st.Levels = append(st.Levels, 0)
+		}
+		st.Nodes++
+		st.Bits += n.size
+		st.Levels[depth] += n.size
+		walk(n.child[0], depth+1)
+		walk(n.child[1], depth+1)
+	}
+	if root != nil {
+		walk(root, 0)
+	}
+	return st
+}
+
+// Compression reports how well a Wavelet Tree compresses its sequence, all in bits per element.
+type Compression struct {
+	// Entropy is the zero-order empirical entropy of s, the least that any code of the keys alone
+	// takes.
+	Entropy float64
+	// CodeLen is the mean length of the codes of the elements, which is the size of the bits of the
+	// nodes. For HuffmanShape it is less than Entropy+1.
+	CodeLen float64
+	// BitsPerSymbol is the size of the whole tree, as SizeInBytes.
+	BitsPerSymbol float64
+}
+
+// Compression reports how well w compresses s. It is all zeros for an empty s.
+func (w *Int64Keys) Compression() Compression {
+	return compression(w.counts, w.n, w.Stats().Bits, w.SizeInBytes())
+}
+
+// Compression is like Int64Keys.Compression.
+func (w *Bytes) Compression() Compression {
+	return compression(w.counts, w.n, w.Stats().Bits, w.SizeInBytes())
+}
+
+// compression returns the Compression of a tree on n elements, whose keys occur counts times, with
+// nodes of bits bits in all, taking size bytes.
+func compression(counts []int, n, bits, size int) Compression {
+	if n == 0 {
+		return Compression{}
+	}
+	var c Compression
+	for _, count := range counts {
+		if count > 0 {
+			p := float64(count) / float64(n)
+			c.Entropy -= p * math.Log2(p)
+		}
+	}
+	c.CodeLen = float64(bits) / float64(n)
+	c.BitsPerSymbol = float64(size) * 8 / float64(n)
+	return c
+}
+
+// String summarizes w as its length, number of keys, depth and approximate size, such as
+// "wltree.Int64Keys{Len: 11, Keys: 5, Depth: 3, Size: 1.2 KiB}", rather than its contents.
+func (w *Int64Keys) String() string {
+	return summary("Int64Keys", w.n, len(w.keyset), w.Stats().MaxDepth, w.SizeInBytes())
+}
+
+// String is like Int64Keys.String.
+func (w *Bytes) String() string {
+	return summary("Bytes", w.n, len(w.keyset), w.Stats().MaxDepth, w.SizeInBytes())
+}
+
+func summary(name string, n, keys, depth, size int) string {
+	return fmt.Sprintf("wltree.%v{Len: %v, Keys: %v, Depth: %v, Size: %v}", name, n, keys, depth, byteSize(size))
+}
+
+// byteSize formats a size in bytes with a binary unit, such as "1.2 KiB".
+func byteSize(size int) string {
+	if size < 1024 {
+		return fmt.Sprintf("%v B", size)
+	}
+	x := float64(size) / 1024
+	for _, unit := range []string{"KiB", "MiB", "GiB"} {
+		if x < 1024 {
+			return fmt.Sprintf("%.1f %v", x, unit)
+		}
+		x /= 1024
+	}
+	return fmt.Sprintf("%.1f TiB", x)
+}
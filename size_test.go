@@ -0,0 +1,149 @@
+package wltree
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// heapSize returns the size in bytes of the live heap that what build returns holds.
+func heapSize(build func() any) int {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	w := build()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(w)
+	return int(after.HeapAlloc) - int(before.HeapAlloc)
+}
+
+func TestEstimateSize(t *testing.T) {
+	for _, ws := range weights {
+		s := random(1<<20+rand.Intn(1<<20), ws)
+		_, counts := NewBytes(s).Symbols()
+
+		got, want := EstimateSizeBytes(s), heapSize(func() any { return NewBytes(s) })
+		if got < want*9/10 || got > want*11/10 {
+			t.Errorf("EstimateSizeBytes(%v bytes) => got %v, want about %v", len(s), got, want)
+		}
+
+		got, want = EstimateSize(counts), heapSize(func() any { return NewInt64Keys(byteSlice(s)) })
+		if got < want*9/10 || got > want*11/10 {
+			t.Errorf("EstimateSize(%v) => got %v, want about %v", counts, got, want)
+		}
+		// Keep s live, so that the heap sizes count the trees alone.
+		runtime.KeepAlive(s)
+	}
+	if got := EstimateSizeBytes(nil); got <= 0 {
+		t.Errorf("EstimateSizeBytes(nil) => got %v, want positive", got)
+	}
+}
+
+func TestSizeInBytes(t *testing.T) {
+	for _, ws := range weights {
+		s := random(1<<20+rand.Intn(1<<20), ws)
+		for _, opts := range []*Options{nil, {RankOnly: true}, {RunLength: true}, {Sparse: true}, {Backend: RRR}} {
+			var w *Bytes
+			want := heapSize(func() any {
+				w = NewBytesWithOptions(s, opts)
+				return w
+			})
+			if got := w.SizeInBytes(); got < want*9/10 || got > want*11/10 {
+				t.Errorf("NewBytesWithOptions(%v bytes, %+v).SizeInBytes() => got %v, want about %v", len(s), opts, got, want)
+			}
+		}
+		var w *Int64Keys
+		want := heapSize(func() any {
+			w = NewInt64Keys(byteSlice(s))
+			w.CacheRanks(int64(s[0]))
+			return w
+		})
+		if got := w.SizeInBytes(); got < want*9/10 || got > want*11/10 {
+			t.Errorf("NewInt64Keys(%v bytes).SizeInBytes() => got %v, want about %v", len(s), got, want)
+		}
+		runtime.KeepAlive(s)
+	}
+}
+
+func TestStats(t *testing.T) {
+	for _, ws := range weights {
+		s := random(rand.Intn(maxSize), ws)
+		w := NewInt64Keys(byteSlice(s))
+		var want Stats
+		for k, c := range w.codes {
+			for d := 0; d < int(c.len); d++ {
+				for len(want.Levels) <= d {
+					want.Levels = append(want.Levels, 0)
+				}
+				want.Levels[d] += w.counts[k]
+				want.Bits += w.counts[k]
+			}
+			want.MaxDepth = max(want.MaxDepth, int(c.len))
+		}
+		want.Nodes = max(len(w.keyset)-1, 0)
+		if got := w.Stats(); !reflect.DeepEqual(got, want) {
+			t.Errorf("NewInt64Keys(%q).Stats() => got %+v, want %+v", s, got, want)
+		}
+		if got := NewBytes(s).Stats(); !reflect.DeepEqual(got, want) {
+			t.Errorf("NewBytes(%q).Stats() => got %+v, want %+v", s, got, want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	s := []byte("abracadabra")
+	w, iw, empty := NewBytes(s), NewInt64Keys(byteSlice(s)), NewInts(nil)
+	for _, tc := range []struct {
+		got  string
+		want string
+	}{
+		{w.String(), "wltree.Bytes{Len: 11, Keys: 5, Depth: 3, Size: " + byteSize(w.SizeInBytes()) + "}"},
+		{fmt.Sprint(iw), "wltree.Int64Keys{Len: 11, Keys: 5, Depth: 3, Size: " + byteSize(iw.SizeInBytes()) + "}"},
+		{empty.String(), "wltree.Int64Keys{Len: 0, Keys: 0, Depth: 0, Size: " + byteSize(empty.SizeInBytes()) + "}"},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("String() => got %v, want %v", tc.got, tc.want)
+		}
+	}
+	for size, want := range map[int]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KiB", 3 << 20: "3.0 MiB", 1 << 30: "1.0 GiB"} {
+		if got := byteSize(size); got != want {
+			t.Errorf("byteSize(%v) => got %v, want %v", size, got, want)
+		}
+	}
+}
+
+func TestCompression(t *testing.T) {
+	for _, ws := range weights {
+		s := random(rand.Intn(maxSize)+1, ws)
+		w := NewBytes(s)
+		freqs := make(map[byte]float64)
+		for _, c := range s {
+			freqs[c]++
+		}
+		var want Compression
+		for c, f := range freqs {
+			p := f / float64(len(s))
+			want.Entropy -= p * math.Log2(p)
+			want.CodeLen += p * float64(w.codes[c].len)
+		}
+		want.BitsPerSymbol = float64(w.SizeInBytes()*8) / float64(len(s))
+
+		got := w.Compression()
+		if math.Abs(got.Entropy-want.Entropy) > 1e-9 || math.Abs(got.CodeLen-want.CodeLen) > 1e-9 || got.BitsPerSymbol != want.BitsPerSymbol {
+			t.Errorf("NewBytes(%q).Compression() => got %+v, want %+v", s, got, want)
+		}
+		if len(freqs) > 1 && (got.CodeLen < got.Entropy || got.CodeLen >= got.Entropy+1) {
+			t.Errorf("NewBytes(%q).Compression() => got code length %v, want within a bit above the entropy %v", s, got.CodeLen, got.Entropy)
+		}
+		if got := NewInt64Keys(byteSlice(s)).Compression(); math.Abs(got.Entropy-want.Entropy) > 1e-9 {
+			t.Errorf("NewInt64Keys(%q).Compression() => got %+v, want entropy %v", s, got, want.Entropy)
+		}
+	}
+	if got := NewBytes(nil).Compression(); got != (Compression{}) {
+		t.Errorf("NewBytes(nil).Compression() => got %+v, want zeros", got)
+	}
+}
@@ -0,0 +1,201 @@
+package wltree
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// eliasFano is a read-only bit vector stored as the Elias-Fano encoding of the positions of its
+// ones, in about 2+log2(size/ones) bits per one, which is far smaller than size bits when the
+// ones are rare.
+type eliasFano struct {
+	size, ones int
+	// Each position is split into its low width bits, stored in lows, and its high bits, stored
+	// in unary in highs: the k-th one of highs is at the high bits of the k-th position plus k.
+	width int
+	lows  bitStream
+	highs *packedBits
+}
+
+// newEliasFano returns an eliasFano of size bits whose ones are at the ascending positions.
+func newEliasFano(size int, positions []int) *eliasFano {
+	ef := &eliasFano{size: size, ones: len(positions)}
+	if len(positions) > 0 {
+		ef.width = bits.Len(uint(size/len(positions))) - 1
+		if ef.width < 0 {
+			ef.width = 0
+		}
+	}
+	nhighs := len(positions) + size>>uint(ef.width) + 1
+	highs := make([]byte, (nhighs+7)/8)
+	for k, p := range positions {
+		ef.lows.write(k*ef.width, ef.width, uint64(p)&(1<<uint(ef.width)-1))
+		h := p>>uint(ef.width) + k
+		highs[h/8] |= 1 << uint(h%8)
+	}
+	ef.highs = newPackedBits(highs, nhighs)
+	return ef
+}
+
+// efSize returns the number of bits of an eliasFano of size bits with ones ones.
+func efSize(size, ones int) int {
+	if ones == 0 {
+		return 0
+	}
+	return ones*(2+bits.Len(uint(size/ones))) + size/ones
+}
+
+// get returns the position of the k-th one.
+func (ef *eliasFano) get(k int) int {
+	h := ef.highs.Select1(k) - k
+	return h<<uint(ef.width) | int(ef.lows.read(k*ef.width, ef.width))
+}
+
+func (ef *eliasFano) Len() int {
+	return ef.size
+}
+
+func (ef *eliasFano) Rank1(i int) int {
+	if i >= ef.size {
+		return ef.ones
+	}
+	// The ones with high bits below those of i come before the h-th zero of highs.
+	h := i >> uint(ef.width)
+	p := 0
+	if h > 0 {
+		p = ef.highs.Select0(h-1) + 1
+	}
+	k := p - h
+	for k < ef.ones && ef.get(k) < i {
+		k++
+	}
+	return k
+}
+
+func (ef *eliasFano) Rank0(i int) int {
+	return i - ef.Rank1(i)
+}
+
+func (ef *eliasFano) Select1(r int) int {
+	if r < 0 || r >= ef.ones {
+		panic("wltree: select beyond the last bit")
+	}
+	return ef.get(r)
+}
+
+func (ef *eliasFano) Select0(r int) int {
+	if r < 0 {
+		panic("wltree: select with negative rank")
+	}
+	// The r-th zero follows the ones with no more than r zeros before them.
+	k := sort.Search(ef.ones, func(k int) bool { return ef.get(k)-k > r })
+	if r+k >= ef.size {
+		panic("wltree: select beyond the last bit")
+	}
+	return r + k
+}
+
+// complement is a bit vector with the bits of bv inverted.
+type complement struct {
+	bv RankSelect
+}
+
+func (c complement) Len() int {
+	return c.bv.Len()
+}
+
+func (c complement) Rank1(i int) int {
+	return c.bv.Rank0(i)
+}
+
+func (c complement) Rank0(i int) int {
+	return c.bv.Rank1(i)
+}
+
+func (c complement) Select1(r int) int {
+	return c.bv.Select0(r)
+}
+
+func (c complement) Select0(r int) int {
+	return c.bv.Select1(r)
+}
+
+// sparseBuilder builds the nodes of a wavelet tree whose ones or zeros are rare as eliasFano,
+// and lays out the others level by level.
+type sparseBuilder struct {
+	levels *levelBuilder
+	sparse map[string]*sparseNode
+}
+
+// sparseNode collects the positions of the rare bits of a node. If flip, the rare bits are zeros.
+type sparseNode struct {
+	size      int
+	flip      bool
+	next      int
+	positions []int
+}
+
+// newSparseBuilder returns a sparseBuilder for the nodes with sizes and ones, indexed by code
+// prefix, that builds the levels of the other nodes with backend.
+func newSparseBuilder(sizes, ones map[string]int, backend Backend) *sparseBuilder {
+	b := &sparseBuilder{sparse: make(map[string]*sparseNode)}
+	dense := make(map[string]int)
+	for prefix, size := range sizes {
+		rare, flip := ones[prefix], false
+		if zeros := size - rare; zeros < rare {
+			rare, flip = zeros, true
+		}
+		if efSize(size, rare) < size {
+			b.sparse[prefix] = &sparseNode{size: size, flip: flip}
+		} else {
+			dense[prefix] = size
+		}
+	}
+	b.levels = newLevelBuilder(dense, backend)
+	return b
+}
+
+func (b *sparseBuilder) set(prefix string, i int) {
+	n, ok := b.sparse[prefix]
+	if !ok {
+		b.levels.set(prefix, i)
+		return
+	}
+	if !n.flip {
+		n.positions = append(n.positions, i)
+		return
+	}
+	for ; n.next < i; n.next++ {
+		n.positions = append(n.positions, n.next)
+	}
+	n.next = i + 1
+}
+
+func (b *sparseBuilder) build() map[string]rankSelect {
+	bvs := b.levels.build()
+	for prefix, n := range b.sparse {
+		if !n.flip {
+			bvs[prefix] = newEliasFano(n.size, n.positions)
+			continue
+		}
+		for ; n.next < n.size; n.next++ {
+			n.positions = append(n.positions, n.next)
+		}
+		bvs[prefix] = complement{newEliasFano(n.size, n.positions)}
+	}
+	return bvs
+}
+
+// nodeOnes returns the number of ones in each node of the wavelet tree, indexed by code prefix,
+// for keys with the counts and codes.
+func nodeOnes(counts []int, codes []string) map[string]int {
+	ones := make(map[string]int)
+	for i, code := range codes {
+		for j := range code {
+			if code[j] == '1' {
+				ones[code[:j]] += counts[i]
+			}
+		}
+	}
+	return ones
+}
@@ -0,0 +1,76 @@
+package wltree
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestEliasFano(t *testing.T) {
+	fails := 0
+	for _, size := range []int{0, 1, 2, 7, 64, 100, 1000, 5000} {
+		for _, density := range []float64{0, 0.001, 0.05, 0.5, 1} {
+			want := make(boolBits, size)
+			var positions []int
+			for i := range want {
+				if rand.Float64() < density {
+					want[i] = true
+					positions = append(positions, i)
+				}
+			}
+			for _, got := range []RankSelect{newEliasFano(size, positions), complement{complement{newEliasFano(size, positions)}}} {
+				ones := 0
+				for i := 0; i <= size && fails < 30; i++ {
+					if r := got.Rank1(i); r != ones {
+						t.Errorf("EF(%v, %v).Rank1(%v) => got %v, want %v", size, density, i, r, ones)
+						fails++
+					}
+					if i == size {
+						break
+					}
+					if want[i] {
+						if p := got.Select1(ones); p != i {
+							t.Errorf("EF(%v, %v).Select1(%v) => got %v, want %v", size, density, ones, p, i)
+							fails++
+						}
+						ones++
+					} else if p := got.Select0(i - ones); p != i {
+						t.Errorf("EF(%v, %v).Select0(%v) => got %v, want %v", size, density, i-ones, p, i)
+						fails++
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSparse(t *testing.T) {
+	s := []byte(strings.Repeat("a", 2000) + strings.Repeat("b", 500) + strings.Repeat("c", 20) + "dddefg")
+	rand.Shuffle(len(s), func(i, j int) { s[i], s[j] = s[j], s[i] })
+	wt := NewBytesWithOptions(s, &Options{Sparse: true})
+	if !wt.Equal(NewBytes(s)) {
+		t.Errorf("NewBytesWithOptions(Sparse) => tree differs from plain tree")
+	}
+	sparse := 0
+	for _, path := range wt.nodes {
+		for _, bv := range path {
+			switch bv.(type) {
+			case *eliasFano, complement:
+				sparse++
+			}
+		}
+	}
+	if sparse == 0 {
+		t.Errorf("NewBytesWithOptions(Sparse) => no sparse nodes in skewed tree")
+	}
+	var counts [256]int
+	for i, c := range s {
+		if got, want := wt.Select(c, counts[c]), i; got != want {
+			t.Errorf("Select(%q, %v) => got %v, want %v", c, counts[c], got, want)
+		}
+		counts[c]++
+		if got, want := wt.Rank(c, i+1), counts[c]; got != want {
+			t.Errorf("Rank(%q, %v) => got %v, want %v", c, i+1, got, want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package wltree
+
+import "sort"
+
+// SubTree returns a Wavelet Tree on s[l:r], with l and r clamped as by the range queries. It
+// copies the bits of each node of w in the range, found by Select on the ones among them, instead
+// of decoding and indexing the elements again, so that the time is proportional to the ones. The
+// tree keeps the codes of w, so it knows all the keys of w, with zero occurrences for those not in
+// s[l:r], and it is built with the options of w and reports errors like w.
+func (w *Int64Keys) SubTree(l, r int) *Int64Keys {
+	l, r = clampRange(l, r, w.n)
+	sub := subTree(append([]int64(nil), w.keyset...), w.codes, w.opts, w.treeRange(l, r))
+	sub.errorMode, sub.opts = w.errorMode, w.opts
+	return sub
+}
+
+// SubTree is like Int64Keys.SubTree for a Wavelet Tree on bytestring.
+func (w *Bytes) SubTree(l, r int) *Bytes {
+	return bytesFrom(w.int64Keys().SubTree(l, r))
+}
+
+// int64Keys returns w as a Wavelet Tree on int64 keys, which shares the nodes of w.
+func (w *Bytes) int64Keys() *Int64Keys {
+	t := &Int64Keys{
+		root:      w.root,
+		counts:    w.counts,
+		n:         w.n,
+		errorMode: w.errorMode,
+		opts:      w.opts,
+	}
+	for _, c := range w.keyset {
+		t.keyset = append(t.keyset, int64(c))
+		t.codes = append(t.codes, w.codes[c])
+		t.nodes = append(t.nodes, w.nodes[c])
+	}
+	return t
+}
+
+// treeRange is the range [l, r) of the elements of the tree rooted at root.
+type treeRange struct {
+	root *node
+	l, r int
+}
+
+// subTree makes the Wavelet Tree on the elements of the ranges in order, whose keys are keyset
+// with the codes, with the nodes stored as selected by opts. The trees of the ranges must give
+// each of their keys the code it has in codes, so that each node of the result is the
+// concatenation of the ranges of the nodes with the same code prefix.
+func subTree(keyset []int64, codes []code, opts *Options, ranges ...treeRange) *Int64Keys {
+	counts := make([]int, len(keyset))
+	strs := make([]string, len(keyset))
+	for i, c := range codes {
+		strs[i] = c.String()
+	}
+
+	// Map each range down to every node, where the ranges of the leaves are the counts of their
+	// keys, and place it after those of the previous ranges in the node.
+	type span struct {
+		prefix string
+		bv     rankSelect
+		lo, hi int
+		off    int
+	}
+	var spans []span
+	offsets := make(map[string]int)
+	var walk func(n *node, prefix string, lo, hi int)
+	walk = func(n *node, prefix string, lo, hi int) {
+		if n.leaf() {
+			counts[sort.Search(len(keyset), func(i int) bool { return keyset[i] >= n.key })] += hi - lo
+			return
+		}
+		spans = append(spans, span{prefix, n.bv, lo, hi, offsets[prefix]})
+		offsets[prefix] += hi - lo
+		walk(n.child[0], prefix+"0", n.bv.Rank0(lo), n.bv.Rank0(hi))
+		walk(n.child[1], prefix+"1", n.bv.Rank1(lo), n.bv.Rank1(hi))
+	}
+	for _, tr := range ranges {
+		if tr.root != nil {
+			walk(tr.root, "", tr.l, tr.r)
+		}
+	}
+
+	sizes := nodeSizes(counts, strs)
+	_, b, _ := opts.nodeBuilder(sizes, counts, strs)
+	for _, sp := range spans {
+		for k, end := sp.bv.Rank1(sp.lo), sp.bv.Rank1(sp.hi); k < end; k++ {
+			b.set(sp.prefix, sp.off+sp.bv.Select1(k)-sp.lo)
+		}
+	}
+	return assemble(keyset, counts, strs, b.build(), sizes)
+}
+
+// treeRange returns the range [l, r) of the elements of w.
+func (w *Int64Keys) treeRange(l, r int) treeRange {
+	return treeRange{w.root, l, r}
+}
@@ -0,0 +1,53 @@
+package wltree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSubTree(t *testing.T) {
+	for size := 0; size < maxSize; size += 17 {
+		for _, ws := range weights {
+			s := random(size, ws)
+			l, r := rand.Intn(len(s)+3)-1, rand.Intn(len(s)+3)-1
+			wt := NewBytes(s)
+			sub := wt.SubTree(l, r)
+			l, r = clampRange(l, r, len(s))
+			want := s[l:r]
+			if got := sub.Len(); got != len(want) {
+				t.Errorf("%q: SubTree(%v, %v).Len() => got %v, want %v", s, l, r, got, len(want))
+			}
+			for _, c := range wt.keyset {
+				count := 0
+				for i := 0; i <= len(want); i++ {
+					if got := sub.Rank(c, i); got != count {
+						t.Errorf("%q: SubTree(%v, %v).Rank(%q, %v) => got %v, want %v", s, l, r, c, i, got, count)
+					}
+					if i < len(want) && want[i] == c {
+						if got := sub.Select(c, count); got != i {
+							t.Errorf("%q: SubTree(%v, %v).Select(%q, %v) => got %v, want %v", s, l, r, c, count, got, i)
+						}
+						count++
+					}
+				}
+				if got := sub.Count(c); got != count {
+					t.Errorf("%q: SubTree(%v, %v).Count(%q) => got %v, want %v", s, l, r, c, got, count)
+				}
+			}
+			for i := range want {
+				if got := sub.Access(i); got != want[i] {
+					t.Errorf("%q: SubTree(%v, %v).Access(%v) => got %q, want %q", s, l, r, i, got, want[i])
+				}
+			}
+			isub := NewInt64Keys(byteSlice(s)).SubTree(l, r)
+			if err := isub.Verify(); err != nil {
+				t.Errorf("%q: IntKeys.SubTree(%v, %v).Verify() => %v", s, l, r, err)
+			}
+			for i := range want {
+				if got := isub.Access(i); got != int64(want[i]) {
+					t.Errorf("%q: IntKeys.SubTree(%v, %v).Access(%v) => got %v, want %v", s, l, r, i, got, want[i])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package wltree
+
+// Tokens is a Wavelet Tree on a sequence of tokens, such as the words of a text. It numbers the
+// distinct tokens in the order of their first occurrences and indexes their numbers, so that
+// tokens can be ranked and selected by their strings. Select returns -1 when the sequence has no
+// such occurrence.
+type Tokens struct {
+	w *Int64Keys
+	// ids maps each distinct token to its number, and dict each number back to the token.
+	ids  map[string]int64
+	dict []string
+}
+
+// NewTokens makes a Wavelet Tree on the sequence of tokens.
+func NewTokens(tokens []string) *Tokens {
+	t := &Tokens{ids: make(map[string]int64)}
+	keys := make([]int64, len(tokens))
+	for i, tok := range tokens {
+		id, ok := t.ids[tok]
+		if !ok {
+			id = int64(len(t.dict))
+			t.ids[tok] = id
+			t.dict = append(t.dict, tok)
+		}
+		keys[i] = id
+	}
+	t.w = NewSlice(keys, func(id int64) int64 { return id })
+	return t
+}
+
+// NewTokensFunc makes a Wavelet Tree on the tokens of text as split by tokenize, such as
+// strings.Fields.
+func NewTokensFunc(text string, tokenize func(string) []string) *Tokens {
+	return NewTokens(tokenize(text))
+}
+
+// Len returns the number of tokens.
+func (t *Tokens) Len() int {
+	return t.w.Len()
+}
+
+// Dict returns the distinct tokens in the order of their first occurrences, which is the order of
+// their numbers. The slice must not be modified.
+func (t *Tokens) Dict() []string {
+	return t.dict
+}
+
+// ID returns the number of the token, and false if it does not occur.
+func (t *Tokens) ID(tok string) (int64, bool) {
+	id, ok := t.ids[tok]
+	return id, ok
+}
+
+// Count returns the count of the token.
+func (t *Tokens) Count(tok string) int {
+	id, ok := t.ids[tok]
+	if !ok {
+		return 0
+	}
+	return t.w.Count(id)
+}
+
+// Rank returns the count of the token in the first i tokens.
+// i is clamped to the range [0, Len()].
+func (t *Tokens) Rank(tok string, i int) int {
+	id, ok := t.ids[tok]
+	if !ok {
+		return 0
+	}
+	return t.w.Rank(id, i)
+}
+
+// Select returns the index of the r-th occurrence of the token, or -1 if there is none.
+func (t *Tokens) Select(tok string, r int) int {
+	id, ok := t.ids[tok]
+	if !ok {
+		return -1
+	}
+	if i, ok := t.w.SelectChecked(id, r); ok {
+		return i
+	}
+	return -1
+}
+
+// Access returns the i-th token. It panics if i is out of range.
+func (t *Tokens) Access(i int) string {
+	return t.dict[t.w.Access(i)]
+}
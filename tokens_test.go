@@ -0,0 +1,63 @@
+package wltree
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	words := []string{"the", "quick", "brown", "fox", "jumps", "over", "lazy", "dog"}
+	for size := 0; size < 200; size += 13 {
+		tokens := make([]string, size)
+		for i := range tokens {
+			tokens[i] = words[rand.Intn(len(words))]
+		}
+		w := NewTokensFunc(strings.Join(tokens, " "), strings.Fields)
+		if got := w.Len(); got != len(tokens) {
+			t.Errorf("Len() => got %v, want %v", got, len(tokens))
+		}
+		var dict []string
+		for _, tok := range tokens {
+			if _, ok := w.ID(tok); !ok {
+				t.Errorf("ID(%q) => not found", tok)
+			}
+			found := false
+			for _, d := range dict {
+				found = found || d == tok
+			}
+			if !found {
+				dict = append(dict, tok)
+			}
+		}
+		if got := w.Dict(); !reflect.DeepEqual(got, dict) {
+			t.Errorf("Dict() => got %q, want %q", got, dict)
+		}
+		for _, word := range append(words, "cat") {
+			count := 0
+			for i, tok := range tokens {
+				if got := w.Rank(word, i); got != count {
+					t.Errorf("Rank(%q, %v) => got %v, want %v", word, i, got, count)
+				}
+				if tok == word {
+					if got := w.Select(word, count); got != i {
+						t.Errorf("Select(%q, %v) => got %v, want %v", word, count, got, i)
+					}
+					count++
+				}
+			}
+			if got := w.Count(word); got != count {
+				t.Errorf("Count(%q) => got %v, want %v", word, got, count)
+			}
+			if got := w.Select(word, count); got != -1 {
+				t.Errorf("Select(%q, %v) => got %v, want -1", word, count, got)
+			}
+		}
+		for i, tok := range tokens {
+			if got := w.Access(i); got != tok {
+				t.Errorf("Access(%v) => got %q, want %q", i, got, tok)
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package wltree
+
+import (
+	"fmt"
+	"iter"
+	"math/bits"
+	"sort"
+)
+
+// Tombstones is a Sequence that deletes elements from an immutable Sequence logically, by marking
+// them dead. Positions and answers are those of the live sequence, that is, of the base without
+// the dead elements. Deletion and the positional mapping take O(log n) time, and Rank and Select
+// additionally take time linear in the number of dead elements with the key, so Tombstones suits
+// deletions that Compact folds into a new tree from time to time. Select returns -1 when the live
+// sequence has no such occurrence.
+type Tombstones struct {
+	base Sequence
+	// dead is the bitmap of the dead positions of base, and tree the Fenwick tree of the numbers of
+	// dead positions in its words.
+	dead []uint64
+	tree []int
+	// deadOf holds the dead positions of each key in ascending order.
+	deadOf map[int64][]int
+	ndead  int
+}
+
+// NewTombstones returns a Tombstones on base with no dead elements.
+func NewTombstones(base Sequence) *Tombstones {
+	words := (base.Len() + 63) / 64
+	return &Tombstones{
+		base:   base,
+		dead:   make([]uint64, words),
+		tree:   make([]int, words+1),
+		deadOf: make(map[int64][]int),
+	}
+}
+
+// Len returns the length of the live sequence.
+func (t *Tombstones) Len() int {
+	return t.base.Len() - t.ndead
+}
+
+// Delete marks the i-th element of the live sequence dead, and returns its key. It panics if i is
+// out of range.
+func (t *Tombstones) Delete(i int) int64 {
+	p := t.basePos(i)
+	t.dead[p/64] |= 1 << uint(p%64)
+	for w := p/64 + 1; w < len(t.tree); w += w & -w {
+		t.tree[w]++
+	}
+	t.ndead++
+	key := t.base.Access(p)
+	ps := t.deadOf[key]
+	k := sort.SearchInts(ps, p)
+	t.deadOf[key] = append(ps[:k], append([]int{p}, ps[k:]...)...)
+	return key
+}
+
+// Access returns the key of the i-th element of the live sequence. It panics if i is out of range.
+func (t *Tombstones) Access(i int) int64 {
+	return t.base.Access(t.basePos(i))
+}
+
+// Rank returns the count of elements with the key in the first i elements of the live sequence.
+// i is clamped to the range [0, Len()].
+func (t *Tombstones) Rank(key int64, i int) int {
+	i = clamp(i, t.Len())
+	p := t.base.Len()
+	if i < t.Len() {
+		p = t.basePos(i)
+	}
+	return t.base.Rank(key, p) - sort.SearchInts(t.deadOf[key], p)
+}
+
+// Select returns the position in the live sequence of its r-th occurrence of the key, or -1 if
+// there is none.
+func (t *Tombstones) Select(key int64, r int) int {
+	if r < 0 {
+		return -1
+	}
+	// Skip the dead occurrences up to the r-th live one.
+	q := r
+	for _, p := range t.deadOf[key] {
+		if t.base.Rank(key, p) > q {
+			break
+		}
+		q++
+	}
+	if q >= t.base.Rank(key, t.base.Len()) {
+		return -1
+	}
+	p := t.base.Select(key, q)
+	return p - t.deadBefore(p)
+}
+
+// Compact returns a Wavelet Tree on the live sequence.
+func (t *Tombstones) Compact() *Int64Keys {
+	return NewSeq(t.All())
+}
+
+// All returns the keys of the live sequence in order.
+func (t *Tombstones) All() iter.Seq[int64] {
+	return func(yield func(int64) bool) {
+		for p := 0; p < t.base.Len(); p++ {
+			if t.dead[p/64]>>uint(p%64)&1 == 0 && !yield(t.base.Access(p)) {
+				return
+			}
+		}
+	}
+}
+
+// deadBefore returns the number of dead positions of base before p.
+func (t *Tombstones) deadBefore(p int) int {
+	n := 0
+	for w := p / 64; w > 0; w -= w & -w {
+		n += t.tree[w]
+	}
+	if p%64 != 0 {
+		n += bits.OnesCount64(t.dead[p/64] & (1<<uint(p%64) - 1))
+	}
+	return n
+}
+
+// basePos returns the position in base of the i-th live element. It panics if i is out of range.
+func (t *Tombstones) basePos(i int) int {
+	if i < 0 || i >= t.Len() {
+		panic(fmt.Sprintf("wltree: index %v out of range [0, %v)", i, t.Len()))
+	}
+	// Find the last word w before which fewer than i+1 positions are live, by binary lifting.
+	w := 0
+	for step := 1 << (bits.Len(uint(len(t.dead))) - 1); step > 0; step >>= 1 {
+		if next := w + step; next <= len(t.dead) && 64*step-t.tree[next] <= i {
+			w = next
+			i -= 64*step - t.tree[next]
+		}
+	}
+	x := ^t.dead[w]
+	for ; i > 0; i-- {
+		x &= x - 1
+	}
+	return 64*w + bits.TrailingZeros64(x)
+}
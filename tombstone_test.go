@@ -0,0 +1,50 @@
+package wltree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestTombstones(t *testing.T) {
+	for size := 0; size < maxSize; size += 29 {
+		for _, ws := range weights {
+			s := random(size, ws)
+			tomb := NewTombstones(NewInt64Keys(byteSlice(s)))
+			for k := rand.Intn(len(s) + 1); k > 0; k-- {
+				i := rand.Intn(len(s))
+				if got := tomb.Delete(i); got != int64(s[i]) {
+					t.Errorf("Delete(%v) => got %v, want %v", i, got, s[i])
+				}
+				s = append(s[:i], s[i+1:]...)
+			}
+			if got := tomb.Len(); got != len(s) {
+				t.Errorf("Len() => got %v, want %v", got, len(s))
+			}
+			for _, key := range []int64{'a', 'c', 'f', 'z'} {
+				count := 0
+				for i := 0; i <= len(s); i++ {
+					if got := tomb.Rank(key, i); got != count {
+						t.Errorf("%q: Rank(%v, %v) => got %v, want %v", s, key, i, got, count)
+					}
+					if i < len(s) && int64(s[i]) == key {
+						if got := tomb.Select(key, count); got != i {
+							t.Errorf("%q: Select(%v, %v) => got %v, want %v", s, key, count, got, i)
+						}
+						count++
+					}
+				}
+				if got := tomb.Select(key, count); got != -1 {
+					t.Errorf("%q: Select(%v, %v) => got %v, want -1", s, key, count, got)
+				}
+			}
+			for i := range s {
+				if got := tomb.Access(i); got != int64(s[i]) {
+					t.Errorf("%q: Access(%v) => got %v, want %v", s, i, got, s[i])
+				}
+			}
+			if got, want := tomb.Compact(), NewInt64Keys(byteSlice(s)); !got.Equal(want) {
+				t.Errorf("%q: Compact() => differs from NewInt64Keys", s)
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package wltree
+
+import "fmt"
+
+// Verify checks the structural invariants of the tree: that the codes of the keys form a prefix
+// code matching the shape of the tree, that the BitVector of each node has a bit for each of its
+// elements, and that the number of elements routed through each node is consistent with the bits
+// of its parent and the counts of the keys at the leaves.
+// It returns an error describing the first violation found, or nil.
+func (w *Int64Keys) Verify() error {
+	return verify(w.root, w.n, w.keyset, w.counts, func(key int64) (string, []rankSelect, bool) {
+		k, ok := w.find(key)
+		if !ok {
+			return "", nil, false
+		}
+		return w.codes[k].String(), w.nodes[k], true
+	})
+}
+
+// Verify is like Int64Keys.Verify.
+func (w *Bytes) Verify() error {
+	keyset := make([]int64, len(w.keyset))
+	for i, c := range w.keyset {
+		keyset[i] = int64(c)
+	}
+	return verify(w.root, w.n, keyset, w.counts, func(key int64) (string, []rankSelect, bool) {
+		if key < 0 || key > 255 || !w.Contains(byte(key)) {
+			return "", nil, false
+		}
+		return w.codes[key].String(), w.nodes[key], true
+	})
+}
+
+// verify checks the tree rooted at root over n elements against the keys in keyset and their
+// counts. path returns the code of a key and the BitVectors along it.
+func verify(root *node, n int, keyset []int64, counts []int,
+	path func(key int64) (string, []rankSelect, bool)) error {
+	if len(keyset) != len(counts) {
+		return fmt.Errorf("wltree: %v keys but %v counts", len(keyset), len(counts))
+	}
+	total := 0
+	for i, k := range keyset {
+		if i > 0 && keyset[i-1] >= k {
+			return fmt.Errorf("wltree: keys %v and %v out of order", keyset[i-1], k)
+		}
+		if counts[i] < 0 {
+			return fmt.Errorf("wltree: negative count %v for key %v", counts[i], k)
+		}
+		total += counts[i]
+	}
+	if total != n {
+		return fmt.Errorf("wltree: counts of keys sum to %v, want length %v", total, n)
+	}
+	if root == nil {
+		if len(keyset) != 0 {
+			return fmt.Errorf("wltree: empty tree with %v keys", len(keyset))
+		}
+		return nil
+	}
+	if root.size != n {
+		return fmt.Errorf("wltree: root node has %v elements, want %v", root.size, n)
+	}
+
+	// Every key must reach a leaf of its own by following its code, passing through the
+	// BitVectors registered for it.
+	for i, k := range keyset {
+		code, bvs, ok := path(k)
+		if !ok {
+			return fmt.Errorf("wltree: no code for key %v", k)
+		}
+		if len(bvs) != len(code) {
+			return fmt.Errorf("wltree: key %v has code %q but %v nodes", k, code, len(bvs))
+		}
+		nd := root
+		for j := range code {
+			if nd.leaf() {
+				return fmt.Errorf("wltree: code %q of key %v extends beyond a leaf", code, k)
+			}
+			if nd.bv != bvs[j] {
+				return fmt.Errorf("wltree: node %q on the path of key %v differs from the tree", code[:j], k)
+			}
+			if code[j] != '0' && code[j] != '1' {
+				return fmt.Errorf("wltree: code %q of key %v is not binary", code, k)
+			}
+			nd = nd.child[code[j]-'0']
+			if nd == nil {
+				return fmt.Errorf("wltree: node %q is missing", code[:j+1])
+			}
+		}
+		if !nd.leaf() {
+			return fmt.Errorf("wltree: code %q of key %v is a prefix of another code", code, k)
+		}
+		if nd.key != k {
+			return fmt.Errorf("wltree: code %q of key %v leads to key %v", code, k, nd.key)
+		}
+		if nd.size != counts[i] {
+			return fmt.Errorf("wltree: leaf of key %v has %v elements, want %v", k, nd.size, counts[i])
+		}
+	}
+
+	// Every node must pass on exactly its elements to its children, and there must be no more
+	// leaves than keys.
+	leaves := 0
+	var walk func(nd *node, prefix string) error
+	walk = func(nd *node, prefix string) error {
+		if nd.leaf() {
+			leaves++
+			return nil
+		}
+		for b, child := range nd.child {
+			if child == nil {
+				return fmt.Errorf("wltree: node %q has no child %v", prefix, b)
+			}
+		}
+		// The bits must be checked before they are queried, as loaded data may be truncated.
+		if got := nd.bv.Len(); got != nd.size {
+			return fmt.Errorf("wltree: node %q has %v bits, want %v", prefix, got, nd.size)
+		}
+		if s, ok := nd.bv.(*levelSlice); ok && s.off+s.n > s.bv.Len() {
+			return fmt.Errorf("wltree: node %q extends beyond its level of %v bits", prefix, s.bv.Len())
+		}
+		ones := nd.bv.Rank1(nd.size)
+		if ones < 0 || ones > nd.size {
+			return fmt.Errorf("wltree: node %q has %v ones in %v elements", prefix, ones, nd.size)
+		}
+		if got, want := nd.child[0].size, nd.size-ones; got != want {
+			return fmt.Errorf("wltree: node %q has %v elements, want %v", prefix+"0", got, want)
+		}
+		if got, want := nd.child[1].size, ones; got != want {
+			return fmt.Errorf("wltree: node %q has %v elements, want %v", prefix+"1", got, want)
+		}
+		if err := walk(nd.child[0], prefix+"0"); err != nil {
+			return err
+		}
+		return walk(nd.child[1], prefix+"1")
+	}
+	if err := walk(root, ""); err != nil {
+		return err
+	}
+	if leaves != len(keyset) {
+		return fmt.Errorf("wltree: tree has %v leaves, want %v", leaves, len(keyset))
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package wltree
+
+import "testing"
+
+func TestVerify(t *testing.T) {
+	for size := 0; size < 64; size++ {
+		for _, ws := range weights {
+			bs := random(size, ws)
+			if err := NewBytes(bs).Verify(); err != nil {
+				t.Errorf("Bytes: %q.Verify() => %v", bs, err)
+			}
+			if err := NewInt64Keys(byteSlice(bs)).Verify(); err != nil {
+				t.Errorf("IntKeys: %q.Verify() => %v", bs, err)
+			}
+		}
+	}
+
+	for _, corrupt := range []func(w *Int64Keys){
+		func(w *Int64Keys) { w.n++ },
+		func(w *Int64Keys) { w.counts[0]++; w.counts[1]-- },
+		func(w *Int64Keys) { w.codes[0] = w.codes[1] },
+		func(w *Int64Keys) { w.codes[0] = packCode(w.codes[0].String() + "0") },
+		func(w *Int64Keys) { w.root.child[0], w.root.child[1] = w.root.child[1], w.root.child[0] },
+		func(w *Int64Keys) { w.root.child[1].size++ },
+		func(w *Int64Keys) { w.keyset[0], w.keyset[1] = w.keyset[1], w.keyset[0] },
+		// Truncated nodes, and a node cut short by its level.
+		func(w *Int64Keys) { w.root.bv = DefaultBackend(w.root.size - 1).Build() },
+		func(w *Int64Keys) { w.root.bv.(*levelSlice).n-- },
+		func(w *Int64Keys) {
+			s := w.root.child[1].bv.(*levelSlice)
+			s.bv = DefaultBackend(s.off + s.n - 1).Build()
+		},
+	} {
+		w := NewInt64Keys(byteSlice("abracadabra"))
+		corrupt(w)
+		if err := w.Verify(); err == nil {
+			t.Errorf("Verify() on corrupted tree => got nil error")
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package wltree
+
+// Node is a node of a Wavelet Tree together with an interval [l, r) of the positions of its
+// elements, such as the elements of a range of s that reach the node. It exposes the tree
+// read-only for custom traversals, such as the many range algorithms that descend the tree by
+// mapping the interval down with Ranks. The keys of a Wavelet Tree on bytestring are its
+// characters as int64.
+//
+// Internal nodes hold a bit for each of their elements, 0 for the elements that go to the left
+// child and 1 for those that go to the right one. Leaves hold the elements of a single key. With
+// the key-ordered shapes, such as BalancedShape, the keys on the left are smaller than those on
+// the right.
+type Node struct {
+	n    *node
+	l, r int
+}
+
+// Root returns the root of w with the interval [l, r), clamped as by the range queries. The root
+// of an empty tree is a leaf with no elements.
+func (w *Int64Keys) Root(l, r int) Node {
+	l, r = clampRange(l, r, w.n)
+	return Node{w.root, l, r}
+}
+
+// Root is like Int64Keys.Root.
+func (w *Bytes) Root(l, r int) Node {
+	l, r = clampRange(l, r, w.n)
+	return Node{w.root, l, r}
+}
+
+// Visit calls f on the nodes of w from the root with the interval [l, r), clamped as by the range
+// queries, in depth-first order, left before right. It descends from a node into its children
+// only if f returns true and their intervals are not both empty.
+func (w *Int64Keys) Visit(l, r int, f func(Node) bool) {
+	w.Root(l, r).visit(f)
+}
+
+// Visit is like Int64Keys.Visit.
+func (w *Bytes) Visit(l, r int, f func(Node) bool) {
+	w.Root(l, r).visit(f)
+}
+
+func (nd Node) visit(f func(Node) bool) {
+	if f(nd) && !nd.Leaf() && nd.l < nd.r {
+		nd.Child(0).visit(f)
+		nd.Child(1).visit(f)
+	}
+}
+
+// Code returns the code of key in w as a string of '0's and '1's, the branches from the root to
+// the leaf of key with '1' for the right child, and whether key is known to w. The only key of a
+// single-key tree has the empty code.
+func (w *Int64Keys) Code(key int64) (string, bool) {
+	i, ok := w.find(key)
+	if !ok {
+		return "", false
+	}
+	return w.codes[i].String(), true
+}
+
+// Code is like Int64Keys.Code.
+func (w *Bytes) Code(c byte) (string, bool) {
+	if !w.Contains(c) {
+		return "", false
+	}
+	return w.codes[c].String(), true
+}
+
+// Interval returns the interval [l, r) of nd.
+func (nd Node) Interval() (l, r int) {
+	return nd.l, nd.r
+}
+
+// Size returns the number of elements of nd, that is, the number of bits of an internal node.
+func (nd Node) Size() int {
+	if nd.n == nil {
+		return 0
+	}
+	return nd.n.size
+}
+
+// Leaf reports whether nd is a leaf.
+func (nd Node) Leaf() bool {
+	return nd.n == nil || nd.n.leaf()
+}
+
+// Key returns the key of the elements of a leaf, or 0 for an internal node.
+func (nd Node) Key() int64 {
+	if nd.n == nil || !nd.n.leaf() {
+		return 0
+	}
+	return nd.n.key
+}
+
+// Keys returns the smallest and largest keys of the leaves below nd.
+func (nd Node) Keys() (lo, hi int64) {
+	if nd.n == nil {
+		return 0, 0
+	}
+	return nd.n.lo, nd.n.hi
+}
+
+// Rank1 returns the number of ones in the first i bits of nd, for i in [0, Size()]. It is 0 for a
+// leaf.
+func (nd Node) Rank1(i int) int {
+	if nd.Leaf() {
+		return 0
+	}
+	return nd.n.bv.Rank1(i)
+}
+
+// Rank0 returns the number of zeros in the first i bits of nd, for i in [0, Size()]. It is i for a
+// leaf.
+func (nd Node) Rank0(i int) int {
+	if nd.Leaf() {
+		return i
+	}
+	return nd.n.bv.Rank0(i)
+}
+
+// Bits returns the bits of an internal node, or nil for a leaf. The RankSelect is a read-only view
+// that shares the storage of the tree.
+func (nd Node) Bits() RankSelect {
+	if nd.Leaf() {
+		return nil
+	}
+	return nodeBits{nd.n.bv, nd.n.size}
+}
+
+// nodeBits is the RankSelect of the bits of a node, which hides the type of the BitVector.
+type nodeBits struct {
+	rankSelect
+	n int
+}
+
+func (b nodeBits) Len() int {
+	return b.n
+}
+
+// Child returns the left child of an internal node if bit is 0, and the right child otherwise,
+// with the interval of the elements of the interval of nd that go there. It panics for a leaf.
+func (nd Node) Child(bit int) Node {
+	if nd.Leaf() {
+		panic("wltree: Child of a leaf")
+	}
+	if bit == 0 {
+		return Node{nd.n.child[0], nd.n.bv.Rank0(nd.l), nd.n.bv.Rank0(nd.r)}
+	}
+	return Node{nd.n.child[1], nd.n.bv.Rank1(nd.l), nd.n.bv.Rank1(nd.r)}
+}
@@ -0,0 +1,142 @@
+package wltree
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestVisit(t *testing.T) {
+	for _, ws := range weights {
+		s := random(rand.Intn(maxSize)+1, ws)
+		w := NewBytes(s)
+		iw := NewInt64KeysWithOptions(byteSlice(s), &Options{Shape: BalancedShape})
+		for trial := 0; trial < 20; trial++ {
+			l, r := randomRange(len(s))
+			lo, hi := int64(s[rand.Intn(len(s))]), int64(s[rand.Intn(len(s))])
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+
+			// Count the keys in [lo, hi] by pruning the nodes whose keys are all in or all out.
+			count := 0
+			w.Visit(l, r, func(nd Node) bool {
+				klo, khi := nd.Keys()
+				nl, nr := nd.Interval()
+				if khi < lo || klo > hi {
+					return false
+				}
+				if lo <= klo && khi <= hi {
+					count += nr - nl
+					return false
+				}
+				return true
+			})
+			if want := iw.RangeCount(l, r, lo, hi+1); count != want {
+				t.Errorf("%q: Visit counting [%v, %v] in [%v, %v) => got %v, want %v", s, lo, hi, l, r, count, want)
+			}
+
+			// Find the k-th smallest key by descending the key-ordered tree.
+			if l == r {
+				continue
+			}
+			k := rand.Intn(r - l)
+			nd, rest := iw.Root(l, r), k
+			for !nd.Leaf() {
+				nl, nr := nd.Interval()
+				if zeros := nd.Rank0(nr) - nd.Rank0(nl); rest < zeros {
+					nd = nd.Child(0)
+				} else {
+					rest -= zeros
+					nd = nd.Child(1)
+				}
+			}
+			sorted := slices.Clone(s[l:r])
+			slices.Sort(sorted)
+			if want := int64(sorted[k]); nd.Key() != want {
+				t.Errorf("%q: descending to the %v-th smallest in [%v, %v) => got %v, want %v", s, k, l, r, nd.Key(), want)
+			}
+		}
+
+		// The leaves visited over all of s are the keys with their counts.
+		keys, counts := w.Symbols()
+		var gotKeys []byte
+		var gotCounts []int
+		w.Visit(0, len(s), func(nd Node) bool {
+			if nd.Leaf() {
+				l, r := nd.Interval()
+				gotKeys, gotCounts = append(gotKeys, byte(nd.Key())), append(gotCounts, r-l)
+				if nd.Size() != r-l || nd.Rank1(r) != 0 || nd.Rank0(r) != r {
+					t.Errorf("%q: leaf %v => size %v, Rank1 %v, Rank0 %v", s, nd.Key(), nd.Size(), nd.Rank1(r), nd.Rank0(r))
+				}
+			}
+			return true
+		})
+		order := make([]int, len(gotKeys))
+		for i := range order {
+			order[i] = i
+		}
+		slices.SortFunc(order, func(a, b int) int { return int(gotKeys[a]) - int(gotKeys[b]) })
+		for i, j := range order {
+			if gotKeys[j] != keys[i] || gotCounts[j] != counts[i] {
+				t.Errorf("%q: Visit leaves %q %v, want %q %v", s, gotKeys, gotCounts, keys, counts)
+				break
+			}
+		}
+	}
+
+	if nd := NewInts(nil).Root(0, 0); !nd.Leaf() || nd.Size() != 0 {
+		t.Errorf("empty Root() => got leaf %v, size %v, want an empty leaf", nd.Leaf(), nd.Size())
+	}
+}
+
+func TestNodeCode(t *testing.T) {
+	for _, ws := range weights {
+		s := random(rand.Intn(maxSize)+1, ws)
+		w := NewBytes(s)
+		keys, counts := w.Symbols()
+		for i, c := range keys {
+			code, ok := w.Code(c)
+			if !ok {
+				t.Errorf("Code(%q) => got not found, want found", c)
+				continue
+			}
+			// Following the code from the root leads to the leaf of c, through the Bits of the nodes.
+			nd := w.Root(0, len(s))
+			for _, b := range code {
+				bits := nd.Bits()
+				if bits == nil || bits.Len() != nd.Size() {
+					t.Errorf("%q: Bits() on the path of %q => got %v, want %v bits", s, c, bits, nd.Size())
+					break
+				}
+				for _, j := range []int{0, rand.Intn(nd.Size() + 1), nd.Size()} {
+					if got, want := bits.Rank1(j), nd.Rank1(j); got != want {
+						t.Errorf("%q: Bits().Rank1(%v) => got %v, want %v", s, j, got, want)
+					}
+				}
+				nd = nd.Child(int(b - '0'))
+			}
+			if !nd.Leaf() || nd.Key() != int64(c) || nd.Size() != counts[i] || nd.Bits() != nil {
+				t.Errorf("%q: leaf at Code(%q) = %q => got key %v, size %v, want %v, %v", s, c, code, nd.Key(), nd.Size(), c, counts[i])
+			}
+		}
+		if code, ok := w.Code('z'); ok {
+			t.Errorf("Code('z') => got %q, want not found", code)
+		}
+
+		iw := NewInt64Keys(byteSlice(s))
+		for _, c := range keys {
+			got, _ := iw.Code(int64(c))
+			if want, _ := w.Code(c); got != want {
+				t.Errorf("%q: Int64Keys.Code(%v) => got %q, want %q", s, c, got, want)
+			}
+		}
+		if code, ok := iw.Code(-1); ok {
+			t.Errorf("Int64Keys.Code(-1) => got %q, want not found", code)
+		}
+	}
+
+	if code, ok := NewBytes([]byte("aaa")).Code('a'); !ok || code != "" {
+		t.Errorf(`Code('a') on "aaa" => got %q, %v, want "", true`, code, ok)
+	}
+}
@@ -4,26 +4,50 @@ See http://en.wikipedia.org/wiki/Wavelet_Tree for details.
 
 Example
 
-    s := []byte("abracadabra")
-    wt := wltree.NewBytes(s)
-    // The number of 'a' in s.
-    wt.Rank('a', len(s)) //=> 5
-    // The number of 'a' in s[3:8] = "acada"
-    wt.Rank('a', 8) - wt.Rank('a', 3) //=> 3
-    // The index of the 3rd occurrence of 'a' in s. 0-origin, thus 2 means 3rd.
-    wt.Select('a', 2) //=> 5
+	s := []byte("abracadabra")
+	wt := wltree.NewBytes(s)
+	// The number of 'a' in s.
+	wt.Rank('a', len(s)) //=> 5
+	// The number of 'a' in s[3:8] = "acada"
+	wt.Rank('a', 8) - wt.Rank('a', 3) //=> 3
+	// The index of the 3rd occurrence of 'a' in s. 0-origin, thus 2 means 3rd.
+	wt.Select('a', 2) //=> 5
+
+Positions are ints, so sequences of billions of elements need a 64-bit platform, where int is
+64 bits wide. Constructors that read streams report longer sequences with ErrTooLong. Large has
+int64 positions, and indexes such sequences on every platform.
+
+Positions given to Rank and to the range queries are clamped to [0, Len()], and a range s[l:r]
+with r < l is empty.
+
+Rank, Select and Access allocate no memory, so they do not add to the work of the garbage
+collector. The only exceptions are the first queries that build lazily built directories, as with
+LoadLazy and Options.LazySelect, and the panics that report errors.
 */
 package wltree
 
 import (
+	"context"
+	"errors"
 	"fmt"
-
-	"github.com/mozu0/bitvector"
-	"github.com/mozu0/huffman"
+	"iter"
+	"math"
+	"sort"
 )
 
+// maxLen is the length of the longest stream that can be indexed. Positions are ints, so this is
+// 2^63-1 on 64-bit platforms but only 2^31-1 on 32-bit ones. Slices are never longer, so only the
+// constructors that read streams check it.
+var maxLen = math.MaxInt
+
+// ErrTooLong is the error for a sequence that is longer than positions of type int can address on
+// this platform. Constructors that do not return an error panic with it. Large indexes such
+// sequences.
+var ErrTooLong = errors.New("wltree: sequence too long for int positions on this platform")
+
 // Interface is a interface for arraylike elements that can be indexed by Wavelet Tree.
 // Equal elements must have the same key, and different elements must have different keys.
+// Keys may be any int64 values, including negative ones.
 type Interface interface {
 	// Len returns the length of the arraylike.
 	Len() int
@@ -33,103 +57,248 @@ type Interface interface {
 
 // Int64Keys represents a Wavelet Tree on int64 keys.
 type Int64Keys struct {
-	nodes map[int64][]*bitvector.BitVector
-	codes map[int64]string
+	root *node
+
+	// keyset and counts are the distinct keys in ascending order and their occurrences, and codes
+	// and nodes are their codes and the BitVectors along them.
+	keyset []int64
+	counts []int
+	codes  []code
+	nodes  [][]rankSelect
+	// hot holds the rank caches of the keys given to CacheRanks, if any.
+	hot []*packedBits
+	n   int
+
+	errorMode ErrorMode
+	// opts are the options the tree was built with, less Progress, so that the trees Merge and
+	// Split derive from it are built alike. They are nil for the defaults.
+	opts *Options
 }
 
 // NewInt64Keys makes a Wavlet Tree from arraylike s whose elements can yield integer keys.
 func NewInt64Keys(s Interface) *Int64Keys {
-	w := &Int64Keys{
-		nodes: make(map[int64][]*bitvector.BitVector),
-		codes: make(map[int64]string),
+	keyset, counts := freq(all(s))
+	return newInt64Keys(all(s), keyset, counts)
+}
+
+// NewInt64KeysAlphabet is like NewInt64Keys, but also assigns codes to the keys in alphabet that
+// do not occur in s, so that they are known to the tree with zero occurrences. Keys of s missing
+// from alphabet are indexed as usual.
+func NewInt64KeysAlphabet(s Interface, alphabet []int64) *Int64Keys {
+	keyset, counts := freq(all(s))
+	keyset, counts = withAlphabet(keyset, counts, alphabet)
+	return newInt64Keys(all(s), keyset, counts)
+}
+
+// NewInt64KeysWithCounts is like NewInt64Keys, but takes the distinct keys of s and their numbers
+// of occurrences, as returned by Symbols, instead of counting them, which saves a pass over s.
+// keys must be distinct and counts exact; keys with zero counts are known to the tree as with
+// NewInt64KeysAlphabet. keys and counts are not modified.
+func NewInt64KeysWithCounts(s Interface, keys []int64, counts []int) *Int64Keys {
+	if len(keys) != len(counts) {
+		panic("wltree: keys and counts of different lengths")
 	}
+	return newInt64Keys(all(s), append([]int64(nil), keys...), append([]int(nil), counts...))
+}
+
+// newInt64Keys makes a Wavelet Tree from seq whose distinct keys and their occurrences are keyset
+// and counts.
+func newInt64Keys(seq iter.Seq[int64], keyset []int64, counts []int) *Int64Keys {
+	return newShapedInt64Keys(context.Background(), seq, keyset, counts, nil, new(scratch))
+}
 
-	// Generate huffman tree based on character occurrences in s.
-	keyset, counts := freq(s)
-	codes := huffman.FromInts(counts)
+// newShapedInt64Keys is like newInt64Keys, but assigns the codes of the keys and stores the nodes
+// as selected by opts, with the scratch memory sc. It returns nil once ctx is done, even while
+// counting the keys beforehand.
+func newShapedInt64Keys(ctx context.Context, seq iter.Seq[int64], keyset []int64, counts []int,
+	opts *Options, sc *scratch) *Int64Keys {
+	if ctx.Err() != nil {
+		return nil
+	}
+	sortFreq(keyset, counts)
+
+	// Generate the code tree based on character occurrences in s. An empty s has no tree at all.
+	var codes []string
+	if len(counts) > 0 {
+		codes = opts.codes(counts)
+	}
+	codeOf := reuse(&sc.codeOf)
 	for i, code := range codes {
-		w.codes[keyset[i]] = code
+		codeOf[keyset[i]] = code
 	}
 
 	// Count number of bits in each node of the wavelet tree.
-	sizes := make(map[string]int)
-	for i := range keyset {
-		code := codes[i]
-		count := counts[i]
-		for j := range code {
-			sizes[code[:j]] += count
+	sizes := nodeSizes(counts, codes)
+
+	levels, b, workers := opts.nodeBuilder(sizes, counts, codes)
+
+	// Set bits in each node, the w-th worker taking every n-th level from the w-th. The first worker
+	// reports the progress.
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	p := opts.progress(ctx, PhaseSet)
+	parallel(workers, func(w, n int) {
+		index := make(map[string]int)
+		if n == 1 {
+			index = reuse(&sc.index)
+		}
+		for k := range p.seq(seq, total, w == 0) {
+			code := codeOf[k]
+			for j := w; j < len(code); j += n {
+				if code[j] == '1' {
+					b.set(code[:j], index[code[:j]])
+				}
+				index[code[:j]]++
+			}
 		}
+	})
+	if ctx.Err() != nil {
+		return nil
 	}
 
-	// Assign BitVector Builders to each wavelet tree node.
-	builders := make(map[string]*bitvector.Builder)
-	var keys []string
-	for key, size := range sizes {
-		keys = append(keys, key)
-		builders[key] = bitvector.NewBuilder(size)
+	// Build all nodes, reporting each level built, or only the end if encoded otherwise.
+	levels.progress = opts.progress(ctx, PhaseBuild)
+	bvs := b.build()
+	if ctx.Err() != nil {
+		return nil
+	}
+	if b != levels {
+		levels.progress.at(1, 1)
 	}
 
-	// Set bits in each BitVector Builder.
-	index := make(map[string]int)
-	for i, size := 0, s.Len(); i < size; i++ {
-		k := s.Key(i)
-		code := w.codes[k]
+	return assemble(keyset, counts, codes, bvs, sizes)
+}
+
+// nodeSizes returns the number of bits in each node of the wavelet tree, indexed by code prefix,
+// for keys with the counts and codes.
+func nodeSizes(counts []int, codes []string) map[string]int {
+	sizes := make(map[string]int)
+	for i, code := range codes {
 		for j := range code {
-			if code[j] == '1' {
-				builders[code[:j]].Set(index[code[:j]])
-			}
-			index[code[:j]]++
+			sizes[code[:j]] += counts[i]
 		}
 	}
+	return sizes
+}
 
-	// Build all BitVectors.
-	bvs := make(map[string]*bitvector.BitVector)
-	for key, builder := range builders {
-		bvs[key] = builder.Build()
+// assemble makes a Wavelet Tree from the keys in keyset, their counts and codes, and the
+// BitVectors and sizes of the wavelet tree nodes indexed by code prefix.
+func assemble(keyset []int64, counts []int, codes []string,
+	bvs map[string]rankSelect, sizes map[string]int) *Int64Keys {
+	w := &Int64Keys{
+		keyset: keyset,
+		counts: counts,
+		codes:  make([]code, len(keyset)),
+		nodes:  make([][]rankSelect, len(keyset)),
+	}
+	for _, count := range counts {
+		w.n += count
+	}
+	for i, c := range codes {
+		w.codes[i] = packCode(c)
 	}
 
 	// For each charactor, register the path from wavelet tree root, through wavelet tree nodes, and
 	// to the leaf.
-	for i, k := range keyset {
-		code := codes[i]
+	for i, code := range codes {
 		for j := range code {
-			w.nodes[k] = append(w.nodes[k], bvs[code[:j]])
+			w.nodes[i] = append(w.nodes[i], bvs[code[:j]])
 		}
 	}
 
+	// Link the nodes into a tree so that range queries can traverse it from the root.
+	w.root = link(bvs, sizes, keyset, counts, codes)
+
 	return w
 }
 
+// NewSlice makes a Wavelet Tree on int64 keys from slice s, where key yields the key of each
+// element.
+func NewSlice[T any](s []T, key func(T) int64) *Int64Keys {
+	return NewInt64Keys(slice[T]{s, key})
+}
+
+// NewFunc makes a Wavelet Tree on int64 keys from a sequence of length n whose i-th key is key(i).
+func NewFunc(n int, key func(i int) int64) *Int64Keys {
+	return NewInt64Keys(funcKeys{n, key})
+}
+
+// Contains reports whether the key is known to the tree, that is, whether it occurs in s or was
+// given in the alphabet at construction.
+func (w *Int64Keys) Contains(key int64) bool {
+	_, ok := w.find(key)
+	return ok
+}
+
+// find returns the index of the key in keyset, and whether it is there.
+func (w *Int64Keys) find(key int64) (int, bool) {
+	i := sort.Search(len(w.keyset), func(i int) bool { return w.keyset[i] >= key })
+	return i, i < len(w.keyset) && w.keyset[i] == key
+}
+
+// Symbols returns the keys known to the tree in ascending order, and the number of occurrences of
+// each of them in s.
+func (w *Int64Keys) Symbols() (keys []int64, counts []int) {
+	return append([]int64(nil), w.keyset...), append([]int(nil), w.counts...)
+}
+
+// Len returns the length of s.
+func (w *Int64Keys) Len() int {
+	return w.n
+}
+
+// Count returns the count of elements with the key in s.
+func (w *Int64Keys) Count(key int64) int {
+	i, ok := w.find(key)
+	if !ok {
+		return 0
+	}
+	return w.counts[i]
+}
+
 // Rank returns the count of elements with the key in s[0:i].
+// i is clamped to the range [0, Len()].
 func (w *Int64Keys) Rank(key int64, i int) int {
-	code := w.codes[key]
-	if code == "" {
+	i = clamp(i, w.n)
+	if w.root != nil && w.root.shallow() {
+		return w.root.rankShallow(key, i)
+	}
+	k, ok := w.find(key)
+	if !ok {
 		return 0
 	}
-
-	nodes := w.nodes[key]
-	for j := range nodes {
-		if code[j] == '1' {
-			i = nodes[j].Rank1(i)
-		} else {
-			i = nodes[j].Rank0(i)
-		}
+	if w.hot != nil && w.hot[k] != nil {
+		return w.hot[k].Rank1(i)
 	}
-	return i
+
+	return rankPath(w.codes[k], w.nodes[k], i)
 }
 
 // Select returns i such that Rank(c, i) = r.
 // i.e. it returns the index of r-th occurrence of the element with the key.
 // Note that r is 0-origined, so wt.Select('a', 2) returns the index of the third 'a'.
+// Errors are reported as selected by Options.ErrorMode, except that Select on an empty tree
+// always returns -1.
 func (w *Int64Keys) Select(key int64, r int) int {
-	code := w.codes[key]
-	if code == "" {
+	if w.n == 0 {
+		return -1
+	}
+	k, ok := w.find(key)
+	if w.errorMode == ReturnNotFound && (r < 0 || !ok || r >= w.counts[k]) {
+		return -1
+	}
+	if !ok {
 		panic(fmt.Sprintf("wltree: no such element with key %v in s.", key))
 	}
+	if w.root.shallow() {
+		return w.root.selectShallow(key, r)
+	}
 
-	nodes := w.nodes[key]
+	code, nodes := w.codes[k], w.nodes[k]
 	for j := len(nodes) - 1; j >= 0; j-- {
-		if code[j] == '1' {
+		if code.bit(j) {
 			r = nodes[j].Select1(r)
 		} else {
 			r = nodes[j].Select0(r)
@@ -138,55 +307,262 @@ func (w *Int64Keys) Select(key int64, r int) int {
 	return r
 }
 
+// SelectChecked is like Select, but reports false instead of panicking or returning garbage when
+// s has no r-th occurrence of the key.
+func (w *Int64Keys) SelectChecked(key int64, r int) (int, bool) {
+	if r < 0 || r >= w.Count(key) {
+		return 0, false
+	}
+	return w.Select(key, r), true
+}
+
+// Access returns the key of s[i]. It panics if i is out of range.
+func (w *Int64Keys) Access(i int) int64 {
+	if i < 0 || i >= w.n {
+		panic(fmt.Sprintf("wltree: index %v out of range [0, %v)", i, w.n))
+	}
+	return w.root.access(i)
+}
+
 // Bytes represents a Wavelet Tree on bytestring.
 type Bytes struct {
-	nodes [256][]*bitvector.BitVector
-	codes [256]string
+	nodes [256][]rankSelect
+	codes [256]code
+	root  *node
+	// hot holds the rank caches of the characters given to CacheRanks.
+	hot [256]*packedBits
+
+	// keyset and counts are the distinct characters in ascending order and their occurrences.
+	keyset []byte
+	counts []int
+	n      int
+
+	errorMode ErrorMode
+	// opts are as for Int64Keys.
+	opts *Options
 }
 
 // NewBytes constructs a Wavelet Tree from bytestring.
 func NewBytes(s []byte) *Bytes {
-	intKeys := NewInt64Keys(byteSlice(s))
-	b := &Bytes{}
-	for i, nodes := range intKeys.nodes {
-		b.nodes[i] = nodes
+	return newBytes(context.Background(), s, nil, nil, new(scratch))
+}
+
+// NewBytesAlphabet is like NewBytes, but also assigns codes to the characters in alphabet that do
+// not occur in s, so that they are known to the tree with zero occurrences.
+func NewBytesAlphabet(s, alphabet []byte) *Bytes {
+	return newBytes(context.Background(), s, alphabet, nil, new(scratch))
+}
+
+// newBytes makes a Wavelet Tree on s that also knows the characters in alphabet, configured by
+// opts, with the scratch memory sc. Unless the nodes are encoded otherwise, it counts the
+// characters into an array and numbers the nodes, so that indexing s takes a few array accesses
+// per bit instead of map lookups by code prefix. It returns nil once ctx is done.
+func newBytes(ctx context.Context, s, alphabet []byte, opts *Options, sc *scratch) *Bytes {
+	if opts != nil && (opts.RunLength || opts.Sparse) {
+		keys := make([]int64, len(alphabet))
+		for i, c := range alphabet {
+			keys[i] = int64(c)
+		}
+		keyset, counts := freqOf(byteSlice(s), opts.workers(len(s)), opts.progress(ctx, PhaseCount))
+		keyset, counts = withAlphabet(keyset, counts, keys)
+		w := newShapedInt64Keys(ctx, all(byteSlice(s)), keyset, counts, opts, sc)
+		if w == nil {
+			return nil
+		}
+		w.configure(opts)
+		return bytesFrom(w)
+	}
+	freqs := countBytes(s, opts.workers(len(s)), opts.progress(ctx, PhaseCount))
+	if ctx.Err() != nil {
+		return nil
+	}
+	x := newByteIndexer(&freqs, alphabet, opts, sc)
+
+	// Each node, and thus each element of next, belongs to a single level, so the w-th worker can
+	// fill every n-th level from the w-th on its own. The first worker reports the progress.
+	p := opts.progress(ctx, PhaseSet)
+	parallel(opts.workers(x.levels()), func(w, n int) {
+		p.steps(0, len(s), w == 0, func(lo, hi int) {
+			for _, c := range s[lo:hi] {
+				x.add(c, w, n)
+			}
+		})
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return x.build(ctx, opts)
+}
+
+// byteIndexer indexes a sequence of bytes one character at a time, with the nodes numbered so that
+// each bit takes a few array accesses instead of map lookups by code prefix.
+type byteIndexer struct {
+	keyset []int64
+	counts []int
+	codes  []string
+	sizes  map[string]int
+	b      *levelBuilder
+
+	// packed is the code of each character, paths the numbers of the nodes on its path, and next
+	// the position in its level of the next bit of each node.
+	packed [256]code
+	paths  *[256][]int
+	next   []int
+}
+
+// newByteIndexer returns a byteIndexer for a sequence with the character counts freqs, that also
+// knows the characters in alphabet, configured by opts, with the scratch memory sc.
+func newByteIndexer(freqs *[256]int, alphabet []byte, opts *Options, sc *scratch) *byteIndexer {
+	var known [256]bool
+	for _, c := range alphabet {
+		known[c] = true
+	}
+	x := &byteIndexer{paths: &sc.paths}
+	for c, count := range freqs {
+		if count > 0 || known[c] {
+			x.keyset = append(x.keyset, int64(c))
+			x.counts = append(x.counts, count)
+		}
+	}
+	if len(x.counts) > 0 {
+		x.codes = opts.codes(x.counts)
+	}
+	x.sizes = nodeSizes(x.counts, x.codes)
+	x.b = newLevelBuilder(x.sizes, opts.levelBackend(x.sizes))
+
+	for k := range x.paths {
+		x.paths[k] = x.paths[k][:0]
+	}
+	ids := reuse(&sc.ids)
+	next := sc.next[:0]
+	for i, c := range x.codes {
+		k := x.keyset[i]
+		x.packed[k] = packCode(c)
+		for j := range c {
+			id, ok := ids[c[:j]]
+			if !ok {
+				id = len(next)
+				ids[c[:j]] = id
+				next = append(next, x.b.offsets[c[:j]])
+			}
+			x.paths[k] = append(x.paths[k], id)
+		}
+	}
+	sc.next = next
+	x.next = next
+	return x
+}
+
+// levels returns the number of levels of the tree.
+func (x *byteIndexer) levels() int {
+	return len(x.b.builders)
+}
+
+// add indexes c as the next character of the sequence, in every n-th level from the w-th.
+func (x *byteIndexer) add(c byte, w, n int) {
+	code, path := x.packed[c], x.paths[c]
+	for j := w; j < len(path); j += n {
+		if code.bit(j) {
+			x.b.builders[j].Set(x.next[path[j]])
+		}
+		x.next[path[j]]++
 	}
-	for i, code := range intKeys.codes {
-		b.codes[i] = code
+}
+
+// build returns the Wavelet Tree on the characters added, configured by opts. It returns nil once
+// ctx is done.
+func (x *byteIndexer) build(ctx context.Context, opts *Options) *Bytes {
+	x.b.progress = opts.progress(ctx, PhaseBuild)
+	bvs := x.b.build()
+	if bvs == nil {
+		return nil
+	}
+	w := assemble(x.keyset, x.counts, x.codes, bvs, x.sizes)
+	w.configure(opts)
+	return bytesFrom(w)
+}
+
+// bytesFrom converts a Wavelet Tree on int64 keys that are all bytes into Bytes.
+func bytesFrom(intKeys *Int64Keys) *Bytes {
+	b := &Bytes{
+		root:      intKeys.root,
+		counts:    intKeys.counts,
+		n:         intKeys.n,
+		errorMode: intKeys.errorMode,
+		opts:      intKeys.opts,
+	}
+	for i, k := range intKeys.keyset {
+		b.keyset = append(b.keyset, byte(k))
+		b.nodes[k] = intKeys.nodes[i]
+		b.codes[k] = intKeys.codes[i]
 	}
 	return b
 }
 
+// Contains reports whether the character c is known to the tree, that is, whether it occurs in s
+// or was given in the alphabet at construction.
+func (w *Bytes) Contains(c byte) bool {
+	// The only character of a single-character tree sits at the root and has the empty code.
+	if w.root != nil && w.root.leaf() {
+		return w.root.key == int64(c)
+	}
+	return w.codes[c].len > 0
+}
+
+// Symbols returns the characters known to the tree in ascending order, and the number of
+// occurrences of each of them in s.
+func (w *Bytes) Symbols() (cs []byte, counts []int) {
+	return append([]byte(nil), w.keyset...), append([]int(nil), w.counts...)
+}
+
+// Len returns the length of s.
+func (w *Bytes) Len() int {
+	return w.n
+}
+
+// Count returns the count of the character c in s.
+func (w *Bytes) Count(c byte) int {
+	i := sort.Search(len(w.keyset), func(i int) bool { return w.keyset[i] >= c })
+	if i == len(w.keyset) || w.keyset[i] != c {
+		return 0
+	}
+	return w.counts[i]
+}
+
 // Rank returns the count of the character c in s[0:i].
+// i is clamped to the range [0, Len()].
 func (w *Bytes) Rank(c byte, i int) int {
-	code := w.codes[c]
-	if code == "" {
+	i = clamp(i, w.n)
+	if !w.Contains(c) {
 		return 0
 	}
-
-	nodes := w.nodes[c]
-	for j := range nodes {
-		if code[j] == '1' {
-			i = nodes[j].Rank1(i)
-		} else {
-			i = nodes[j].Rank0(i)
-		}
+	if w.root.shallow() {
+		return w.root.rankShallow(int64(c), i)
 	}
-	return i
+	if h := w.hot[c]; h != nil {
+		return h.Rank1(i)
+	}
+	return rankPath(w.codes[c], w.nodes[c], i)
 }
 
 // Select returns i such that Rank(c, i) = r.
 // i.e. it returns the index of r-th occurrence of the character c.
 // Note that r is 0-origined, so wt.Select('a', 2) returns the index of the third 'a'.
+// Errors are reported as selected by Options.ErrorMode, except that Select on an empty tree
+// always returns -1.
 func (w *Bytes) Select(c byte, r int) int {
-	code := w.codes[c]
-	if code == "" {
+	if w.n == 0 || w.errorMode == ReturnNotFound && (r < 0 || r >= w.Count(c)) {
+		return -1
+	}
+	if !w.Contains(c) {
 		panic(fmt.Sprintf("wltree: no such character %q in s.", string(c)))
 	}
-
-	nodes := w.nodes[c]
+	if w.root.shallow() {
+		return w.root.selectShallow(int64(c), r)
+	}
+	code, nodes := w.codes[c], w.nodes[c]
 	for j := len(nodes) - 1; j >= 0; j-- {
-		if code[j] == '1' {
+		if code.bit(j) {
 			r = nodes[j].Select1(r)
 		} else {
 			r = nodes[j].Select0(r)
@@ -195,16 +571,207 @@ func (w *Bytes) Select(c byte, r int) int {
 	return r
 }
 
-func freq(s Interface) (keyset []int64, counts []int) {
-	freqs := make(map[int64]int)
-	for i, size := 0, s.Len(); i < size; i++ {
-		freqs[s.Key(i)]++
+// SelectChecked is like Select, but reports false instead of panicking or returning garbage when
+// s has no r-th occurrence of the character c.
+func (w *Bytes) SelectChecked(c byte, r int) (int, bool) {
+	if r < 0 || r >= w.Count(c) {
+		return 0, false
+	}
+	return w.Select(c, r), true
+}
+
+// Access returns s[i]. It panics if i is out of range.
+func (w *Bytes) Access(i int) byte {
+	if i < 0 || i >= w.n {
+		panic(fmt.Sprintf("wltree: index %v out of range [0, %v)", i, w.n))
+	}
+	return byte(w.root.access(i))
+}
+
+// Extract returns a copy of s[l:r], with l and r clamped as by the range queries.
+func (w *Bytes) Extract(l, r int) []byte {
+	l, r = clampRange(l, r, w.n)
+	s := make([]byte, 0, r-l)
+	for c := range w.Iter(l, r) {
+		s = append(s, c)
+	}
+	return s
+}
+
+// clamp returns i clamped to the range [0, n].
+func clamp(i, n int) int {
+	if i < 0 {
+		return 0
+	}
+	if i > n {
+		return n
+	}
+	return i
+}
+
+// clampRange clamps l and r to the range [0, n], and r to be no less than l, so that [l, r) is a
+// valid, possibly empty, range.
+func clampRange(l, r, n int) (int, int) {
+	l = clamp(l, n)
+	r = clamp(r, n)
+	if r < l {
+		r = l
+	}
+	return l, r
+}
+
+// rankSelect is a bit vector that supports rank and select, such as a RankSelect.
+type rankSelect interface {
+	Len() int
+	Rank0(i int) int
+	Rank1(i int) int
+	Select0(r int) int
+	Select1(r int) int
+}
+
+// node is a node of the wavelet tree. Internal nodes hold the BitVector that routes each
+// element to child[0] or child[1], and leaves hold the key of a single element instead.
+// size is the number of elements routed through the node, and lo and hi are the smallest and
+// largest keys in its subtree.
+type node struct {
+	bv     rankSelect
+	child  [2]*node
+	key    int64
+	size   int
+	lo, hi int64
+}
+
+func (n *node) leaf() bool {
+	return n.bv == nil
+}
+
+// shallow reports whether n is a leaf or the parent of two leaves, as the root of a tree on at
+// most two keys is. Queries on such a tree are answered from the root alone.
+func (n *node) shallow() bool {
+	return n.leaf() || n.child[0].leaf() && n.child[1].leaf()
+}
+
+// rankShallow returns the number of keys equal to key in the first i elements of shallow n.
+func (n *node) rankShallow(key int64, i int) int {
+	switch {
+	case n.leaf():
+		if n.key == key {
+			return i
+		}
+	case n.child[0].key == key:
+		return n.bv.Rank0(i)
+	case n.child[1].key == key:
+		return n.bv.Rank1(i)
+	}
+	return 0
+}
+
+// selectShallow returns the position of the r-th key equal to key, which must be known, in
+// shallow n.
+func (n *node) selectShallow(key int64, r int) int {
+	switch {
+	case n.leaf():
+		return r
+	case n.child[0].key == key:
+		return n.bv.Select0(r)
+	default:
+		return n.bv.Select1(r)
+	}
+}
+
+// link builds the tree of nodes from the BitVectors and sizes of the internal nodes, indexed by
+// their code prefix, and the counts and codes of the keys at the leaves. It returns nil for an
+// empty keyset.
+func link(bvs map[string]rankSelect, sizes map[string]int,
+	keyset []int64, counts []int, codes []string) *node {
+	nodes := make(map[string]*node)
+	for prefix, bv := range bvs {
+		nodes[prefix] = &node{bv: bv, size: sizes[prefix]}
+	}
+	for i, k := range keyset {
+		nodes[codes[i]] = &node{key: k, size: counts[i]}
+	}
+	for prefix, n := range nodes {
+		if prefix != "" {
+			parent := nodes[prefix[:len(prefix)-1]]
+			parent.child[prefix[len(prefix)-1]-'0'] = n
+		}
+	}
+	root := nodes[""]
+	if root != nil {
+		root.bound()
+	}
+	return root
+}
+
+// bound sets lo and hi of n and all its descendants.
+func (n *node) bound() {
+	if n.leaf() {
+		n.lo, n.hi = n.key, n.key
+		return
+	}
+	n.child[0].bound()
+	n.child[1].bound()
+	n.lo, n.hi = n.child[0].lo, n.child[0].hi
+	if n.child[1].lo < n.lo {
+		n.lo = n.child[1].lo
+	}
+	if n.child[1].hi > n.hi {
+		n.hi = n.child[1].hi
+	}
+}
+
+// freq returns the distinct keys of seq and the number of occurrences of each of them.
+func freq(seq iter.Seq[int64]) (keyset []int64, counts []int) {
+	return new(scratch).freq(seq)
+}
+
+// sortFreq sorts keyset in ascending order, along with the counts of the keys.
+func sortFreq(keyset []int64, counts []int) {
+	sort.Sort(freqs{keyset, counts})
+}
+
+type freqs struct {
+	keyset []int64
+	counts []int
+}
+
+func (f freqs) Len() int {
+	return len(f.keyset)
+}
+func (f freqs) Less(i, j int) bool {
+	return f.keyset[i] < f.keyset[j]
+}
+func (f freqs) Swap(i, j int) {
+	f.keyset[i], f.keyset[j] = f.keyset[j], f.keyset[i]
+	f.counts[i], f.counts[j] = f.counts[j], f.counts[i]
+}
+
+// withAlphabet adds the keys in alphabet missing from keyset to it with zero counts.
+func withAlphabet(keyset []int64, counts []int, alphabet []int64) ([]int64, []int) {
+	known := make(map[int64]bool)
+	for _, k := range keyset {
+		known[k] = true
 	}
-	for k, w := range freqs {
-		keyset = append(keyset, k)
-		counts = append(counts, w)
+	for _, k := range alphabet {
+		if !known[k] {
+			known[k] = true
+			keyset = append(keyset, k)
+			counts = append(counts, 0)
+		}
+	}
+	return keyset, counts
+}
+
+// all returns the sequence of keys of s.
+func all(s Interface) iter.Seq[int64] {
+	return func(yield func(int64) bool) {
+		for i, size := 0, s.Len(); i < size; i++ {
+			if !yield(s.Key(i)) {
+				return
+			}
+		}
 	}
-	return
 }
 
 type byteSlice []byte
@@ -215,3 +782,27 @@ func (b byteSlice) Len() int {
 func (b byteSlice) Key(i int) int64 {
 	return int64(b[i])
 }
+
+type slice[T any] struct {
+	s   []T
+	key func(T) int64
+}
+
+func (s slice[T]) Len() int {
+	return len(s.s)
+}
+func (s slice[T]) Key(i int) int64 {
+	return s.key(s.s[i])
+}
+
+type funcKeys struct {
+	n   int
+	key func(i int) int64
+}
+
+func (f funcKeys) Len() int {
+	return f.n
+}
+func (f funcKeys) Key(i int) int64 {
+	return f.key(i)
+}
@@ -1,7 +1,12 @@
 package wltree
 
 import (
+	"fmt"
+	"iter"
+	"math"
 	"math/rand"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -85,3 +90,403 @@ func random(size int, weights map[byte]int) []byte {
 
 	return bs
 }
+
+func TestNewBytes(t *testing.T) {
+	for _, opts := range []*Options{nil, {Shape: AlphabeticShape}, {Arena: true}, {RankOnly: true}, {Sparse: true}} {
+		for size := 0; size < maxSize; size += 7 {
+			for _, ws := range weights {
+				bs := random(size, ws)
+				want := bytesFrom(NewInt64KeysWithOptions(byteSlice(bs), opts))
+				if got := NewBytesWithOptions(bs, opts); !got.Equal(want) {
+					t.Errorf("%+v: NewBytesWithOptions(%q) => differs from NewInt64KeysWithOptions", opts, bs)
+				}
+			}
+		}
+	}
+	s, alphabet := []byte("abracadabra"), []byte("abcdefxyz")
+	keys := make([]int64, len(alphabet))
+	for i, c := range alphabet {
+		keys[i] = int64(c)
+	}
+	if got, want := NewBytesAlphabet(s, alphabet), bytesFrom(NewInt64KeysAlphabet(byteSlice(s), keys)); !got.Equal(want) {
+		t.Errorf("NewBytesAlphabet(%q, %q) => differs from NewInt64KeysAlphabet", s, alphabet)
+	}
+}
+
+func TestNewSlice(t *testing.T) {
+	words := []string{"a", "bb", "cc", "ddd", "e", "ff"}
+	wt := NewSlice(words, func(w string) int64 { return int64(len(w)) })
+	for i, want := range []int{0, 1, 1, 1, 1, 2, 2} {
+		if got := wt.Rank(1, i); got != want {
+			t.Errorf("Rank(1, %v) => got %v, want %v", i, got, want)
+		}
+	}
+	if got, want := wt.Select(2, 2), 5; got != want {
+		t.Errorf("Select(2, 2) => got %v, want %v", got, want)
+	}
+}
+
+func TestNewFunc(t *testing.T) {
+	type point struct{ x, y int }
+	ps := []point{{1, 2}, {3, 2}, {1, 5}, {2, 2}, {1, 1}}
+	wt := NewFunc(len(ps), func(i int) int64 { return int64(ps[i].x) })
+	for i, want := range []int{0, 1, 1, 2, 2, 3} {
+		if got := wt.Rank(1, i); got != want {
+			t.Errorf("Rank(1, %v) => got %v, want %v", i, got, want)
+		}
+	}
+	if got, want := wt.Select(1, 2), 4; got != want {
+		t.Errorf("Select(1, 2) => got %v, want %v", got, want)
+	}
+}
+
+func TestNewSeq(t *testing.T) {
+	seq := func(yield func(int64) bool) {
+		for i := int64(0); i < 100; i++ {
+			if !yield(i * i % 7) {
+				return
+			}
+		}
+	}
+	wt := NewSeq(seq)
+
+	counts := make(map[int64]int)
+	i := 0
+	for k := range seq {
+		if got, want := wt.Rank(k, i), counts[k]; got != want {
+			t.Errorf("Rank(%v, %v) => got %v, want %v", k, i, got, want)
+		}
+		if got, want := wt.Select(k, counts[k]), i; got != want {
+			t.Errorf("Select(%v, %v) => got %v, want %v", k, counts[k], got, want)
+		}
+		counts[k]++
+		i++
+	}
+}
+
+func TestNewSeqOneShot(t *testing.T) {
+	// Enough distinct keys to widen the buffered indices twice.
+	for _, size := range []int{0, 1, 100, 1 << 10, 1 << 17} {
+		s := make([]int, size)
+		for i := range s {
+			s[i] = rand.Intn(size+1) - size/2
+		}
+		// oneShot yields s the first time it is ranged over, and nothing afterwards.
+		oneShot := func() iter.Seq[int] {
+			used := false
+			return func(yield func(int) bool) {
+				if used {
+					return
+				}
+				used = true
+				for _, k := range s {
+					if !yield(k) {
+						return
+					}
+				}
+			}
+		}
+		want := NewInts(s)
+		for _, hint := range []int{size / 2, 2 * size} {
+			if got := NewSeqLen(oneShot(), hint); !got.Equal(want) {
+				t.Errorf("NewSeqLen(%v keys, %v) => differs from NewInts", size, hint)
+			}
+		}
+		if got := NewSeq(oneShot()); !got.Equal(want) {
+			t.Errorf("NewSeq(%v keys) => differs from NewInts", size)
+		}
+	}
+}
+
+func TestWithCounts(t *testing.T) {
+	for size := 0; size < maxSize; size += 7 {
+		for _, ws := range weights {
+			bs := random(size, ws)
+			want := NewInt64Keys(byteSlice(bs))
+			keys, counts := want.Symbols()
+			// Any order of the keys will do.
+			rand.Shuffle(len(keys), func(i, j int) {
+				keys[i], keys[j] = keys[j], keys[i]
+				counts[i], counts[j] = counts[j], counts[i]
+			})
+			if got := NewInt64KeysWithCounts(byteSlice(bs), keys, counts); !got.Equal(want) {
+				t.Errorf("NewInt64KeysWithCounts(%q) => differs from NewInt64Keys", bs)
+			}
+		}
+	}
+	wt := NewInt64KeysWithCounts(byteSlice("abracadabra"), []int64{'z', 'a', 'b', 'c', 'd', 'r'}, []int{0, 5, 2, 1, 1, 2})
+	if !wt.Contains('z') || wt.Count('z') != 0 {
+		t.Errorf("NewInt64KeysWithCounts() with zero count => Contains('z') = %v, Count('z') = %v", wt.Contains('z'), wt.Count('z'))
+	}
+	if got, want := wt.Select('r', 1), 9; got != want {
+		t.Errorf("Select('r', 1) => got %v, want %v", got, want)
+	}
+}
+
+func TestAlphabet(t *testing.T) {
+	s := []byte("abracadabra")
+	wt := NewBytesAlphabet(s, []byte("abcdefxyz"))
+	for i := 0; i <= len(s); i++ {
+		for _, c := range []byte("efxyz") {
+			if got := wt.Rank(c, i); got != 0 {
+				t.Errorf("Rank(%q, %v) => got %v, want 0", c, i, got)
+			}
+		}
+	}
+	if got, want := wt.Rank('a', len(s)), 5; got != want {
+		t.Errorf("Rank('a', %v) => got %v, want %v", len(s), got, want)
+	}
+	if got, want := wt.Rank('r', len(s)), 2; got != want {
+		t.Errorf("Rank('r', %v) => got %v, want %v", len(s), got, want)
+	}
+	if got, want := wt.Select('a', 2), 5; got != want {
+		t.Errorf("Select('a', 2) => got %v, want %v", got, want)
+	}
+	for _, c := range []byte("xyz") {
+		if wt.codes[c].len == 0 {
+			t.Errorf("codes[%q] => got empty code for symbol in alphabet", c)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	for _, s := range []string{"", "a", "aaa", "abracadabra"} {
+		wt := NewBytesAlphabet([]byte(s), []byte("z"))
+		wtb := NewBytes([]byte(s))
+		wti := NewInt64Keys(byteSlice(s))
+		for c := 0; c < 256; c++ {
+			c := byte(c)
+			want := strings.IndexByte(s, c) >= 0
+			if got := wtb.Contains(c); got != want {
+				t.Errorf("Bytes: %q.Contains(%q) => got %v, want %v", s, c, got, want)
+			}
+			if got := wti.Contains(int64(c)); got != want {
+				t.Errorf("IntKeys: %q.Contains(%q) => got %v, want %v", s, c, got, want)
+			}
+			if got := wt.Contains(c); got != (want || c == 'z') {
+				t.Errorf("Bytes: %q.Contains(%q) => got %v, want %v", s, c, got, want || c == 'z')
+			}
+		}
+	}
+}
+
+func TestSymbols(t *testing.T) {
+	s := []byte("abracadabra")
+	cs, counts := NewBytesAlphabet(s, []byte("z")).Symbols()
+	if got, want := string(cs), "abcdrz"; got != want {
+		t.Errorf("Bytes: Symbols() => got characters %q, want %q", got, want)
+	}
+	if got, want := fmt.Sprint(counts), "[5 2 1 1 2 0]"; got != want {
+		t.Errorf("Bytes: Symbols() => got counts %v, want %v", got, want)
+	}
+
+	keys, counts := NewInts([]int{3, -1, 3, 7, 3}).Symbols()
+	if got, want := fmt.Sprint(keys, counts), "[-1 3 7] [1 3 1]"; got != want {
+		t.Errorf("IntKeys: Symbols() => got %v, want %v", got, want)
+	}
+}
+
+func TestLenCount(t *testing.T) {
+	for _, s := range []string{"", "a", "aaa", "abracadabra"} {
+		wt := NewBytes([]byte(s))
+		wti := NewInt64Keys(byteSlice(s))
+		if got, want := wt.Len(), len(s); got != want {
+			t.Errorf("Bytes: %q.Len() => got %v, want %v", s, got, want)
+		}
+		if got, want := wti.Len(), len(s); got != want {
+			t.Errorf("IntKeys: %q.Len() => got %v, want %v", s, got, want)
+		}
+		for c := 0; c < 256; c++ {
+			want := strings.Count(s, string(rune(c)))
+			if got := wt.Count(byte(c)); got != want {
+				t.Errorf("Bytes: %q.Count(%q) => got %v, want %v", s, byte(c), got, want)
+			}
+			if got := wti.Count(int64(c)); got != want {
+				t.Errorf("IntKeys: %q.Count(%v) => got %v, want %v", s, c, got, want)
+			}
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	for _, s := range []string{"", "a", "aaa", "abracadabra"} {
+		wt := NewBytes([]byte(s))
+		for l := -1; l <= len(s)+1; l++ {
+			for r := -1; r <= len(s)+1; r++ {
+				cl, cr := clampRange(l, r, len(s))
+				if got, want := string(wt.Extract(l, r)), s[cl:cr]; got != want {
+					t.Errorf("%q.Extract(%v, %v) => got %q, want %q", s, l, r, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestSelectChecked(t *testing.T) {
+	s := []byte("abracadabra")
+	wt := NewBytesAlphabet(s, []byte("z"))
+	wti := NewInt64Keys(byteSlice(s))
+	for _, tc := range []struct {
+		c    byte
+		r    int
+		want int
+		ok   bool
+	}{
+		{'a', 0, 0, true},
+		{'a', 4, 10, true},
+		{'a', 5, 0, false},
+		{'a', -1, 0, false},
+		{'r', 1, 9, true},
+		{'z', 0, 0, false},
+		{'x', 0, 0, false},
+	} {
+		if got, ok := wt.SelectChecked(tc.c, tc.r); got != tc.want || ok != tc.ok {
+			t.Errorf("Bytes: SelectChecked(%q, %v) => got (%v, %v), want (%v, %v)", tc.c, tc.r, got, ok, tc.want, tc.ok)
+		}
+		if got, ok := wti.SelectChecked(int64(tc.c), tc.r); got != tc.want || ok != tc.ok {
+			t.Errorf("IntKeys: SelectChecked(%q, %v) => got (%v, %v), want (%v, %v)", tc.c, tc.r, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestRankBounds(t *testing.T) {
+	s := []byte("abracadabra")
+	wt := NewBytes(s)
+	wti := NewInt64Keys(byteSlice(s))
+	for _, tc := range []struct{ i, want int }{
+		{-1, 0},
+		{-100, 0},
+		{len(s) + 1, 5},
+		{math.MaxInt, 5},
+	} {
+		if got := wt.Rank('a', tc.i); got != tc.want {
+			t.Errorf("Bytes: Rank('a', %v) => got %v, want %v", tc.i, got, tc.want)
+		}
+		if got := wti.Rank('a', tc.i); got != tc.want {
+			t.Errorf("IntKeys: Rank('a', %v) => got %v, want %v", tc.i, got, tc.want)
+		}
+	}
+	if got, want := wt.DistinctCount(-5, 100), 5; got != want {
+		t.Errorf("Bytes: DistinctCount(-5, 100) => got %v, want %v", got, want)
+	}
+	if got, want := wt.DistinctCount(5, 2), 0; got != want {
+		t.Errorf("Bytes: DistinctCount(5, 2) => got %v, want %v", got, want)
+	}
+}
+
+func TestSingleSymbol(t *testing.T) {
+	for size := 1; size < 20; size++ {
+		s := []byte(strings.Repeat("x", size))
+		wt := NewBytes(s)
+		wti := NewInt64Keys(byteSlice(s))
+		for i := 0; i <= size; i++ {
+			if got := wt.Rank('x', i); got != i {
+				t.Errorf("Bytes: %q.Rank('x', %v) => got %v, want %v", s, i, got, i)
+			}
+			if got := wti.Rank('x', i); got != i {
+				t.Errorf("IntKeys: %q.Rank('x', %v) => got %v, want %v", s, i, got, i)
+			}
+			if got := wt.Rank('y', i); got != 0 {
+				t.Errorf("Bytes: %q.Rank('y', %v) => got %v, want 0", s, i, got)
+			}
+			if i < size {
+				if got := wt.Select('x', i); got != i {
+					t.Errorf("Bytes: %q.Select('x', %v) => got %v, want %v", s, i, got, i)
+				}
+				if got := wti.Select('x', i); got != i {
+					t.Errorf("IntKeys: %q.Select('x', %v) => got %v, want %v", s, i, got, i)
+				}
+			}
+		}
+	}
+}
+
+func TestTwoSymbols(t *testing.T) {
+	for size := 1; size < 100; size++ {
+		s := random(size, map[byte]int{'x': 3, 'y': 1})
+		for _, wt := range []*Bytes{NewBytes(s), NewBytesAlphabet(s, []byte("xy"))} {
+			if !wt.root.shallow() {
+				t.Fatalf("%q: root is not shallow", s)
+			}
+			var counts [256]int
+			for i := 0; i <= len(s); i++ {
+				for _, c := range []byte("xyz") {
+					if got, want := wt.Rank(c, i), counts[c]; got != want {
+						t.Errorf("%q.Rank(%q, %v) => got %v, want %v", s, c, i, got, want)
+					}
+				}
+				if i != len(s) {
+					c := s[i]
+					if got, want := wt.Select(c, counts[c]), i; got != want {
+						t.Errorf("%q.Select(%q, %v) => got %v, want %v", s, c, counts[c], got, want)
+					}
+					counts[c]++
+				}
+			}
+		}
+	}
+
+	// A key known only from the alphabet has no occurrences.
+	wt := NewBytesAlphabet([]byte("xxx"), []byte("y"))
+	if got := wt.Rank('y', 3); got != 0 {
+		t.Errorf("Rank('y', 3) => got %v, want 0", got)
+	}
+	if _, ok := wt.SelectChecked('y', 0); ok {
+		t.Errorf("SelectChecked('y', 0) => got ok")
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	wt := NewBytes(nil)
+	wti := NewInt64Keys(byteSlice{})
+	wtr := NewString("")
+	if wt.Len() != 0 || wti.Len() != 0 || wtr.Len() != 0 {
+		t.Errorf("Len() => got %v, %v, %v, want 0", wt.Len(), wti.Len(), wtr.Len())
+	}
+	for _, i := range []int{-1, 0, 1} {
+		if got := wt.Rank('a', i); got != 0 {
+			t.Errorf("Bytes: Rank('a', %v) => got %v, want 0", i, got)
+		}
+		if got := wti.Rank('a', i); got != 0 {
+			t.Errorf("IntKeys: Rank('a', %v) => got %v, want 0", i, got)
+		}
+		if got := wtr.Rank('a', i); got != 0 {
+			t.Errorf("Runes: Rank('a', %v) => got %v, want 0", i, got)
+		}
+	}
+	if got := wt.Select('a', 0); got != -1 {
+		t.Errorf("Bytes: Select('a', 0) => got %v, want -1", got)
+	}
+	if got := wti.Select('a', 0); got != -1 {
+		t.Errorf("IntKeys: Select('a', 0) => got %v, want -1", got)
+	}
+	if got := wtr.Select('a', 0); got != -1 {
+		t.Errorf("Runes: Select('a', 0) => got %v, want -1", got)
+	}
+	if c, count := wt.Mode(0, 0); c != 0 || count != 0 {
+		t.Errorf("Bytes: Mode(0, 0) => got (%q, %v), want (0, 0)", c, count)
+	}
+}
+
+func TestTooLong(t *testing.T) {
+	defer func(n int) { maxLen = n }(maxLen)
+	maxLen = 10
+
+	if _, err := NewBytesFromReader(strings.NewReader("abracadabra")); err != ErrTooLong {
+		t.Errorf("NewBytesFromReader(11 bytes) => got error %v, want %v", err, ErrTooLong)
+	}
+	if _, err := NewBytesFromReader(strings.NewReader("abracadabr")); err != nil {
+		t.Errorf("NewBytesFromReader(10 bytes) => got error %v, want nil", err)
+	}
+	func() {
+		defer func() {
+			if err := recover(); err != ErrTooLong {
+				t.Errorf("NewSeq(11 keys) => got panic %v, want %v", err, ErrTooLong)
+			}
+		}()
+		NewSeq(slices.Values(make([]int, 11)))
+	}()
+	if got := NewSeq(slices.Values(make([]int, 10))).Len(); got != 10 {
+		t.Errorf("NewSeq(10 keys).Len() => got %v, want 10", got)
+	}
+}